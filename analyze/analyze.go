@@ -0,0 +1,349 @@
+package analyze
+
+import (
+	"sort"
+
+	"github.com/rvsingh011/alien-invasion/simulation"
+)
+
+// ExactDiameterCities is the size of the largest component whose diameter is computed exactly, larger components are estimated
+const ExactDiameterCities = 5000
+
+// number of sweeps estimating the diameter of a large component
+const diameterSweeps = 4
+
+/*
+	Analysis describes the shape of a world, its roads are taken as two way roads.
+	Components are sorted from the largest, Bridges are the roads whose loss splits a component and Articulation the cities
+	whose loss splits a component. Degrees counts the cities by number of roads. Diameter is the longest shortest path of
+	all the components, DiameterExact is false when it was estimated on a component of more than ExactDiameterCities cities.
+*/
+type Analysis struct {
+	Cities        int         `json:"cities"`
+	Roads         int         `json:"roads"`
+	Components    [][]string  `json:"components"`
+	Bridges       [][2]string `json:"bridges"`
+	Articulation  []string    `json:"articulationCities"`
+	Degrees       map[int]int `json:"degrees"`
+	Diameter      int         `json:"diameter"`
+	DiameterExact bool        `json:"diameterExact"`
+	Isolated      []string    `json:"isolated"`
+}
+
+/*
+	graph is a world with the cities interned by name order and every road listed from both of its cities.
+*/
+type graph struct {
+	names      []string
+	neighbours [][]int32
+}
+
+/*
+	newGraph builds the graph of a world, roads to cities which are not in the world and roads from a city to itself are ignored.
+*/
+func newGraph(world map[string][]*simulation.City) *graph {
+	g := &graph{names: make([]string, 0, len(world))}
+	for city := range world {
+		g.names = append(g.names, city)
+	}
+	sort.Strings(g.names)
+	index := make(map[string]int32, len(g.names))
+	for id, name := range g.names {
+		index[name] = int32(id)
+	}
+
+	g.neighbours = make([][]int32, len(g.names))
+	for from, name := range g.names {
+		for _, link := range world[name] {
+			to, ok := index[link.Name]
+			if !ok || to == int32(from) {
+				continue
+			}
+			g.neighbours[from] = append(g.neighbours[from], to)
+			g.neighbours[to] = append(g.neighbours[to], int32(from))
+		}
+	}
+	// a road listed from both cities is the same road
+	for city, neighbours := range g.neighbours {
+		sort.Slice(neighbours, func(i, j int) bool { return neighbours[i] < neighbours[j] })
+		unique := neighbours[:0]
+		for idx, neighbour := range neighbours {
+			if idx == 0 || neighbour != neighbours[idx-1] {
+				unique = append(unique, neighbour)
+			}
+		}
+		g.neighbours[city] = unique
+	}
+	return g
+}
+
+/*
+	World analyses a world map, e.g. Simulation.World or WorldSnapshot.World.
+*/
+func World(world map[string][]*simulation.City) *Analysis {
+	g := newGraph(world)
+	analysis := &Analysis{
+		Cities:        len(g.names),
+		Components:    make([][]string, 0),
+		Bridges:       make([][2]string, 0),
+		Articulation:  make([]string, 0),
+		Degrees:       make(map[int]int),
+		DiameterExact: true,
+		Isolated:      make([]string, 0),
+	}
+	for city, neighbours := range g.neighbours {
+		analysis.Roads += len(neighbours)
+		analysis.Degrees[len(neighbours)]++
+		if len(neighbours) == 0 {
+			analysis.Isolated = append(analysis.Isolated, g.names[city])
+		}
+	}
+	analysis.Roads /= 2
+
+	components := g.components()
+	distances := newDistances(len(g.names))
+	for _, component := range components {
+		diameter, exact := g.diameter(component, distances)
+		if diameter > analysis.Diameter {
+			analysis.Diameter = diameter
+		}
+		analysis.DiameterExact = analysis.DiameterExact && exact
+		analysis.Components = append(analysis.Components, g.sortedNames(component))
+	}
+	sort.SliceStable(analysis.Components, func(i, j int) bool {
+		return len(analysis.Components[i]) > len(analysis.Components[j])
+	})
+
+	bridges, articulation := g.cuts()
+	for _, bridge := range bridges {
+		from, to := g.names[bridge[0]], g.names[bridge[1]]
+		if to < from {
+			from, to = to, from
+		}
+		analysis.Bridges = append(analysis.Bridges, [2]string{from, to})
+	}
+	sort.Slice(analysis.Bridges, func(i, j int) bool {
+		if analysis.Bridges[i][0] != analysis.Bridges[j][0] {
+			return analysis.Bridges[i][0] < analysis.Bridges[j][0]
+		}
+		return analysis.Bridges[i][1] < analysis.Bridges[j][1]
+	})
+	analysis.Articulation = g.sortedNames(articulation)
+	return analysis
+}
+
+/*
+	sortedNames returns the names of cities sorted, cities are interned in name order.
+*/
+func (g *graph) sortedNames(cities []int32) []string {
+	sorted := append([]int32(nil), cities...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	names := make([]string, 0, len(sorted))
+	for _, city := range sorted {
+		names = append(names, g.names[city])
+	}
+	return names
+}
+
+/*
+	components returns the cities of every connected component, in the order their first city is found.
+*/
+func (g *graph) components() [][]int32 {
+	seen := make([]bool, len(g.names))
+	components := make([][]int32, 0)
+	for start := range g.names {
+		if seen[start] {
+			continue
+		}
+		seen[start] = true
+		component := []int32{int32(start)}
+		for idx := 0; idx < len(component); idx++ {
+			for _, neighbour := range g.neighbours[component[idx]] {
+				if !seen[neighbour] {
+					seen[neighbour] = true
+					component = append(component, neighbour)
+				}
+			}
+		}
+		components = append(components, component)
+	}
+	return components
+}
+
+/*
+	cuts finds the bridges and the articulation cities with Tarjan's low links.
+	The depth first search keeps its own stack, a long chain of cities would overflow the goroutine stack otherwise.
+*/
+func (g *graph) cuts() ([][2]int32, []int32) {
+	count := len(g.names)
+	discovered := make([]int32, count)
+	low := make([]int32, count)
+	parent := make([]int32, count)
+	next := make([]int, count)
+	cut := make([]bool, count)
+	bridges := make([][2]int32, 0)
+	clock := int32(0)
+
+	for root := range g.names {
+		if discovered[root] != 0 {
+			continue
+		}
+		clock++
+		discovered[root], low[root], parent[root] = clock, clock, -1
+		children := 0
+		stack := []int32{int32(root)}
+		for len(stack) > 0 {
+			city := stack[len(stack)-1]
+			if next[city] < len(g.neighbours[city]) {
+				neighbour := g.neighbours[city][next[city]]
+				next[city]++
+				if discovered[neighbour] == 0 {
+					clock++
+					discovered[neighbour], low[neighbour], parent[neighbour] = clock, clock, city
+					stack = append(stack, neighbour)
+					if city == int32(root) {
+						children++
+					}
+				} else if neighbour != parent[city] && discovered[neighbour] < low[city] {
+					low[city] = discovered[neighbour]
+				}
+				continue
+			}
+
+			stack = stack[:len(stack)-1]
+			above := parent[city]
+			if above < 0 {
+				continue
+			}
+			if low[city] < low[above] {
+				low[above] = low[city]
+			}
+			if low[city] > discovered[above] {
+				bridges = append(bridges, [2]int32{above, city})
+			}
+			if above != int32(root) && low[city] >= discovered[above] {
+				cut[above] = true
+			}
+		}
+		if children > 1 {
+			cut[root] = true
+		}
+	}
+
+	articulation := make([]int32, 0)
+	for city, isCut := range cut {
+		if isCut {
+			articulation = append(articulation, int32(city))
+		}
+	}
+	return bridges, articulation
+}
+
+/*
+	distances holds the distances of a breadth first search, only the cities reached are reset before the next search.
+*/
+type distances struct {
+	hops  []int32
+	queue []int32
+}
+
+func newDistances(count int) *distances {
+	hops := make([]int32, count)
+	for city := range hops {
+		hops[city] = -1
+	}
+	return &distances{hops: hops}
+}
+
+/*
+	farthest returns the city farthest from a city and its distance.
+*/
+func (g *graph) farthest(from int32, distances *distances) (int32, int) {
+	for _, city := range distances.queue {
+		distances.hops[city] = -1
+	}
+	distances.queue = append(distances.queue[:0], from)
+	distances.hops[from] = 0
+	far := from
+	for idx := 0; idx < len(distances.queue); idx++ {
+		city := distances.queue[idx]
+		if distances.hops[city] > distances.hops[far] {
+			far = city
+		}
+		for _, neighbour := range g.neighbours[city] {
+			if distances.hops[neighbour] < 0 {
+				distances.hops[neighbour] = distances.hops[city] + 1
+				distances.queue = append(distances.queue, neighbour)
+			}
+		}
+	}
+	return far, int(distances.hops[far])
+}
+
+/*
+	diameter returns the longest shortest path of a component.
+	It searches from every city of a small component, and sweeps from the farthest city found in a large one, which gives a
+	lower bound that is exact on trees and close to it on grids.
+*/
+func (g *graph) diameter(component []int32, distances *distances) (int, bool) {
+	longest := 0
+	if len(component) <= ExactDiameterCities {
+		for _, city := range component {
+			if _, hops := g.farthest(city, distances); hops > longest {
+				longest = hops
+			}
+		}
+		return longest, true
+	}
+	from := component[0]
+	for sweep := 0; sweep < diameterSweeps; sweep++ {
+		far, hops := g.farthest(from, distances)
+		if hops > longest {
+			longest = hops
+		}
+		from = far
+	}
+	return longest, false
+}
+
+/*
+	Fragmentation compares a world before and after an invasion.
+*/
+type Fragmentation struct {
+	Before *Analysis `json:"before"`
+	After  *Analysis `json:"after"`
+	// cities standing before but isolated after the invasion
+	NewlyIsolated []string `json:"newlyIsolated"`
+	// fraction of the cities standing before which are still in the largest component after the invasion
+	Connected float64 `json:"connected"`
+}
+
+/*
+	Compare measures how much an invasion fragmented a world.
+*/
+func Compare(before, after *Analysis) *Fragmentation {
+	fragmentation := &Fragmentation{Before: before, After: after, NewlyIsolated: make([]string, 0)}
+	wasIsolated := make(map[string]bool, len(before.Isolated))
+	for _, city := range before.Isolated {
+		wasIsolated[city] = true
+	}
+	for _, city := range after.Isolated {
+		if !wasIsolated[city] {
+			fragmentation.NewlyIsolated = append(fragmentation.NewlyIsolated, city)
+		}
+	}
+	if before.Cities > 0 {
+		fragmentation.Connected = float64(after.Largest()) / float64(before.Cities)
+	}
+	return fragmentation
+}
+
+/*
+	Largest returns the number of cities of the largest component.
+*/
+func (analysis *Analysis) Largest() int {
+	if len(analysis.Components) == 0 {
+		return 0
+	}
+	return len(analysis.Components[0])
+}
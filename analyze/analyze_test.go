@@ -0,0 +1,137 @@
+package analyze
+
+import (
+	"testing"
+
+	"github.com/rvsingh011/alien-invasion/generator"
+	"github.com/rvsingh011/alien-invasion/internal/worldtest"
+	"github.com/rvsingh011/alien-invasion/simulation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorld(t *testing.T) {
+	tests := []struct {
+		name     string
+		world    map[string][]*simulation.City
+		expected *Analysis
+	}{
+		{
+			name:  "World example 4",
+			world: worldtest.Load(t, "America\nFoo west=bax south=qu-ux\nbax east=Foo south=abc north=xyz\nqu-ux north=Foo\nbrazil\nabc \nxyz \nmno").World,
+			expected: &Analysis{
+				Cities:        8,
+				Roads:         4,
+				Components:    [][]string{{"Foo", "abc", "bax", "qu-ux", "xyz"}, {"America"}, {"brazil"}, {"mno"}},
+				Bridges:       [][2]string{{"Foo", "bax"}, {"Foo", "qu-ux"}, {"abc", "bax"}, {"bax", "xyz"}},
+				Articulation:  []string{"Foo", "bax"},
+				Degrees:       map[int]int{0: 3, 1: 3, 2: 1, 3: 1},
+				Diameter:      3,
+				DiameterExact: true,
+				Isolated:      []string{"America", "brazil", "mno"},
+			},
+		},
+		{
+			name:  "A ring has no bridge",
+			world: worldtest.Load(t, "A east=B\nB south=C\nC west=D\nD north=A").World,
+			expected: &Analysis{
+				Cities:        4,
+				Roads:         4,
+				Components:    [][]string{{"A", "B", "C", "D"}},
+				Bridges:       [][2]string{},
+				Articulation:  []string{},
+				Degrees:       map[int]int{2: 4},
+				Diameter:      2,
+				DiameterExact: true,
+				Isolated:      []string{},
+			},
+		},
+		{
+			name:  "Two rings joined by a road",
+			world: worldtest.Load(t, "A east=B\nB south=C\nC west=A east=D\nD east=E\nE south=F\nF west=D").World,
+			expected: &Analysis{
+				Cities:        6,
+				Roads:         7,
+				Components:    [][]string{{"A", "B", "C", "D", "E", "F"}},
+				Bridges:       [][2]string{{"C", "D"}},
+				Articulation:  []string{"C", "D"},
+				Degrees:       map[int]int{2: 4, 3: 2},
+				Diameter:      3,
+				DiameterExact: true,
+				Isolated:      []string{},
+			},
+		},
+		{
+			name: "One way roads, roads to destroyed cities and to the city itself",
+			world: map[string][]*simulation.City{
+				"Foo": {simulation.NewCityWithDirections("Bar", "north"), simulation.NewCityWithDirections("Foo", "south")},
+				"Bar": {simulation.NewCityWithDirections("Gone", "east")},
+				"Lee": {simulation.NewCityWithDirections("Gone", "west")},
+			},
+			expected: &Analysis{
+				Cities:        3,
+				Roads:         1,
+				Components:    [][]string{{"Bar", "Foo"}, {"Lee"}},
+				Bridges:       [][2]string{{"Bar", "Foo"}},
+				Articulation:  []string{},
+				Degrees:       map[int]int{0: 1, 1: 2},
+				Diameter:      1,
+				DiameterExact: true,
+				Isolated:      []string{"Lee"},
+			},
+		},
+		{
+			name:  "Empty world",
+			world: map[string][]*simulation.City{},
+			expected: &Analysis{
+				Components:    [][]string{},
+				Bridges:       [][2]string{},
+				Articulation:  []string{},
+				Degrees:       map[int]int{},
+				DiameterExact: true,
+				Isolated:      []string{},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, World(tt.world))
+		})
+	}
+}
+
+func TestWorld_large(t *testing.T) {
+	tests := []struct {
+		name         string
+		worldMap     string
+		cities       int
+		bridges      int
+		articulation int
+		diameter     int
+	}{
+		// larger than ExactDiameterCities, the sweeps find the diameter of a full grid
+		{name: "Grid", worldMap: worldtest.Generated(t, generator.Options{Topology: generator.TopologyGrid, Width: 80, Height: 80, Density: 1, Seed: 1}), cities: 6400, diameter: 158},
+		// a chain deeper than a recursive search could go, every road is a bridge
+		{name: "Chain", worldMap: worldtest.Generated(t, generator.Options{Topology: generator.TopologyChain, Width: 400, Height: 500, Seed: 1}), cities: 200000, bridges: 199999, articulation: 199998, diameter: 199999},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analysis := World(worldtest.Load(t, tt.worldMap).World)
+			assert.Equal(t, tt.cities, analysis.Cities)
+			assert.Len(t, analysis.Components, 1)
+			assert.Len(t, analysis.Bridges, tt.bridges)
+			assert.Len(t, analysis.Articulation, tt.articulation)
+			assert.Equal(t, tt.diameter, analysis.Diameter)
+			assert.False(t, analysis.DiameterExact)
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	before := World(worldtest.Load(t, "A east=B\nB east=C\nC east=D\nE").World)
+	after := World(worldtest.Load(t, "A\nC east=D\nE").World)
+	fragmentation := Compare(before, after)
+	assert.Equal(t, []string{"A"}, fragmentation.NewlyIsolated)
+	assert.Equal(t, 0.4, fragmentation.Connected)
+	assert.Equal(t, 4, before.Largest())
+	assert.Equal(t, 2, after.Largest())
+}
@@ -0,0 +1,299 @@
+package bench
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rvsingh011/alien-invasion/engine"
+	"github.com/rvsingh011/alien-invasion/generator"
+	"github.com/rvsingh011/alien-invasion/simulation"
+	"go.uber.org/zap"
+)
+
+const (
+	// RunnerSimulation runs the attack with simulation.Start
+	RunnerSimulation = "simulation"
+	// RunnerEngine runs the attack with the fast engine
+	RunnerEngine = "engine"
+)
+
+// Runners lists the supported runners
+var Runners = []string{RunnerSimulation, RunnerEngine}
+
+// time between two readings of the heap while a case is measured
+const heapSampling = 10 * time.Millisecond
+
+/*
+	Scale is the size of a benchmark: a grid world of about Cities cities with every road, Aliens aliens and at most Rounds rounds.
+*/
+type Scale struct {
+	Cities int
+	Aliens int
+	Rounds int
+}
+
+// DefaultScales are the scales measured when none is given, from a small town to a hundred thousand cities
+var DefaultScales = []Scale{
+	{Cities: 100, Aliens: 10, Rounds: 10000},
+	{Cities: 1000, Aliens: 100, Rounds: 10000},
+	{Cities: 10000, Aliens: 1000, Rounds: 1000},
+	{Cities: 100000, Aliens: 10000, Rounds: 1000},
+}
+
+/*
+	String writes the scale as citiesxaliensxrounds, the format ParseScale reads.
+*/
+func (scale Scale) String() string {
+	return fmt.Sprintf("%dx%dx%d", scale.Cities, scale.Aliens, scale.Rounds)
+}
+
+/*
+	ParseScale reads a scale written as citiesxaliensxrounds, e.g. 10000x1000x500.
+*/
+func ParseScale(text string) (Scale, error) {
+	parts := strings.Split(text, "x")
+	if len(parts) != 3 {
+		return Scale{}, fmt.Errorf("Invalid scale %q, expected citiesxaliensxrounds", text)
+	}
+	values := make([]int, 0, 3)
+	for _, part := range parts {
+		value, err := strconv.Atoi(part)
+		if err != nil || value < 1 {
+			return Scale{}, fmt.Errorf("Invalid scale %q, the cities, aliens and rounds must be positive numbers", text)
+		}
+		values = append(values, value)
+	}
+	return Scale{Cities: values[0], Aliens: values[1], Rounds: values[2]}, nil
+}
+
+/*
+	ParseScales reads a comma separated list of scales.
+*/
+func ParseScales(text string) ([]Scale, error) {
+	scales := make([]Scale, 0)
+	for _, part := range strings.Split(text, ",") {
+		scale, err := ParseScale(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		scales = append(scales, scale)
+	}
+	return scales, nil
+}
+
+/*
+	World writes the world of a scale, the smallest grid with at least the cities of the scale, as a world file.
+*/
+func World(scale Scale, seed int64) ([]byte, error) {
+	width := int(math.Ceil(math.Sqrt(float64(scale.Cities))))
+	height := (scale.Cities + width - 1) / width
+	world, err := generator.Generate(generator.Options{Topology: generator.TopologyGrid, Width: width, Height: height, Density: 1, Seed: seed})
+	if err != nil {
+		return nil, err
+	}
+	var text bytes.Buffer
+	if _, err := world.WriteTo(&text); err != nil {
+		return nil, err
+	}
+	return text.Bytes(), nil
+}
+
+/*
+	NewSimulation prepares a silent simulation of a scale on a world file, the aliens are unnamed.
+*/
+func NewSimulation(worldMap []byte, scale Scale, seed int64) (*simulation.Simulation, error) {
+	sim, err := simulation.NewSimulation(scale.Rounds, scale.Aliens, "", "", nil, zap.NewNop())
+	if err != nil {
+		return nil, err
+	}
+	sim.Output = io.Discard
+	sim.SetSeed(seed)
+	if err := sim.LoadWorld(bytes.NewReader(worldMap)); err != nil {
+		return nil, err
+	}
+	if err := sim.CreateAliens(); err != nil {
+		return nil, err
+	}
+	return sim, nil
+}
+
+/*
+	Result holds the measures of a runner at a scale.
+	The rounds per second, allocations and bytes per round only cover the attack, LoadTime covers loading the world, creating
+	the aliens and building the engine. PeakHeap is the largest heap in use seen from loading the world to the end of the attack.
+*/
+type Result struct {
+	Runner          string
+	Scale           Scale
+	Cities          int
+	Roads           int
+	Rounds          int
+	LoadTime        time.Duration
+	RunTime         time.Duration
+	RoundsPerSecond float64
+	AllocsPerRound  float64
+	BytesPerRound   float64
+	PeakHeap        uint64
+}
+
+/*
+	Measure runs the attack of a scale with a runner and measures it, on the world of the scale written by World.
+*/
+func Measure(runner string, scale Scale, worldMap []byte, seed int64) (Result, error) {
+	if runner != RunnerSimulation && runner != RunnerEngine {
+		return Result{}, fmt.Errorf("Unknown runner %q, supported runners: %s", runner, strings.Join(Runners, ", "))
+	}
+	result := Result{Runner: runner, Scale: scale}
+	runtime.GC()
+	peak := watchHeap()
+
+	loading := time.Now()
+	sim, err := NewSimulation(worldMap, scale, seed)
+	if err != nil {
+		peak()
+		return result, err
+	}
+	result.Cities = len(sim.Cities)
+	for _, roads := range sim.World {
+		result.Roads += len(roads)
+	}
+	var attack *engine.Engine
+	if runner == RunnerEngine {
+		if attack, err = engine.New(sim); err != nil {
+			peak()
+			return result, err
+		}
+	}
+	result.LoadTime = time.Since(loading)
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	started := time.Now()
+	if attack != nil {
+		attack.Run()
+		result.Rounds = attack.Round
+	} else {
+		if err := sim.Start(); err != nil {
+			peak()
+			return result, err
+		}
+		result.Rounds = sim.Round
+	}
+	result.RunTime = time.Since(started)
+	runtime.ReadMemStats(&after)
+	result.PeakHeap = peak()
+
+	if result.Rounds > 0 {
+		rounds := float64(result.Rounds)
+		result.RoundsPerSecond = rounds / result.RunTime.Seconds()
+		result.AllocsPerRound = float64(after.Mallocs-before.Mallocs) / rounds
+		result.BytesPerRound = float64(after.TotalAlloc-before.TotalAlloc) / rounds
+	}
+	return result, nil
+}
+
+/*
+	watchHeap reads the heap in use until the returned function is called, which returns the largest heap seen.
+*/
+func watchHeap() func() uint64 {
+	var memory runtime.MemStats
+	runtime.ReadMemStats(&memory)
+	peak := memory.HeapAlloc
+	var lock sync.Mutex
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	record := func() {
+		var memory runtime.MemStats
+		runtime.ReadMemStats(&memory)
+		lock.Lock()
+		if memory.HeapAlloc > peak {
+			peak = memory.HeapAlloc
+		}
+		lock.Unlock()
+	}
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(heapSampling)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				record()
+			}
+		}
+	}()
+	return func() uint64 {
+		close(done)
+		<-stopped
+		record()
+		lock.Lock()
+		defer lock.Unlock()
+		return peak
+	}
+}
+
+// columns of the scaling report
+var columns = []string{"runner", "cities", "roads", "aliens", "max rounds", "rounds", "load ms", "run ms", "rounds/sec", "allocs/round", "bytes/round", "peak heap MB"}
+
+/*
+	row formats the measures of a result in the order of the columns.
+*/
+func (result Result) row() []string {
+	return []string{
+		result.Runner,
+		strconv.Itoa(result.Cities),
+		strconv.Itoa(result.Roads),
+		strconv.Itoa(result.Scale.Aliens),
+		strconv.Itoa(result.Scale.Rounds),
+		strconv.Itoa(result.Rounds),
+		strconv.FormatInt(result.LoadTime.Milliseconds(), 10),
+		strconv.FormatInt(result.RunTime.Milliseconds(), 10),
+		strconv.FormatFloat(result.RoundsPerSecond, 'f', 1, 64),
+		strconv.FormatFloat(result.AllocsPerRound, 'f', 1, 64),
+		strconv.FormatFloat(result.BytesPerRound, 'f', 0, 64),
+		strconv.FormatFloat(float64(result.PeakHeap)/(1<<20), 'f', 1, 64),
+	}
+}
+
+/*
+	WriteCSV writes the scaling report as CSV, one line per result.
+*/
+func WriteCSV(w io.Writer, results []Result) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(columns); err != nil {
+		return err
+	}
+	for _, result := range results {
+		if err := writer.Write(result.row()); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+/*
+	WriteMarkdown writes the scaling report as a markdown table, one line per result.
+*/
+func WriteMarkdown(w io.Writer, results []Result) error {
+	separators := make([]string, len(columns))
+	for idx := range separators {
+		separators[idx] = "---"
+	}
+	lines := []string{"| " + strings.Join(columns, " | ") + " |", "|" + strings.Join(separators, "|") + "|"}
+	for _, result := range results {
+		lines = append(lines, "| "+strings.Join(result.row(), " | ")+" |")
+	}
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
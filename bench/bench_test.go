@@ -0,0 +1,92 @@
+package bench
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseScales(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    []Scale
+		wantErr bool
+	}{
+		{name: "One scale", text: "10000x1000x500", want: []Scale{{Cities: 10000, Aliens: 1000, Rounds: 500}}},
+		{name: "Several scales", text: "100x10x1000, 1000x100x1000", want: []Scale{{100, 10, 1000}, {1000, 100, 1000}}},
+		{name: "Missing rounds", text: "100x10", wantErr: true},
+		{name: "Not a number", text: "100xtenx1000", wantErr: true},
+		{name: "No aliens", text: "100x0x1000", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseScales(tt.text)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.want[0], mustParse(t, tt.want[0].String()))
+		})
+	}
+}
+
+func mustParse(t *testing.T, text string) Scale {
+	scale, err := ParseScale(text)
+	assert.NoError(t, err)
+	return scale
+}
+
+func TestMeasure(t *testing.T) {
+	scale := Scale{Cities: 400, Aliens: 40, Rounds: 500}
+	worldMap, err := World(scale, 3)
+	assert.NoError(t, err)
+
+	results := make([]Result, 0, len(Runners))
+	for _, runner := range Runners {
+		result, err := Measure(runner, scale, worldMap, 3)
+		assert.NoError(t, err)
+		assert.Equal(t, 400, result.Cities)
+		assert.Equal(t, 4*20*19, result.Roads)
+		assert.NotZero(t, result.Rounds)
+		assert.NotZero(t, result.RoundsPerSecond)
+		assert.NotZero(t, result.PeakHeap)
+		results = append(results, result)
+	}
+	// both runners run the same attack
+	assert.Equal(t, results[0].Rounds, results[1].Rounds)
+
+	_, err = Measure("threads", scale, worldMap, 3)
+	assert.Error(t, err)
+}
+
+func TestWriteReport(t *testing.T) {
+	results := []Result{{
+		Runner:          RunnerEngine,
+		Scale:           Scale{Cities: 100, Aliens: 10, Rounds: 1000},
+		Cities:          100,
+		Roads:           360,
+		Rounds:          168,
+		LoadTime:        2 * time.Millisecond,
+		RunTime:         40 * time.Millisecond,
+		RoundsPerSecond: 4200,
+		AllocsPerRound:  0.5,
+		BytesPerRound:   12,
+		PeakHeap:        3 << 20,
+	}}
+
+	var csv bytes.Buffer
+	assert.NoError(t, WriteCSV(&csv, results))
+	assert.Equal(t, "runner,cities,roads,aliens,max rounds,rounds,load ms,run ms,rounds/sec,allocs/round,bytes/round,peak heap MB\n"+
+		"engine,100,360,10,1000,168,2,40,4200.0,0.5,12,3.0\n", csv.String())
+
+	var markdown bytes.Buffer
+	assert.NoError(t, WriteMarkdown(&markdown, results))
+	assert.Equal(t, "| runner | cities | roads | aliens | max rounds | rounds | load ms | run ms | rounds/sec | allocs/round | bytes/round | peak heap MB |\n"+
+		"|---|---|---|---|---|---|---|---|---|---|---|---|\n"+
+		"| engine | 100 | 360 | 10 | 1000 | 168 | 2 | 40 | 4200.0 | 0.5 | 12 | 3.0 |\n", markdown.String())
+}
@@ -0,0 +1,130 @@
+package commands
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/rvsingh011/alien-invasion/analyze"
+	"github.com/rvsingh011/alien-invasion/simulation"
+	"go.uber.org/zap"
+)
+
+/*
+	analyzeCommand analyses a world before and after an invasion:
+	analyze [-world file] [-iterations n] [-aliens n] [-names file] [-seed n] | [-replay file [-round n]] [-list n] [-json out.json]
+	Without -replay the invasion is run with the seed, with -replay the world is analysed before the run and after the round.
+*/
+func analyzeCommand(args []string, logger *zap.Logger) error {
+	flags := flag.NewFlagSet("analyze", flag.ContinueOnError)
+	world := flags.String("world", "./data/world-example-1.txt", "a file used as world map input")
+	replayFile := flags.String("replay", "", "a recorded run to analyse instead of running an invasion")
+	round := flags.Int("round", -1, "with -replay, round of the recorded run to analyse, the last recorded round if negative")
+	iterations := flags.Int("iterations", 10000, "without -replay, number of iterations of the invasion")
+	aliens := flags.Int("aliens", 10, "without -replay, number of aliens invading")
+	names := flags.String("names", "./data/alien_names.txt", "without -replay, a file used as alien names input")
+	seed := flags.Int64("seed", 0, "without -replay, seed of the random generator, the current time is used if 0")
+	list := flags.Int("list", 20, "maximum number of bridges, articulation and isolated cities listed")
+	jsonFile := flags.String("json", "", "a file the analyses are written to as JSON")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var states []*simulation.WorldSnapshot
+	var err error
+	if *replayFile != "" {
+		states, err = worldStates(*world, *replayFile, *round, logger)
+	} else {
+		// only the world before and after the invasion is kept
+		states, err = runFrames(*iterations, *aliens, *names, *world, *seed, &frames{every: math.MaxInt32, max: 2}, logger)
+	}
+	if err != nil {
+		return err
+	}
+	before := analyze.World(states[0].World)
+	after := analyze.World(states[len(states)-1].World)
+	fragmentation := analyze.Compare(before, after)
+
+	printAnalyses(before, after, states[len(states)-1].Round, *list)
+	fmt.Printf("%.1f%% of the cities are still in the largest component, %d cities were cut off\n",
+		100*fragmentation.Connected, len(fragmentation.NewlyIsolated))
+
+	if *jsonFile != "" {
+		return writeFile(*jsonFile, func(w io.Writer) error {
+			encoder := json.NewEncoder(w)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(fragmentation)
+		})
+	}
+	return nil
+}
+
+/*
+	printAnalyses prints the analyses of the world before and after the invasion side by side.
+*/
+func printAnalyses(before, after *analyze.Analysis, round, list int) {
+	diameter := func(analysis *analyze.Analysis) string {
+		if analysis.DiameterExact {
+			return fmt.Sprint(analysis.Diameter)
+		}
+		return fmt.Sprintf(">= %d", analysis.Diameter)
+	}
+	rows := [][3]string{
+		{"", "before", fmt.Sprintf("after round %d", round)},
+		{"Cities", fmt.Sprint(before.Cities), fmt.Sprint(after.Cities)},
+		{"Roads", fmt.Sprint(before.Roads), fmt.Sprint(after.Roads)},
+		{"Components", fmt.Sprint(len(before.Components)), fmt.Sprint(len(after.Components))},
+		{"Largest component", fmt.Sprint(before.Largest()), fmt.Sprint(after.Largest())},
+		{"Bridges", fmt.Sprint(len(before.Bridges)), fmt.Sprint(len(after.Bridges))},
+		{"Articulation cities", fmt.Sprint(len(before.Articulation)), fmt.Sprint(len(after.Articulation))},
+		{"Isolated cities", fmt.Sprint(len(before.Isolated)), fmt.Sprint(len(after.Isolated))},
+		{"Diameter", diameter(before), diameter(after)},
+	}
+
+	degrees := make([]int, 0, len(before.Degrees)+len(after.Degrees))
+	for degree := range before.Degrees {
+		degrees = append(degrees, degree)
+	}
+	for degree := range after.Degrees {
+		if _, ok := before.Degrees[degree]; !ok {
+			degrees = append(degrees, degree)
+		}
+	}
+	sort.Ints(degrees)
+	for _, degree := range degrees {
+		rows = append(rows, [3]string{fmt.Sprintf("Cities with %d roads", degree), fmt.Sprint(before.Degrees[degree]), fmt.Sprint(after.Degrees[degree])})
+	}
+	for _, row := range rows {
+		fmt.Printf("%-24s %-12s %s\n", row[0], row[1], row[2])
+	}
+
+	for _, analysis := range []struct {
+		when string
+		*analyze.Analysis
+	}{{"before", before}, {"after", after}} {
+		bridges := make([]string, 0, len(analysis.Bridges))
+		for _, bridge := range analysis.Bridges {
+			bridges = append(bridges, bridge[0]+"-"+bridge[1])
+		}
+		fmt.Printf("Bridges %s: %s\n", analysis.when, truncated(bridges, list))
+		fmt.Printf("Articulation cities %s: %s\n", analysis.when, truncated(analysis.Articulation, list))
+		fmt.Printf("Isolated cities %s: %s\n", analysis.when, truncated(analysis.Isolated, list))
+	}
+}
+
+/*
+	truncated joins at most n names, telling how many more there are.
+*/
+func truncated(names []string, n int) string {
+	if len(names) == 0 {
+		return "none"
+	}
+	if len(names) <= n {
+		return strings.Join(names, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(names[:n], ", "), len(names)-n)
+}
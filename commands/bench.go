@@ -0,0 +1,64 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/rvsingh011/alien-invasion/bench"
+	"go.uber.org/zap"
+)
+
+/*
+	benchCommand measures the attack at several scales and writes a scaling report:
+	bench [-scales citiesxaliensxrounds,...] [-runners simulation,engine] [-seed n] [-csv report.csv] [-markdown report.md]
+*/
+func benchCommand(args []string, logger *zap.Logger) error {
+	flags := flag.NewFlagSet("bench", flag.ContinueOnError)
+	defaults := make([]string, 0, len(bench.DefaultScales))
+	for _, scale := range bench.DefaultScales {
+		defaults = append(defaults, scale.String())
+	}
+	scalesFlag := flags.String("scales", strings.Join(defaults, ","), "comma separated scales to measure, each written citiesxaliensxrounds")
+	runners := flags.String("runners", strings.Join(bench.Runners, ","), "comma separated runners to measure: "+strings.Join(bench.Runners, ", "))
+	seed := flags.Int64("seed", 1, "seed of the worlds and of the attacks")
+	csvFile := flags.String("csv", "", "a file the report is written to as CSV")
+	markdownFile := flags.String("markdown", "", "a file the report is written to as a markdown table")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	scales, err := bench.ParseScales(*scalesFlag)
+	if err != nil {
+		return err
+	}
+
+	results := make([]bench.Result, 0)
+	for _, scale := range scales {
+		worldMap, err := bench.World(scale, *seed)
+		if err != nil {
+			return err
+		}
+		for _, runner := range strings.Split(*runners, ",") {
+			result, err := bench.Measure(strings.TrimSpace(runner), scale, worldMap, *seed)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "%s %s: %d rounds in %s\n", result.Runner, scale, result.Rounds, result.RunTime)
+			results = append(results, result)
+		}
+	}
+
+	if *csvFile != "" {
+		if err := writeFile(*csvFile, func(w io.Writer) error { return bench.WriteCSV(w, results) }); err != nil {
+			return err
+		}
+	}
+	if *markdownFile != "" {
+		if err := writeFile(*markdownFile, func(w io.Writer) error { return bench.WriteMarkdown(w, results) }); err != nil {
+			return err
+		}
+	}
+	return bench.WriteMarkdown(os.Stdout, results)
+}
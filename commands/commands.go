@@ -0,0 +1,59 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+/*
+	command is a sub command of the simulator, it receives the arguments following its name.
+*/
+type command func(args []string, logger *zap.Logger) error
+
+// registry of all the sub commands by name
+var registry = map[string]command{
+	"analyze":  analyzeCommand,
+	"bench":    benchCommand,
+	"defend":   defendCommand,
+	"export":   exportCommand,
+	"generate": generateCommand,
+	"history":  historyCommand,
+	"import":   importCommand,
+	"layout":   layoutCommand,
+	"markov":   markovCommand,
+	"render":   renderCommand,
+	"replay":   replayCommand,
+	"route":    routeCommand,
+	"repl":     replCommand,
+	"scenario": scenarioCommand,
+	"serve":    serveCommand,
+}
+
+/*
+	Run executes the sub command named by the first argument.
+*/
+func Run(args []string, logger *zap.Logger) error {
+	if len(args) == 0 {
+		return fmt.Errorf("No command given, available commands: %s", available())
+	}
+	run, ok := registry[args[0]]
+	if !ok {
+		return fmt.Errorf("Unknown command %q, available commands: %s", args[0], available())
+	}
+	return run(args[1:], logger)
+}
+
+/*
+	available lists the names of the sub commands.
+*/
+func available() string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,86 @@
+package commands
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/rvsingh011/alien-invasion/defense"
+	"github.com/rvsingh011/alien-invasion/engine"
+	"github.com/rvsingh011/alien-invasion/graphio"
+	"github.com/rvsingh011/alien-invasion/simulation"
+	"github.com/rvsingh011/alien-invasion/utils"
+	"go.uber.org/zap"
+)
+
+/*
+	defendCommand searches the cities to fortify against an invasion, fortified cities cannot be destroyed:
+	defend [-world file] [-aliens n] [-iterations n] -budget k [-population file] [-runs n] [-seed n] [-candidates n] [-steps n] [-json out.json]
+	Without -population the expected number of cities surviving is maximised, with it the expected population surviving.
+*/
+func defendCommand(args []string, logger *zap.Logger) error {
+	flags := flag.NewFlagSet("defend", flag.ContinueOnError)
+	world := flags.String("world", "./data/world-example-1.txt", "a file used as world map input")
+	aliens := flags.Int("aliens", 10, "number of aliens invading")
+	iterations := flags.Int("iterations", 10000, "number of iterations of every invasion")
+	budget := flags.Int("budget", 1, "number of cities fortified")
+	population := flags.String("population", "", "a file holding the population of the cities, one city and number per line")
+	runs := flags.Int("runs", defense.DefaultRuns, "number of invasions every set of fortified cities is scored on")
+	seed := flags.Int64("seed", 1, "seed of the first invasion, the invasions use the seeds following it")
+	candidates := flags.Int("candidates", defense.DefaultCandidates, "number of cities destroyed most often considered for a fortification")
+	steps := flags.Int("steps", defense.DefaultSteps, "number of swaps tried by the simulated annealing")
+	jsonFile := flags.String("json", "", "a file the result is written to as JSON")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := utils.ValidateInput(*iterations, *aliens, "", *world); err != nil {
+		return err
+	}
+
+	options := defense.Options{Aliens: *aliens, Iterations: *iterations, Budget: *budget, Runs: *runs, Seed: *seed, Candidates: *candidates, Steps: *steps}
+	unit := "cities"
+	if *population != "" {
+		file, err := os.Open(*population)
+		if err != nil {
+			return fmt.Errorf("Unable to read the population: %s", err.Error())
+		}
+		defer file.Close()
+		if options.Weights, err = defense.ReadWeights(file); err != nil {
+			return err
+		}
+		unit = "people"
+	}
+
+	sim, err := simulation.NewSimulation(*iterations, *aliens, "", *world, nil, logger)
+	if err != nil {
+		return err
+	}
+	if err := graphio.LoadWorld(sim); err != nil {
+		return err
+	}
+	worldMap, err := engine.FromSimulation(sim)
+	if err != nil {
+		return err
+	}
+	result, err := defense.Optimize(worldMap, options)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Without fortification %.2f %s survive on average\n", result.Baseline, unit)
+	fmt.Printf("Greedy search: %.2f %s survive fortifying %s\n", result.Greedy.Score, unit, strings.Join(result.Greedy.Fortified, ", "))
+	fmt.Printf("Simulated annealing: %.2f %s survive fortifying %s\n", result.Best.Score, unit, strings.Join(result.Best.Fortified, ", "))
+	fmt.Printf("%d sets of cities were scored on %d invasions each\n", result.Evaluations, *runs)
+
+	if *jsonFile != "" {
+		return writeFile(*jsonFile, func(w io.Writer) error {
+			encoder := json.NewEncoder(w)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(result)
+		})
+	}
+	return nil
+}
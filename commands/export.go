@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/rvsingh011/alien-invasion/graphio"
+	"go.uber.org/zap"
+)
+
+/*
+	exportCommand exports a world file or a recorded run at a round as a graph:
+	export [-format dot|graphml] [-world file | -replay file [-round n]] [-o out]
+*/
+func exportCommand(args []string, logger *zap.Logger) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := flags.String("format", "dot", "format of the graph, dot or graphml")
+	world := flags.String("world", "./data/world-example-1.txt", "a file used as world map input")
+	replayFile := flags.String("replay", "", "a recorded run to export instead of a world file")
+	round := flags.Int("round", -1, "round of the recorded run to export, the last recorded round if negative")
+	output := flags.String("o", "", "the file the graph is written to, world.<format> by default")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var write func(w io.Writer) error
+	states, err := worldStates(*world, *replayFile, *round, logger)
+	if err != nil {
+		return err
+	}
+	base, state := states[0], states[len(states)-1]
+	switch *format {
+	case "dot":
+		write = func(w io.Writer) error { return graphio.WriteDOT(w, base, state) }
+	case "graphml":
+		write = func(w io.Writer) error { return graphio.WriteGraphML(w, base, state) }
+	default:
+		return fmt.Errorf("Unknown graph format %q, supported formats: dot, graphml", *format)
+	}
+	if *output == "" {
+		*output = "world." + *format
+	}
+	if err := writeFile(*output, write); err != nil {
+		return err
+	}
+	fmt.Printf("The world was exported to %s\n", *output)
+	return nil
+}
+
+/*
+	importCommand converts a GraphML graph into a world file: import [-o world.txt] <graph.graphml>
+*/
+func importCommand(args []string, logger *zap.Logger) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	output := flags.String("o", "world.txt", "the world file the graph is converted to")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("Usage: import [-o world.txt] <graph.graphml>")
+	}
+	world, err := graphio.LoadGraphML(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if err := writeFile(*output, func(w io.Writer) error {
+		_, err := io.WriteString(w, world)
+		return err
+	}); err != nil {
+		return err
+	}
+	fmt.Printf("The graph was converted to the world file %s\n", *output)
+	return nil
+}
@@ -0,0 +1,73 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rvsingh011/alien-invasion/generator"
+	"go.uber.org/zap"
+)
+
+/*
+	generateCommand writes random world files:
+	generate [-topology grid|holes|tree|islands|chain|hubs] [-width n] [-height n] [-density p] [-seed n] [-count n] [-o world.txt]
+*/
+func generateCommand(args []string, logger *zap.Logger) error {
+	flags := flag.NewFlagSet("generate", flag.ContinueOnError)
+	var options generator.Options
+	flags.StringVar(&options.Topology, "topology", generator.TopologyGrid, "shape of the world: "+strings.Join(generator.Topologies, ", "))
+	flags.IntVar(&options.Width, "width", 10, "width of the grid, of every island or of every hub")
+	flags.IntVar(&options.Height, "height", 10, "height of the grid, of every island or of every hub")
+	flags.Float64Var(&options.Density, "density", 1, "probability of keeping a road which is not needed to connect the cities")
+	flags.Float64Var(&options.Holes, "holes", 0.2, "probability of a city of the grid to be missing, for the holes topology")
+	flags.IntVar(&options.Count, "islands", 4, "number of islands, for the islands topology")
+	hubs := flags.Int("hubs", 4, "number of hubs, for the hubs topology")
+	flags.IntVar(&options.Spoke, "spoke", 3, "number of cities of the chains linking the hubs")
+	flags.Int64Var(&options.Seed, "seed", 0, "seed of the random generator, the current time is used if 0")
+	count := flags.Int("count", 1, "number of worlds to generate, with the seeds following each other")
+	output := flags.String("o", "world.txt", "the world file, numbered after its name when several worlds are generated")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if options.Topology == generator.TopologyHubs {
+		options.Count = *hubs
+	}
+	if options.Seed == 0 {
+		options.Seed = time.Now().UnixNano()
+	}
+	if *count < 1 {
+		return fmt.Errorf("At least one world must be generated, got %d", *count)
+	}
+	if err := options.Validate(); err != nil {
+		return err
+	}
+
+	extension := filepath.Ext(*output)
+	base := strings.TrimSuffix(*output, extension)
+	width := len(fmt.Sprint(*count))
+	seed := options.Seed
+	for idx := 0; idx < *count; idx++ {
+		options.Seed = seed + int64(idx)
+		world, err := generator.Generate(options)
+		if err != nil {
+			return err
+		}
+		path := *output
+		if *count > 1 {
+			path = fmt.Sprintf("%s-%0*d%s", base, width, idx+1, extension)
+		}
+		err = writeFile(path, func(w io.Writer) error {
+			_, err := world.WriteTo(w)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s: %d cities and %d roads, seed %d\n", path, world.Cities(), world.Roads(), options.Seed)
+	}
+	return nil
+}
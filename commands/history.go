@@ -0,0 +1,220 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/rvsingh011/alien-invasion/history"
+	"go.uber.org/zap"
+)
+
+// historyUsage lists the sub commands of the history command
+const historyUsage = "Usage: history list [-destroyed city [-before round]] [-survived city] [-seed n] [-world file] [-max-rounds n] | history show [-events] <id> | history compare <id> <id>"
+
+/*
+	historyCommand queries the runs kept in the run store: history list|show|compare
+*/
+func historyCommand(args []string, logger *zap.Logger) error {
+	if len(args) == 0 {
+		return fmt.Errorf(historyUsage)
+	}
+	flags := flag.NewFlagSet("history "+args[0], flag.ContinueOnError)
+	dir := flags.String("dir", history.DefaultDir, "directory of the run store")
+	switch args[0] {
+	case "list":
+		destroyed := flags.String("destroyed", "", "keep the runs which destroyed the city")
+		before := flags.Int("before", 0, "with -destroyed, keep the runs which destroyed the city before the round")
+		survived := flags.String("survived", "", "keep the runs in which the city survived")
+		seed := flags.Int64("seed", 0, "keep the runs with the seed")
+		world := flags.String("world", "", "keep the runs of the world file")
+		source := flags.String("source", "", "keep the runs from the source: cli, scenario or server")
+		maxRounds := flags.Int("max-rounds", 0, "keep the runs which ended by the round")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		query := history.Query{Destroyed: *destroyed, Before: *before, Survived: *survived, World: *world, Source: *source, MaxRounds: *maxRounds}
+		flags.Visit(func(set *flag.Flag) {
+			if set.Name == "seed" {
+				query.Seed = seed
+			}
+		})
+		store, err := history.Open(*dir)
+		if err != nil {
+			return err
+		}
+		records, err := store.Find(query)
+		if err != nil {
+			return err
+		}
+		printRecords(records, query)
+		return nil
+	case "show":
+		events := flags.Bool("events", false, "print the event log of the run")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		if flags.NArg() != 1 {
+			return fmt.Errorf(historyUsage)
+		}
+		store, err := history.Open(*dir)
+		if err != nil {
+			return err
+		}
+		record, err := store.Get(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		printRecord(record)
+		if !*events {
+			return nil
+		}
+		log, err := store.Events(record.ID)
+		if err != nil {
+			return err
+		}
+		fmt.Println("Events:")
+		for _, event := range log {
+			fmt.Printf("  round %d: %s\n", event.Round, event)
+		}
+		return nil
+	case "compare":
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		if flags.NArg() != 2 {
+			return fmt.Errorf(historyUsage)
+		}
+		store, err := history.Open(*dir)
+		if err != nil {
+			return err
+		}
+		first, err := store.Get(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		second, err := store.Get(flags.Arg(1))
+		if err != nil {
+			return err
+		}
+		compareRecords(first, second)
+		return nil
+	}
+	return fmt.Errorf(historyUsage)
+}
+
+/*
+	printRecords prints one line per run, with the round the queried city was destroyed in.
+*/
+func printRecords(records []*history.Record, query history.Query) {
+	if len(records) == 0 {
+		fmt.Println("No run found")
+		return
+	}
+	table := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	header := "ID\tSTARTED\tSOURCE\tWORLD\tSEED\tALIENS\tROUNDS\tDESTROYED\tALIVE"
+	if query.Destroyed != "" {
+		header += "\t" + strings.ToUpper(query.Destroyed) + " DESTROYED IN"
+	}
+	fmt.Fprintln(table, header)
+	for _, record := range records {
+		line := fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%d\t%d\t%d\t%d", record.ID, record.Started.Local().Format("2006-01-02 15:04:05"), record.Source,
+			record.WorldFile, seedOf(record), record.Aliens, record.Rounds, len(record.Destroyed), record.AliensAlive)
+		if query.Destroyed != "" {
+			line += fmt.Sprintf("\tround %d", record.Destroyed[query.Destroyed])
+		}
+		fmt.Fprintln(table, line)
+	}
+	table.Flush()
+	fmt.Printf("%d runs\n", len(records))
+}
+
+/*
+	printRecord prints the parameters and the outcome of a run.
+*/
+func printRecord(record *history.Record) {
+	fmt.Printf("Run %s (%s, %s)\n", record.ID, record.Source, record.Status)
+	fmt.Printf("Started %s, took %s\n", record.Started.Local().Format("2006-01-02 15:04:05"), record.Duration)
+	fmt.Printf("Seed %s, world %s, alien names %s\n", seedOf(record), record.WorldFile, record.AlienNames)
+	fmt.Printf("%d aliens, %d iterations\n", record.Aliens, record.Iterations)
+	for _, event := range record.Events {
+		fmt.Printf("Scheduled event %s\n", event)
+	}
+	if record.StartRound > 0 {
+		fmt.Printf("Resumed after round %d\n", record.StartRound)
+	}
+	fmt.Printf("Ended after round %d with %d aliens alive\n", record.Rounds, record.AliensAlive)
+	if record.EndReason != "" {
+		fmt.Printf("Ended because %s\n", record.EndReason)
+	}
+	fmt.Printf("Destroyed cities (%d):", len(record.Destroyed))
+	for _, city := range record.DestroyedCities() {
+		fmt.Printf(" %s (round %d)", city, record.Destroyed[city])
+	}
+	fmt.Println()
+	fmt.Printf("Surviving cities (%d): %s\n", len(record.Survived), strings.Join(record.Survived, " "))
+}
+
+/*
+	compareRecords prints the parameters and the outcomes of two runs side by side, with the cities only one of them destroyed.
+*/
+func compareRecords(first, second *history.Record) {
+	table := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(table, "\t%s\t%s\n", first.ID, second.ID)
+	rows := [][3]string{
+		{"source", first.Source, second.Source},
+		{"world", first.WorldFile, second.WorldFile},
+		{"seed", seedOf(first), seedOf(second)},
+		{"aliens", fmt.Sprint(first.Aliens), fmt.Sprint(second.Aliens)},
+		{"iterations", fmt.Sprint(first.Iterations), fmt.Sprint(second.Iterations)},
+		{"rounds", fmt.Sprint(first.Rounds), fmt.Sprint(second.Rounds)},
+		{"cities destroyed", fmt.Sprint(len(first.Destroyed)), fmt.Sprint(len(second.Destroyed))},
+		{"cities survived", fmt.Sprint(len(first.Survived)), fmt.Sprint(len(second.Survived))},
+		{"aliens alive", fmt.Sprint(first.AliensAlive), fmt.Sprint(second.AliensAlive)},
+	}
+	for _, row := range rows {
+		marker := ""
+		if row[1] != row[2] {
+			marker = "  *"
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s%s\n", row[0], row[1], row[2], marker)
+	}
+	table.Flush()
+
+	cities := make(map[string]bool)
+	for _, record := range []*history.Record{first, second} {
+		for city := range record.Destroyed {
+			cities[city] = true
+		}
+	}
+	fmt.Println("Destroyed cities:")
+	for _, city := range append(first.DestroyedCities(), second.DestroyedCities()...) {
+		if !cities[city] {
+			continue
+		}
+		delete(cities, city)
+		fmt.Printf("  %-20s %-12s %s\n", city, destroyedIn(first, city), destroyedIn(second, city))
+	}
+}
+
+/*
+	destroyedIn describes when a run destroyed a city.
+*/
+func destroyedIn(record *history.Record, city string) string {
+	if round, ok := record.Destroyed[city]; ok {
+		return fmt.Sprintf("round %d", round)
+	}
+	return "survived"
+}
+
+/*
+	seedOf returns the seed of a run, or why the run cannot be reproduced.
+*/
+func seedOf(record *history.Record) string {
+	if !record.Seeded {
+		return "unknown"
+	}
+	return fmt.Sprint(record.Seed)
+}
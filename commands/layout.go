@@ -0,0 +1,38 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/rvsingh011/alien-invasion/graphio"
+	"github.com/rvsingh011/alien-invasion/simulation"
+	"go.uber.org/zap"
+)
+
+/*
+	layoutCommand prints the grid coordinates inferred for the cities of a world: layout [-world file]
+*/
+func layoutCommand(args []string, logger *zap.Logger) error {
+	flags := flag.NewFlagSet("layout", flag.ContinueOnError)
+	world := flags.String("world", "./data/world-example-1.txt", "a file used as world map input")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	sim, err := simulation.NewSimulation(0, 0, "", *world, nil, logger)
+	if err != nil {
+		return err
+	}
+	if err := graphio.LoadWorld(sim); err != nil {
+		return err
+	}
+	layout := sim.InferLayout()
+	fmt.Printf("%d cities in %d components on a %dx%d grid\n", len(sim.Cities), len(layout.Components), layout.Width, layout.Height)
+	for _, city := range sim.Cities {
+		fmt.Printf("%s (%d,%d)\n", city.Name, city.X, city.Y)
+	}
+	for _, conflict := range layout.Conflicts {
+		fmt.Printf("Conflict: %s\n", conflict.Reason)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package commands
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/rvsingh011/alien-invasion/engine"
+	"github.com/rvsingh011/alien-invasion/graphio"
+	"github.com/rvsingh011/alien-invasion/markov"
+	"github.com/rvsingh011/alien-invasion/simulation"
+	"github.com/rvsingh011/alien-invasion/utils"
+	"go.uber.org/zap"
+)
+
+/*
+	markovCommand computes the exact outcome of an invasion of a small world:
+	markov [-world file] [-iterations n] [-aliens n] [-max-states n] [-runs n [-seed n]] [-list n] [-json out.json]
+	With -runs the invasion is also run n times, with the seeds following -seed, to compare the sampled survival with the exact one.
+*/
+func markovCommand(args []string, logger *zap.Logger) error {
+	flags := flag.NewFlagSet("markov", flag.ContinueOnError)
+	world := flags.String("world", "./data/world-example-1.txt", "a file used as world map input")
+	iterations := flags.Int("iterations", 10000, "number of iterations of the invasion")
+	aliens := flags.Int("aliens", 2, "number of aliens invading")
+	maxStates := flags.Int("max-states", markov.DefaultMaxStates, "largest number of states of the invasion analysed")
+	runs := flags.Int("runs", 0, "number of invasions run to compare with, none if 0")
+	seed := flags.Int64("seed", 1, "with -runs, seed of the first invasion run")
+	list := flags.Int("list", 10, "maximum number of final states listed")
+	jsonFile := flags.String("json", "", "a file the analysis is written to as JSON")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := utils.ValidateInput(*iterations, *aliens, "", *world); err != nil {
+		return err
+	}
+
+	sim, err := markovSimulation(*iterations, *aliens, *world, logger)
+	if err != nil {
+		return err
+	}
+	analysis, err := markov.Analyze(sim, *maxStates)
+	if err != nil {
+		return err
+	}
+
+	var sampled map[string]float64
+	sampledRounds := 0.0
+	if *runs > 0 {
+		if sampled, sampledRounds, err = sampleSurvival(*iterations, *aliens, *world, *seed, *runs, logger); err != nil {
+			return err
+		}
+	}
+	printExact(analysis, sim, *runs, sampled, sampledRounds, *list)
+
+	if *jsonFile != "" {
+		return writeFile(*jsonFile, func(w io.Writer) error {
+			encoder := json.NewEncoder(w)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(analysis)
+		})
+	}
+	return nil
+}
+
+/*
+	markovSimulation prepares a silent simulation of unnamed aliens on a world file.
+*/
+func markovSimulation(iterations, aliens int, world string, logger *zap.Logger) (*simulation.Simulation, error) {
+	sim, err := simulation.NewSimulation(iterations, aliens, "", world, nil, logger)
+	if err != nil {
+		return nil, err
+	}
+	sim.Output = io.Discard
+	if err := graphio.LoadWorld(sim); err != nil {
+		return nil, err
+	}
+	if err := sim.CreateAliens(); err != nil {
+		return nil, err
+	}
+	return sim, nil
+}
+
+/*
+	sampleSurvival runs the invasion with the fast engine and returns the fraction of the runs every city survived and the
+	average round the runs ended after.
+*/
+func sampleSurvival(iterations, aliens int, world string, seed int64, runs int, logger *zap.Logger) (map[string]float64, float64, error) {
+	survived := make(map[string]float64)
+	rounds := 0.0
+	for run := 0; run < runs; run++ {
+		sim, err := markovSimulation(iterations, aliens, world, logger)
+		if err != nil {
+			return nil, 0, err
+		}
+		sim.SetSeed(seed + int64(run))
+		if err := engine.Run(sim); err != nil {
+			return nil, 0, err
+		}
+		for _, city := range sim.Cities {
+			survived[city.Name] += 1 / float64(runs)
+		}
+		rounds += float64(sim.Round) / float64(runs)
+	}
+	return survived, rounds, nil
+}
+
+/*
+	printExact prints the exact outcome of the invasion, next to the sampled one if the invasion was run.
+*/
+func printExact(analysis *markov.Analysis, sim *simulation.Simulation, runs int, sampled map[string]float64, sampledRounds float64, list int) {
+	fmt.Printf("%d aliens on %d cities: %d states, %d rounds analysed\n", len(sim.Aliens), len(sim.Cities), analysis.States, analysis.Rounds)
+	if analysis.FightProbability > 0 {
+		fmt.Printf("A fight happens with probability %.6f, the first one after %.4f rounds on average\n", analysis.FightProbability, analysis.ExpectedFirstFight)
+	} else {
+		fmt.Println("No fight can happen")
+	}
+	fmt.Printf("The invasion ends after %.4f rounds on average", analysis.ExpectedRounds)
+	if runs > 0 {
+		fmt.Printf(", %.4f in %d runs", sampledRounds, runs)
+	}
+	fmt.Println()
+	if analysis.Unresolved > 0 {
+		fmt.Printf("The invasion is still going on with probability %.3g after the last round analysed\n", analysis.Unresolved)
+	}
+
+	cities := make([]string, 0, len(analysis.Survival))
+	for city := range analysis.Survival {
+		cities = append(cities, city)
+	}
+	sort.Strings(cities)
+	if runs > 0 {
+		fmt.Printf("%-24s %-12s %s\n", "City", "survival", fmt.Sprintf("in %d runs", runs))
+	} else {
+		fmt.Printf("%-24s %s\n", "City", "survival")
+	}
+	for _, city := range cities {
+		if runs > 0 {
+			fmt.Printf("%-24s %-12.6f %.6f\n", city, analysis.Survival[city], sampled[city])
+			continue
+		}
+		fmt.Printf("%-24s %.6f\n", city, analysis.Survival[city])
+	}
+
+	fmt.Printf("%d final states, the most likely:\n", len(analysis.Finals))
+	for idx, final := range analysis.Finals {
+		if idx == list {
+			break
+		}
+		positions := make([]string, 0, len(final.Aliens))
+		for city, count := range final.Aliens {
+			positions = append(positions, fmt.Sprintf("%s=%d", city, count))
+		}
+		sort.Strings(positions)
+		fmt.Printf("%.6f %s, destroyed: %s, aliens: %s\n", final.Probability, final.Reason, truncated(final.Destroyed, len(final.Destroyed)), truncated(positions, len(positions)))
+	}
+}
@@ -0,0 +1,246 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/rvsingh011/alien-invasion/graphio"
+	"github.com/rvsingh011/alien-invasion/render"
+	"github.com/rvsingh011/alien-invasion/simulation"
+	"github.com/rvsingh011/alien-invasion/utils"
+	"go.uber.org/zap"
+)
+
+/*
+	renderCommand draws a world file, or a recorded run before and after a round: render [-world file | -replay file [-round n]] [-o out.svg]
+	With -animate the whole run is drawn to an animated GIF, one frame every n rounds and at most m frames:
+	render -animate [-every n] [-frames m] [-world file | -replay file] [-o out.gif]
+*/
+func renderCommand(args []string, logger *zap.Logger) error {
+	flags := flag.NewFlagSet("render", flag.ContinueOnError)
+	world := flags.String("world", "./data/world-example-1.txt", "a file used as world map input")
+	replayFile := flags.String("replay", "", "a recorded run to draw instead of a world file")
+	round := flags.Int("round", -1, "round of the recorded run to draw, the last recorded round if negative")
+	output := flags.String("o", "", "the file the drawing is written to, world.svg or world.gif by default")
+	animate := flags.Bool("animate", false, "draw the whole run to an animated GIF")
+	every := flags.Int("every", 1, "with -animate, draw a frame every n rounds")
+	maxFrames := flags.Int("frames", 500, "with -animate, most frames drawn, the rounds between two frames double until the run fits")
+	delay := flags.Int("delay", render.DefaultFrameDelay, "with -animate, time between two frames in hundredths of a second")
+	iterations := flags.Int("iterations", 10000, "with -animate and no -replay, number of iterations of the run")
+	aliens := flags.Int("aliens", 10, "with -animate and no -replay, number of aliens invading")
+	names := flags.String("names", "./data/alien_names.txt", "with -animate and no -replay, a file used as alien names input")
+	seed := flags.Int64("seed", 0, "with -animate and no -replay, seed of the random generator, the current time is used if 0")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *every < 1 {
+		return fmt.Errorf("Invalid number of rounds between two frames %d", *every)
+	}
+	if *maxFrames < 2 {
+		return fmt.Errorf("Invalid number of frames %d, at least 2 are needed", *maxFrames)
+	}
+
+	if *animate {
+		if *output == "" {
+			*output = "world.gif"
+		}
+		var states []*simulation.WorldSnapshot
+		var err error
+		if *replayFile != "" {
+			states, err = replayFrames(*replayFile, &frames{every: *every, max: *maxFrames}, logger)
+		} else {
+			states, err = runFrames(*iterations, *aliens, *names, *world, *seed, &frames{every: *every, max: *maxFrames}, logger)
+		}
+		if err != nil {
+			return err
+		}
+		scene := render.NewScene(states[0])
+		if err := writeFile(*output, func(w io.Writer) error { return scene.WriteGIF(w, states, *delay) }); err != nil {
+			return err
+		}
+		fmt.Printf("%d frames of the invasion were drawn to %s\n", len(states), *output)
+		return nil
+	}
+
+	if *output == "" {
+		*output = "world.svg"
+	}
+	states, err := worldStates(*world, *replayFile, *round, logger)
+	if err != nil {
+		return err
+	}
+
+	scene := render.NewScene(states[0])
+	if err := writeFile(*output, func(w io.Writer) error { return scene.WriteSVG(w, states...) }); err != nil {
+		return err
+	}
+	fmt.Printf("The world was drawn to %s\n", *output)
+	return nil
+}
+
+/*
+	frames keeps the states of the world drawn to an animation, the first one and one every n rounds. When more than max
+	states are kept every other one is dropped and n doubles, so a run of any length fits in max frames and the last one.
+*/
+type frames struct {
+	every  int
+	max    int
+	states []*simulation.WorldSnapshot
+}
+
+/*
+	wants reports whether the state of the world after the round is kept.
+*/
+func (frames *frames) wants(round int) bool {
+	return len(frames.states) == 0 || round%frames.every == 0
+}
+
+/*
+	add keeps the state of the world of the simulation if it is wanted.
+*/
+func (frames *frames) add(sim *simulation.Simulation) {
+	if !frames.wants(sim.Round) {
+		return
+	}
+	frames.states = append(frames.states, sim.SnapshotWorld())
+	for len(frames.states) > frames.max {
+		frames.every *= 2
+		kept := frames.states[:1]
+		for _, state := range frames.states[1:] {
+			if state.Round%frames.every == 0 {
+				kept = append(kept, state)
+			}
+		}
+		frames.states = kept
+	}
+}
+
+/*
+	end keeps the last state of the world of the simulation and returns the states kept.
+*/
+func (frames *frames) end(sim *simulation.Simulation) []*simulation.WorldSnapshot {
+	if len(frames.states) == 0 || frames.states[len(frames.states)-1].Round != sim.Round {
+		frames.states = append(frames.states, sim.SnapshotWorld())
+	}
+	return frames.states
+}
+
+/*
+	replayFrames returns the states of a recorded run before its first round, every n rounds and after its last round.
+*/
+func replayFrames(replayFile string, frames *frames, logger *zap.Logger) ([]*simulation.WorldSnapshot, error) {
+	replay, err := simulation.LoadReplay(replayFile, logger)
+	if err != nil {
+		return nil, err
+	}
+	for round := replay.FirstRound(); round < replay.LastRound(); round++ {
+		if !frames.wants(round) {
+			continue
+		}
+		sim, err := replay.Seek(round)
+		if err != nil {
+			return nil, err
+		}
+		frames.add(sim)
+	}
+	sim, err := replay.Seek(replay.LastRound())
+	if err != nil {
+		return nil, err
+	}
+	return frames.end(sim), nil
+}
+
+/*
+	runFrames runs a new simulation and returns the states of the world before the invasion, every n rounds and at the end.
+*/
+func runFrames(iterations, aliens int, names, world string, seed int64, frames *frames, logger *zap.Logger) ([]*simulation.WorldSnapshot, error) {
+	sim, err := newRun(iterations, aliens, names, world, seed, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	frames.add(sim)
+	sim.OnRound(frames.add)
+	for !sim.Ended() {
+		sim.Step()
+	}
+	return frames.end(sim), nil
+}
+
+/*
+	newRun loads a new simulation seeded with seed, the current time if 0, without printing its rounds.
+*/
+func newRun(iterations, aliens int, names, world string, seed int64, logger *zap.Logger) (*simulation.Simulation, error) {
+	if err := utils.ValidateInput(iterations, aliens, names, world); err != nil {
+		return nil, err
+	}
+	sim, err := simulation.NewSimulation(iterations, aliens, names, world, nil, logger)
+	if err != nil {
+		return nil, err
+	}
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	sim.SetSeed(seed)
+	sim.Output = io.Discard
+	if err := graphio.LoadWorld(sim); err != nil {
+		return nil, err
+	}
+	if err := sim.CreateAliens(); err != nil {
+		return nil, err
+	}
+	fmt.Printf("Using the seed %d\n", seed)
+	return sim, nil
+}
+
+/*
+	worldStates returns the states of the world to export: the world file alone,
+	or a recorded run before its first round and after the round (the last one if negative).
+*/
+func worldStates(world, replayFile string, round int, logger *zap.Logger) ([]*simulation.WorldSnapshot, error) {
+	if replayFile == "" {
+		sim, err := simulation.NewSimulation(0, 0, "", world, nil, logger)
+		if err != nil {
+			return nil, err
+		}
+		if err := graphio.LoadWorld(sim); err != nil {
+			return nil, err
+		}
+		return []*simulation.WorldSnapshot{sim.SnapshotWorld()}, nil
+	}
+
+	replay, err := simulation.LoadReplay(replayFile, logger)
+	if err != nil {
+		return nil, err
+	}
+	if round < 0 {
+		round = replay.LastRound()
+	}
+	states := make([]*simulation.WorldSnapshot, 0, 2)
+	for _, at := range []int{replay.FirstRound(), round} {
+		sim, err := replay.Seek(at)
+		if err != nil {
+			return nil, err
+		}
+		states = append(states, sim.SnapshotWorld())
+	}
+	return states, nil
+}
+
+/*
+	writeFile creates a file and writes it with write.
+*/
+func writeFile(path string, write func(w io.Writer) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("Error Creating the file : %s, Error: %s", path, err.Error())
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
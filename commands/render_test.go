@@ -0,0 +1,44 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rvsingh011/alien-invasion/simulation"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestFrames(t *testing.T) {
+	tests := []struct {
+		name       string
+		every      int
+		max        int
+		last       int
+		wantRounds []int
+		wantEvery  int
+	}{
+		{name: "Every round", every: 1, max: 10, last: 5, wantRounds: []int{0, 1, 2, 3, 4, 5}, wantEvery: 1},
+		{name: "Every other round and the last", every: 2, max: 10, last: 5, wantRounds: []int{0, 2, 4, 5}, wantEvery: 2},
+		{name: "Too many rounds", every: 1, max: 4, last: 13, wantRounds: []int{0, 4, 8, 12, 13}, wantEvery: 4},
+		{name: "Too many rounds for the rounds between two frames", every: 3, max: 3, last: 20, wantRounds: []int{0, 12, 20}, wantEvery: 12},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sim, err := simulation.NewSimulation(tt.last, 0, "", "", nil, zap.NewNop())
+			assert.NoError(t, err)
+			assert.NoError(t, sim.LoadWorld(strings.NewReader("Foo north=Bar")))
+
+			kept := &frames{every: tt.every, max: tt.max}
+			for ; sim.Round < tt.last; sim.Round++ {
+				kept.add(sim)
+			}
+			rounds := make([]int, 0)
+			for _, state := range kept.end(sim) {
+				rounds = append(rounds, state.Round)
+			}
+			assert.Equal(t, tt.wantRounds, rounds)
+			assert.Equal(t, tt.wantEvery, kept.every)
+		})
+	}
+}
@@ -0,0 +1,288 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rvsingh011/alien-invasion/graphio"
+	"github.com/rvsingh011/alien-invasion/simulation"
+	"github.com/rvsingh011/alien-invasion/utils"
+	"go.uber.org/zap"
+)
+
+// replHelp lists the commands of the interactive shell
+const replHelp = `Commands:
+  step [n]           run the next n rounds of attack, 1 by default
+  show city <city>   show the roads of a city and the aliens in it
+  show alien <alien> show where an alien is
+  where              show where every alien is
+  map                show what is left of the world
+  move <alien> to <city>
+                     move an alien to any city, aliens meeting there fight straight away
+  destroy <city>     destroy a city and every alien in it
+  undo               undo the last step, move or destroy
+  save <file>        checkpoint the simulation, resume it with -resume
+  help               show this help
+  quit               leave the shell`
+
+/*
+	replCommand starts an interactive shell on a new or checkpointed simulation.
+*/
+func replCommand(args []string, logger *zap.Logger) error {
+	flags := flag.NewFlagSet("repl", flag.ContinueOnError)
+	iterations := flags.Int("iterations", 10000, "number of iterations")
+	aliens := flags.Int("aliens", 10, "number of aliens invading")
+	names := flags.String("names", "./data/alien_names.txt", "a file used as alien names input")
+	world := flags.String("world", "./data/world-example-1.txt", "a file used as world map input")
+	seed := flags.Int64("seed", 0, "seed of the random generator, the current time is used if 0")
+	resume := flags.String("resume", "", "a checkpoint file to resume the simulation from")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var sim *simulation.Simulation
+	var err error
+	if *resume != "" {
+		if sim, err = simulation.LoadCheckpoint(*resume, logger); err != nil {
+			return err
+		}
+	} else {
+		if err := utils.ValidateInput(*iterations, *aliens, *names, *world); err != nil {
+			return err
+		}
+		if sim, err = simulation.NewSimulation(*iterations, *aliens, *names, *world, nil, logger); err != nil {
+			return err
+		}
+		if *seed == 0 {
+			*seed = time.Now().UnixNano()
+		}
+		sim.SetSeed(*seed)
+		if err := graphio.LoadWorld(sim); err != nil {
+			return err
+		}
+		if err := sim.CreateAliens(); err != nil {
+			return err
+		}
+		fmt.Printf("Using the seed %d\n", *seed)
+	}
+
+	shell := newShell(sim, os.Stdout, logger)
+	return shell.run(os.Stdin)
+}
+
+/*
+	shell is an interactive shell exploring a simulation.
+*/
+type shell struct {
+	sim    *simulation.Simulation
+	out    io.Writer
+	logger *zap.Logger
+
+	// checkpoints taken before every command changing the simulation, used to undo them
+	history [][]byte
+}
+
+/*
+	newShell returns a shell exploring the simulation, the messages of the simulation are written to out.
+*/
+func newShell(sim *simulation.Simulation, out io.Writer, logger *zap.Logger) *shell {
+	sim.Output = out
+	return &shell{sim: sim, out: out, logger: logger}
+}
+
+/*
+	run reads commands from in until the input ends or the quit command.
+*/
+func (shell *shell) run(in io.Reader) error {
+	fmt.Fprintf(shell.out, "%d cities and %d aliens, round %d, type help for the commands\n", len(shell.sim.Cities), len(shell.sim.Aliens), shell.sim.Round)
+	scanner := bufio.NewScanner(in)
+	for fmt.Fprint(shell.out, "invasion> "); scanner.Scan(); fmt.Fprint(shell.out, "invasion> ") {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "quit" || fields[0] == "exit" {
+			return nil
+		}
+		if err := shell.execute(fields); err != nil {
+			fmt.Fprintln(shell.out, err.Error())
+		}
+	}
+	fmt.Fprintln(shell.out)
+	return scanner.Err()
+}
+
+/*
+	execute runs a single command.
+*/
+func (shell *shell) execute(fields []string) error {
+	sim := shell.sim
+	switch fields[0] {
+	case "step":
+		count := 1
+		if len(fields) > 1 {
+			var err error
+			if count, err = strconv.Atoi(fields[1]); err != nil || count < 1 {
+				return fmt.Errorf("Invalid number of rounds %s", fields[1])
+			}
+		}
+		if sim.Ended() {
+			return fmt.Errorf("The attack is over after round %d", sim.Round)
+		}
+		return shell.change(func() error {
+			for step := 0; step < count && !sim.Ended(); step++ {
+				sim.Step()
+			}
+			return nil
+		})
+	case "show":
+		if len(fields) < 3 {
+			return fmt.Errorf("Usage: show city <city> or show alien <alien>")
+		}
+		name := strings.Join(fields[2:], " ")
+		switch fields[1] {
+		case "city":
+			return shell.showCity(name)
+		case "alien":
+			alien, ok := sim.FindAlien(name)
+			if !ok {
+				return fmt.Errorf("There is no alien %s alive", name)
+			}
+			city, ok := sim.AlienCityMapping[alien.ID]
+			if !ok {
+				return fmt.Errorf("The alien %s has not arrived yet", name)
+			}
+			fmt.Fprintf(shell.out, "The alien %s is in %s\n", name, city)
+			return nil
+		}
+		return fmt.Errorf("Usage: show city <city> or show alien <alien>")
+	case "where":
+		shell.where()
+		return nil
+	case "map":
+		fmt.Fprint(shell.out, sim.WorldMap())
+		return nil
+	case "move":
+		to := -1
+		for idx := len(fields) - 2; idx > 1; idx-- {
+			if fields[idx] == "to" {
+				to = idx
+				break
+			}
+		}
+		if to < 0 {
+			return fmt.Errorf("Usage: move <alien> to <city>")
+		}
+		return shell.change(func() error {
+			return sim.MoveAlien(strings.Join(fields[1:to], " "), strings.Join(fields[to+1:], " "))
+		})
+	case "destroy":
+		if len(fields) < 2 {
+			return fmt.Errorf("Usage: destroy <city>")
+		}
+		return shell.change(func() error {
+			return sim.DestroyCity(strings.Join(fields[1:], " "))
+		})
+	case "undo":
+		return shell.undo()
+	case "save":
+		if len(fields) != 2 {
+			return fmt.Errorf("Usage: save <file>")
+		}
+		if err := sim.SaveCheckpoint(fields[1]); err != nil {
+			return err
+		}
+		fmt.Fprintf(shell.out, "Checkpoint of round %d saved to %s\n", sim.Round, fields[1])
+		return nil
+	case "help":
+		fmt.Fprintln(shell.out, replHelp)
+		return nil
+	}
+	return fmt.Errorf("Unknown command %s, type help for the commands", fields[0])
+}
+
+/*
+	change checkpoints the simulation before running a command changing it, so it can be undone.
+	The checkpoint is dropped if the command fails.
+*/
+func (shell *shell) change(command func() error) error {
+	var checkpoint bytes.Buffer
+	if err := shell.sim.WriteCheckpoint(&checkpoint); err != nil {
+		return err
+	}
+	if err := command(); err != nil {
+		return err
+	}
+	shell.history = append(shell.history, checkpoint.Bytes())
+	return nil
+}
+
+/*
+	undo restores the simulation as it was before the last command changing it.
+*/
+func (shell *shell) undo() error {
+	if len(shell.history) == 0 {
+		return fmt.Errorf("Nothing to undo")
+	}
+	last := shell.history[len(shell.history)-1]
+	sim, err := simulation.ReadCheckpoint(bytes.NewReader(last), shell.logger)
+	if err != nil {
+		return err
+	}
+	shell.history = shell.history[:len(shell.history)-1]
+	sim.Output = shell.out
+	shell.sim = sim
+	fmt.Fprintf(shell.out, "Back to round %d\n", sim.Round)
+	return nil
+}
+
+/*
+	showCity prints the roads of a city and the aliens in it.
+*/
+func (shell *shell) showCity(city string) error {
+	roads, ok := shell.sim.World[city]
+	if !ok {
+		return fmt.Errorf("There is no city %s left", city)
+	}
+	fmt.Fprintf(shell.out, "The City %s is connected to %d cities\n", city, len(roads))
+	for _, road := range roads {
+		fmt.Fprintf(shell.out, "\tThe City %s is %s to the %s\n", road.Name, road.Direction, city)
+	}
+	aliens := make([]string, 0)
+	for _, alien := range shell.sim.Aliens {
+		if shell.sim.AlienCityMapping[alien.ID] == city {
+			aliens = append(aliens, alien.String())
+		}
+	}
+	if len(aliens) == 0 {
+		fmt.Fprintf(shell.out, "No alien is in %s\n", city)
+		return nil
+	}
+	fmt.Fprintf(shell.out, "Aliens in %s: %s\n", city, strings.Join(aliens, ", "))
+	return nil
+}
+
+/*
+	where prints the city of every alien alive, in the order they joined the invasion.
+*/
+func (shell *shell) where() {
+	if shell.sim.Round == 0 {
+		fmt.Fprintf(shell.out, "The %d aliens have not arrived yet\n", len(shell.sim.Aliens))
+		return
+	}
+	alive := 0
+	for _, alien := range shell.sim.Aliens {
+		if city, ok := shell.sim.AlienCityMapping[alien.ID]; ok {
+			fmt.Fprintf(shell.out, "The alien %s is in %s\n", alien, city)
+			alive++
+		}
+	}
+	fmt.Fprintf(shell.out, "%d aliens alive after round %d\n", alive, shell.sim.Round)
+}
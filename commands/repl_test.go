@@ -0,0 +1,78 @@
+package commands
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/rvsingh011/alien-invasion/simulation"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func newTestShell(t *testing.T) (*shell, *bytes.Buffer) {
+	sim, err := simulation.NewSimulation(100, 2, "", "", nil, zap.NewNop())
+	assert.NoError(t, err)
+	sim.SetSeed(3)
+	assert.NoError(t, sim.LoadWorld(strings.NewReader("Foo north=Bar west=Baz south=Qu-ux\nBar west=Bee north=Lee")))
+	assert.NoError(t, sim.LoadAliens(strings.NewReader("Michael\nJessica")))
+	var out bytes.Buffer
+	return newShell(sim, &out, zap.NewNop()), &out
+}
+
+func TestShell_run(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		wantOutputs []string
+		wantCities  int
+		wantAliens  int
+	}{
+		{
+			name:        "Show the arrival",
+			input:       "step\nwhere\n",
+			wantOutputs: []string{"2 aliens alive after round 1"},
+			wantCities:  6,
+			wantAliens:  2,
+		},
+		{
+			name:        "Manual move makes the aliens fight",
+			input:       "step\nmove Michael to Foo\nmove Jessica to Foo\nshow city Foo\n",
+			wantOutputs: []string{"The Foo was destroyed by", "There is no city Foo left"},
+			wantCities:  5,
+			wantAliens:  0,
+		},
+		{
+			name:        "Undo a destroyed city",
+			input:       "destroy Foo\nundo\nshow city Foo\n",
+			wantOutputs: []string{"The Foo was destroyed", "Back to round 0", "The City Foo is connected to 3 cities"},
+			wantCities:  6,
+			wantAliens:  2,
+		},
+		{
+			name:        "Nothing to undo",
+			input:       "undo\nmove Michael to Foo\n",
+			wantOutputs: []string{"Nothing to undo", "The aliens have not arrived yet"},
+			wantCities:  6,
+			wantAliens:  2,
+		},
+		{
+			name:        "Unknown command",
+			input:       "fly Michael\nquit\nstep\n",
+			wantOutputs: []string{"Unknown command fly"},
+			wantCities:  6,
+			wantAliens:  2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shell, out := newTestShell(t)
+			assert.NoError(t, shell.run(strings.NewReader(tt.input)))
+			for _, want := range tt.wantOutputs {
+				assert.Contains(t, out.String(), want)
+			}
+			assert.Equal(t, tt.wantCities, len(shell.sim.World))
+			assert.Equal(t, tt.wantAliens, len(shell.sim.Aliens))
+		})
+	}
+}
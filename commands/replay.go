@@ -0,0 +1,115 @@
+package commands
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rvsingh011/alien-invasion/simulation"
+	"go.uber.org/zap"
+)
+
+/*
+	replayCommand prints the state of a recorded run at a round: replay [-round n] [-interactive] <file>
+*/
+func replayCommand(args []string, logger *zap.Logger) error {
+	flags := flag.NewFlagSet("replay", flag.ContinueOnError)
+	round := flags.Int("round", -1, "round to print the map at, the last recorded round if negative")
+	interactive := flags.Bool("interactive", false, "step through the recording from the standard input")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("Usage: replay [-round n] [-interactive] <replay file>")
+	}
+
+	replay, err := simulation.LoadReplay(flags.Arg(0), logger)
+	if err != nil {
+		return err
+	}
+	if *round < 0 {
+		*round = replay.LastRound()
+	}
+	sim, err := replay.Seek(*round)
+	if err != nil {
+		return err
+	}
+	printReplayRound(sim)
+	if !*interactive {
+		return nil
+	}
+
+	fmt.Printf("Recording covers rounds %d to %d, commands: next [n], prev [n], seek <round>, map, quit\n", replay.FirstRound(), replay.LastRound())
+	scanner := bufio.NewScanner(os.Stdin)
+	for fmt.Print("replay> "); scanner.Scan(); fmt.Print("replay> ") {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		target := sim.Round
+		switch fields[0] {
+		case "next", "n":
+			target += replayCount(fields)
+		case "prev", "p":
+			target -= replayCount(fields)
+		case "seek", "s":
+			if len(fields) != 2 {
+				fmt.Println("Usage: seek <round>")
+				continue
+			}
+			if target, err = strconv.Atoi(fields[1]); err != nil {
+				fmt.Printf("Invalid round %s\n", fields[1])
+				continue
+			}
+		case "map", "m":
+		case "quit", "q":
+			return nil
+		default:
+			fmt.Printf("Unknown command %s\n", fields[0])
+			continue
+		}
+		if sim, err = replay.Seek(target); err != nil {
+			fmt.Println(err.Error())
+			continue
+		}
+		printReplayRound(sim)
+	}
+	return scanner.Err()
+}
+
+/*
+	replayCount returns the optional number of rounds of a next or prev command.
+*/
+func replayCount(fields []string) int {
+	if len(fields) < 2 {
+		return 1
+	}
+	count, err := strconv.Atoi(fields[1])
+	if err != nil || count < 1 {
+		return 1
+	}
+	return count
+}
+
+/*
+	printReplayRound prints the map and the position of the aliens of a replayed simulation.
+*/
+func printReplayRound(sim *simulation.Simulation) {
+	println("=========================================")
+	fmt.Printf("The World after round %d\n", sim.Round)
+	println("=========================================")
+	fmt.Print(sim.WorldMap())
+
+	aliens := make([]string, 0, len(sim.AlienCityMapping))
+	for _, alien := range sim.Aliens {
+		if city, ok := sim.AlienCityMapping[alien.ID]; ok {
+			aliens = append(aliens, fmt.Sprintf("%s in %s", alien, city))
+		}
+	}
+	sort.Strings(aliens)
+	fmt.Printf("%d aliens alive: %s\n", len(aliens), strings.Join(aliens, ", "))
+}
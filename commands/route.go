@@ -0,0 +1,182 @@
+package commands
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/rvsingh011/alien-invasion/route"
+	"github.com/rvsingh011/alien-invasion/simulation"
+	"go.uber.org/zap"
+)
+
+/*
+	roundPlan is the evacuation plan of a round, as written by the route command.
+*/
+type roundPlan struct {
+	Round int         `json:"round"`
+	Plan  *route.Plan `json:"plan"`
+}
+
+/*
+	routeCommand plans the evacuation of a city to safe destinations, round after round of an invasion:
+	route -from city -to city,... [-world file] [-iterations n] [-aliens n] [-names file] [-seed n] | [-replay file] [-round n] [-json out.json]
+	The routes are printed every time they change, or for the round given by -round only.
+*/
+func routeCommand(args []string, logger *zap.Logger) error {
+	flags := flag.NewFlagSet("route", flag.ContinueOnError)
+	from := flags.String("from", "", "the city evacuated")
+	to := flags.String("to", "", "comma separated safe destinations")
+	world := flags.String("world", "./data/world-example-1.txt", "a file used as world map input")
+	replayFile := flags.String("replay", "", "a recorded run to plan on instead of running an invasion")
+	round := flags.Int("round", -1, "the only round to plan the evacuation after, every round if negative")
+	iterations := flags.Int("iterations", 10000, "without -replay, number of iterations of the invasion")
+	aliens := flags.Int("aliens", 10, "without -replay, number of aliens invading")
+	names := flags.String("names", "./data/alien_names.txt", "without -replay, a file used as alien names input")
+	seed := flags.Int64("seed", 0, "without -replay, seed of the random generator, the current time is used if 0")
+	jsonFile := flags.String("json", "", "a file the plans of every round are written to as JSON")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *from == "" || *to == "" {
+		return fmt.Errorf("The city evacuated and the destinations are required, see -from and -to")
+	}
+	destinations := strings.Split(*to, ",")
+	for idx := range destinations {
+		destinations[idx] = strings.TrimSpace(destinations[idx])
+	}
+
+	planner := &evacuation{from: *from, destinations: destinations, round: *round, plans: make([]roundPlan, 0)}
+	if *replayFile != "" {
+		if err := planner.replay(*replayFile, logger); err != nil {
+			return err
+		}
+	} else {
+		sim, err := newRun(*iterations, *aliens, *names, *world, *seed, logger)
+		if err != nil {
+			return err
+		}
+		if err := planner.check(sim); err != nil {
+			return err
+		}
+		sim.OnRound(planner.plan)
+		for !sim.Ended() && !planner.done {
+			sim.Step()
+		}
+	}
+	printPlans(planner.plans, *round >= 0)
+
+	if *jsonFile != "" {
+		return writeFile(*jsonFile, func(w io.Writer) error {
+			encoder := json.NewEncoder(w)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(planner.plans)
+		})
+	}
+	return nil
+}
+
+/*
+	evacuation plans the evacuation of a city on the live state of a simulation after every round the aliens are in the
+	world, or after the given round only, until the city evacuated is destroyed.
+	The world is never copied, only the plans are kept.
+*/
+type evacuation struct {
+	from         string
+	destinations []string
+	// the only round planned, every round if negative
+	round int
+	plans []roundPlan
+	// no round is left to plan
+	done bool
+}
+
+/*
+	check makes sure the cities of the evacuation are in the world before the invasion.
+*/
+func (planner *evacuation) check(sim *simulation.Simulation) error {
+	for _, city := range append([]string{planner.from}, planner.destinations...) {
+		if _, ok := sim.World[city]; !ok {
+			return fmt.Errorf("There is no city %s in the world", city)
+		}
+	}
+	return nil
+}
+
+/*
+	plan plans the evacuation after the round the simulation just ran, it is a round hook.
+*/
+func (planner *evacuation) plan(sim *simulation.Simulation) {
+	if planner.done || sim.Round < 1 || (planner.round >= 0 && sim.Round < planner.round) {
+		return
+	}
+	if _, ok := sim.World[planner.from]; !ok {
+		planner.done = true
+		return
+	}
+	// the city evacuated is standing, the plan cannot fail
+	plan, _ := route.Find(sim.World, sim.AlienCityMapping, planner.from, planner.destinations)
+	planner.plans = append(planner.plans, roundPlan{Round: sim.Round, Plan: plan})
+	planner.done = planner.round >= 0
+}
+
+/*
+	replay plans the evacuation on the rounds of a recorded run, only the round planned is sought with -round.
+*/
+func (planner *evacuation) replay(replayFile string, logger *zap.Logger) error {
+	replay, err := simulation.LoadReplay(replayFile, logger)
+	if err != nil {
+		return err
+	}
+	sim, err := replay.Seek(replay.FirstRound())
+	if err != nil {
+		return err
+	}
+	if err := planner.check(sim); err != nil {
+		return err
+	}
+	first, last := replay.FirstRound(), replay.LastRound()
+	if planner.round >= 0 {
+		if planner.round > first {
+			first = planner.round
+		}
+		if planner.round < last {
+			last = planner.round
+		}
+	}
+	for round := first; round <= last && !planner.done; round++ {
+		if sim, err = replay.Seek(round); err != nil {
+			return err
+		}
+		planner.plan(sim)
+	}
+	return nil
+}
+
+/*
+	printPlans prints the plans which differ from the plan of the round before, or every plan.
+*/
+func printPlans(plans []roundPlan, every bool) {
+	if len(plans) == 0 {
+		fmt.Println("There is no round to plan an evacuation after")
+		return
+	}
+	for idx, plan := range plans {
+		if !every && idx > 0 && reflect.DeepEqual(plan.Plan, plans[idx-1].Plan) {
+			continue
+		}
+		fmt.Printf("After round %d:\n", plan.Round)
+		for _, way := range plan.Plan.Routes {
+			fmt.Printf("\t%s (%d roads, risk %d)\n", strings.Join(way.Path, " -> "), way.Length, way.Risk)
+		}
+		if len(plan.Plan.Unreachable) > 0 {
+			fmt.Printf("\tno safe route to %s\n", strings.Join(plan.Plan.Unreachable, ", "))
+		}
+		if len(plan.Plan.Destroyed) > 0 {
+			fmt.Printf("\tdestroyed: %s\n", strings.Join(plan.Plan.Destroyed, ", "))
+		}
+	}
+}
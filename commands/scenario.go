@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/rvsingh011/alien-invasion/history"
+	"github.com/rvsingh011/alien-invasion/scenario"
+	"go.uber.org/zap"
+)
+
+/*
+	scenarioCommand runs a JSON scenario file: scenario run [-history dir] <file>, YAML is not supported
+*/
+func scenarioCommand(args []string, logger *zap.Logger) error {
+	usage := fmt.Errorf("Usage: scenario run [-history dir] <scenario.json>, scenarios are written in JSON, YAML is not supported")
+	if len(args) == 0 || args[0] != "run" {
+		return usage
+	}
+	flags := flag.NewFlagSet("scenario run", flag.ContinueOnError)
+	historyDir := flags.String("history", "", "a directory the run is kept in, e.g. "+history.DefaultDir+", see the history command, not kept if empty")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return usage
+	}
+	invasion, err := scenario.Load(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	sim, seed, err := invasion.NewSimulation(logger)
+	if err != nil {
+		return err
+	}
+	var entry *history.Entry
+	if *historyDir != "" {
+		store, err := history.Open(*historyDir)
+		if err != nil {
+			return err
+		}
+		entry = store.Track(sim, "scenario")
+	}
+	result, err := invasion.Play(sim, seed)
+	if err != nil {
+		return err
+	}
+	if entry != nil {
+		record, err := entry.Save(sim, "finished")
+		if err != nil {
+			return err
+		}
+		fmt.Printf("The run was kept as %s\n", record.ID)
+	}
+
+	println("=========================================")
+	fmt.Printf("Scenario %s finished with seed %d\n", invasion.Name, result.Seed)
+	println("=========================================")
+	fmt.Printf("Destroyed cities: %v\n", result.Destroyed)
+	if !result.Passed() {
+		for _, failure := range result.Failures {
+			fmt.Printf("FAIL: %s\n", failure)
+		}
+		return fmt.Errorf("%d expectations of the scenario were not met", len(result.Failures))
+	}
+	fmt.Println("PASS: all expectations of the scenario were met")
+	return nil
+}
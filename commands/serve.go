@@ -0,0 +1,44 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+
+	"github.com/rvsingh011/alien-invasion/history"
+	"github.com/rvsingh011/alien-invasion/server"
+	"go.uber.org/zap"
+)
+
+/*
+	serveCommand exposes the REST API running simulations on demand: serve [-addr :8080] [-max-running n] [-max-queued n] [-history dir] [-keep-runs n] [-keep-events n]
+	[-max-aliens n] [-max-iterations n] [-max-cities n]
+*/
+func serveCommand(args []string, logger *zap.Logger) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := flags.String("addr", ":8080", "address the API listens on")
+	maxRunning := flags.Int("max-running", 4, "number of runs simulated at once")
+	maxQueued := flags.Int("max-queued", 64, "number of runs waiting for a free slot, more runs are refused")
+	historyDir := flags.String("history", "", "a directory the runs are kept in, e.g. "+history.DefaultDir+", see the history command, not kept if empty")
+	keepRuns := flags.Int("keep-runs", server.DefaultLimits.Runs, "number of runs over kept in memory, the oldest are forgotten first")
+	keepEvents := flags.Int("keep-events", server.DefaultLimits.Events, "number of events kept in memory by run, the oldest are dropped first")
+	maxAliens := flags.Int("max-aliens", server.DefaultLimits.Aliens, "largest number of aliens of a run, landings included")
+	maxIterations := flags.Int("max-iterations", server.DefaultLimits.Iterations, "largest number of iterations of a run")
+	maxCities := flags.Int("max-cities", server.DefaultLimits.Cities, "largest number of cities of the world of a run")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	api := server.New(*maxRunning, *maxQueued, logger)
+	defer api.Close()
+	api.SetLimits(server.Limits{Runs: *keepRuns, Events: *keepEvents, Aliens: *maxAliens, Iterations: *maxIterations, Cities: *maxCities})
+	if *historyDir != "" {
+		store, err := history.Open(*historyDir)
+		if err != nil {
+			return err
+		}
+		api.KeepRunsIn(store)
+	}
+	fmt.Printf("Serving the alien invasion API on %s\n", *addr)
+	return http.ListenAndServe(*addr, api)
+}
@@ -0,0 +1,358 @@
+package defense
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/rvsingh011/alien-invasion/engine"
+	"github.com/rvsingh011/alien-invasion/simulation"
+)
+
+// default options of the search
+const (
+	DefaultRuns       = 200
+	DefaultSteps      = 300
+	DefaultCandidates = 50
+	// initial temperature of the annealing, as a fraction of the weight of the world
+	DefaultTemperature = 0.01
+)
+
+/*
+	Options of the search of the cities to fortify.
+	Budget is the number of cities fortified. Every set of cities is scored by Runs attacks of Aliens aliens for at most
+	Iterations rounds, run with the seeds following Seed, so all the sets face the same attacks. Weights gives the weight of
+	every city, e.g. its population, cities not listed weigh nothing, every city weighs 1 if Weights is nil. Only the
+	Candidates cities destroyed most often without fortification are considered, the annealing tries Steps swaps starting
+	from a temperature of Temperature times the weight of the world.
+*/
+type Options struct {
+	Aliens      int
+	Iterations  int
+	Budget      int
+	Runs        int
+	Seed        int64
+	Weights     map[string]float64
+	Candidates  int
+	Steps       int
+	Temperature float64
+}
+
+/*
+	Plan is a set of fortified cities and the expected weight of the cities surviving the attack with them.
+*/
+type Plan struct {
+	Fortified []string `json:"fortified"`
+	Score     float64  `json:"score"`
+}
+
+/*
+	Result holds the expected weight of the cities surviving without fortification, the plan found by the greedy search
+	and the best plan found by the annealing, which starts from the greedy one. Evaluations counts the sets scored.
+*/
+type Result struct {
+	Baseline    float64  `json:"baseline"`
+	Candidates  []string `json:"candidates"`
+	Greedy      *Plan    `json:"greedy"`
+	Best        *Plan    `json:"best"`
+	Evaluations int      `json:"evaluations"`
+}
+
+/*
+	search scores sets of fortified cities on a world, scores are cached by set.
+*/
+type search struct {
+	world   *engine.World
+	options Options
+	aliens  []*simulation.Alien
+	// weight by city ID
+	weights []float64
+	total   float64
+	scores  map[string]float64
+}
+
+/*
+	Optimize searches the cities of a world to fortify for the most weight surviving an attack.
+	The greedy search fortifies the candidate adding the most to the score, one after the other, the annealing then swaps a
+	fortified city with another candidate at random, keeping a worse set with a probability falling with the temperature.
+*/
+func Optimize(world *engine.World, options Options) (*Result, error) {
+	if options.Budget < 1 {
+		return nil, fmt.Errorf("The budget must be at least one city")
+	}
+	if options.Budget > len(world.Cities) {
+		return nil, fmt.Errorf("The budget of %d cities is larger than the %d cities of the world", options.Budget, len(world.Cities))
+	}
+	if options.Runs <= 0 {
+		options.Runs = DefaultRuns
+	}
+	if options.Candidates <= 0 {
+		options.Candidates = DefaultCandidates
+	}
+	// the budget is spent whatever the number of candidates asked for
+	if options.Candidates < options.Budget {
+		options.Candidates = options.Budget
+	}
+	if options.Steps <= 0 {
+		options.Steps = DefaultSteps
+	}
+	if options.Temperature <= 0 {
+		options.Temperature = DefaultTemperature
+	}
+	s := newSearch(world, options)
+
+	baseline, destroyed := s.run(nil)
+	result := &Result{Baseline: baseline, Candidates: make([]string, 0)}
+	candidates := s.candidates(destroyed)
+	for _, city := range candidates {
+		result.Candidates = append(result.Candidates, world.Names[city])
+	}
+	// cities never destroyed gain nothing from a fortification, any of them does
+	for _, city := range world.Cities {
+		if len(candidates) >= options.Budget {
+			break
+		}
+		if destroyed[city] == 0 {
+			candidates = append(candidates, city)
+		}
+	}
+
+	greedy := s.greedy(candidates)
+	best := s.anneal(greedy, candidates)
+	result.Greedy = s.plan(greedy)
+	result.Best = s.plan(best)
+	result.Evaluations = len(s.scores)
+	return result, nil
+}
+
+func newSearch(world *engine.World, options Options) *search {
+	s := &search{
+		world:   world,
+		options: options,
+		aliens:  make([]*simulation.Alien, 0, options.Aliens),
+		weights: make([]float64, len(world.Names)),
+		scores:  make(map[string]float64),
+	}
+	for id := 1; id <= options.Aliens; id++ {
+		s.aliens = append(s.aliens, simulation.NewAlien(id, ""))
+	}
+	for _, city := range world.Cities {
+		weight := 1.0
+		if options.Weights != nil {
+			weight = options.Weights[world.Names[city]]
+		}
+		s.weights[city] = weight
+		s.total += weight
+	}
+	return s
+}
+
+/*
+	candidates returns the Candidates cities destroyed most often without fortification.
+*/
+func (s *search) candidates(destroyed []int) []int32 {
+	candidates := make([]int32, 0)
+	for _, city := range s.world.Cities {
+		if destroyed[city] > 0 {
+			candidates = append(candidates, city)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return float64(destroyed[candidates[i]])*s.weights[candidates[i]] > float64(destroyed[candidates[j]])*s.weights[candidates[j]]
+	})
+	if len(candidates) > s.options.Candidates {
+		candidates = candidates[:s.options.Candidates]
+	}
+	return candidates
+}
+
+/*
+	run attacks the world Runs times with the cities fortified, and returns the average weight of the cities left and the
+	number of runs every city was destroyed in. The runs are shared among the processors.
+*/
+func (s *search) run(fortified []int32) (float64, []int) {
+	runs := s.options.Runs
+	left := make([]float64, runs)
+	destroyed := make([][]int, runtime.NumCPU())
+	for worker := range destroyed {
+		destroyed[worker] = make([]int, len(s.world.Names))
+	}
+
+	var wg sync.WaitGroup
+	next := make(chan int)
+	for worker := range destroyed {
+		wg.Add(1)
+		go func(counts []int) {
+			defer wg.Done()
+			for run := range next {
+				attack := engine.NewAttack(s.world, s.aliens, s.options.Iterations, rand.New(rand.NewSource(s.options.Seed+int64(run))))
+				for _, city := range fortified {
+					// the cities fortified are standing cities of the world
+					_ = attack.Fortify(s.world.Names[city])
+				}
+				attack.Run()
+				for _, city := range s.world.Cities {
+					if attack.Stands(city) {
+						left[run] += s.weights[city]
+						continue
+					}
+					counts[city]++
+				}
+			}
+		}(destroyed[worker])
+	}
+	for run := 0; run < runs; run++ {
+		next <- run
+	}
+	close(next)
+	wg.Wait()
+
+	// summed in the order of the runs, the score does not depend on the scheduling
+	score := 0.0
+	for _, weight := range left {
+		score += weight
+	}
+	counts := destroyed[0]
+	for _, worker := range destroyed[1:] {
+		for city, count := range worker {
+			counts[city] += count
+		}
+	}
+	return score / float64(runs), counts
+}
+
+/*
+	score returns the score of a set of fortified cities, from the cache if it was scored before.
+*/
+func (s *search) score(fortified []int32) float64 {
+	key := setKey(fortified)
+	if score, ok := s.scores[key]; ok {
+		return score
+	}
+	score, _ := s.run(fortified)
+	s.scores[key] = score
+	return score
+}
+
+func setKey(fortified []int32) string {
+	sorted := append([]int32(nil), fortified...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	parts := make([]string, 0, len(sorted))
+	for _, city := range sorted {
+		parts = append(parts, strconv.Itoa(int(city)))
+	}
+	return strings.Join(parts, ",")
+}
+
+/*
+	greedy fortifies the candidates one after the other, each time the one adding the most to the score, until the budget
+	is spent or no candidate is left.
+*/
+func (s *search) greedy(candidates []int32) []int32 {
+	fortified := make([]int32, 0, s.options.Budget)
+	taken := make(map[int32]bool, s.options.Budget)
+	for len(fortified) < s.options.Budget {
+		best, bestScore := int32(-1), math.Inf(-1)
+		for _, city := range candidates {
+			if taken[city] {
+				continue
+			}
+			if score := s.score(append(fortified, city)); score > bestScore {
+				best, bestScore = city, score
+			}
+		}
+		if best < 0 {
+			break
+		}
+		fortified = append(fortified, best)
+		taken[best] = true
+	}
+	return fortified
+}
+
+/*
+	anneal swaps a fortified city with another candidate at random, a better set is always kept and a worse one with the
+	probability exp(loss / temperature). The temperature falls geometrically to a hundredth of its start, the best set
+	seen is returned.
+*/
+func (s *search) anneal(start, candidates []int32) []int32 {
+	if len(candidates) <= len(start) {
+		return start
+	}
+	random := rand.New(rand.NewSource(s.options.Seed))
+	current := append([]int32(nil), start...)
+	currentScore := s.score(current)
+	best, bestScore := append([]int32(nil), current...), currentScore
+
+	temperature := s.options.Temperature * s.total
+	cooling := math.Pow(0.01, 1/float64(s.options.Steps))
+	for step := 0; step < s.options.Steps; step++ {
+		taken := make(map[int32]bool, len(current))
+		for _, city := range current {
+			taken[city] = true
+		}
+		replacement := candidates[random.Intn(len(candidates))]
+		for taken[replacement] {
+			replacement = candidates[random.Intn(len(candidates))]
+		}
+		proposal := append([]int32(nil), current...)
+		proposal[random.Intn(len(proposal))] = replacement
+
+		score := s.score(proposal)
+		if score >= currentScore || random.Float64() < math.Exp((score-currentScore)/temperature) {
+			current, currentScore = proposal, score
+			if score > bestScore {
+				best, bestScore = append([]int32(nil), proposal...), score
+			}
+		}
+		temperature *= cooling
+	}
+	return best
+}
+
+/*
+	plan names the cities of a set, sorted, with its score.
+*/
+func (s *search) plan(fortified []int32) *Plan {
+	plan := &Plan{Fortified: make([]string, 0, len(fortified)), Score: s.score(fortified)}
+	for _, city := range fortified {
+		plan.Fortified = append(plan.Fortified, s.world.Names[city])
+	}
+	sort.Strings(plan.Fortified)
+	return plan
+}
+
+/*
+	ReadWeights reads the weight of cities, e.g. their population, from lines holding a city name and a number:
+	Foo 120000
+	Lines starting with # are comments.
+*/
+func ReadWeights(r io.Reader) (map[string]float64, error) {
+	weights := make(map[string]float64)
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("Invalid weight on line %d, expected a city and a number: %s", line, text)
+		}
+		weight, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("Invalid weight on line %d, expected a positive number: %s", line, text)
+		}
+		weights[fields[0]] = weight
+	}
+	return weights, scanner.Err()
+}
@@ -0,0 +1,111 @@
+package defense
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rvsingh011/alien-invasion/engine"
+	"github.com/rvsingh011/alien-invasion/internal/worldtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func loadWorld(t *testing.T, worldMap string) *engine.World {
+	world, err := engine.FromSimulation(worldtest.Load(t, worldMap))
+	assert.NoError(t, err)
+	return world
+}
+
+// a star, Hub is on the way of every alien
+const star = "Hub north=A east=B south=C west=D\nA south=Hub\nB west=Hub\nC north=Hub\nD east=Hub"
+
+func TestOptimize(t *testing.T) {
+	tests := []struct {
+		name      string
+		options   Options
+		fortified []string
+		score     float64
+	}{
+		{
+			name:      "Protect the population",
+			options:   Options{Aliens: 6, Iterations: 100, Budget: 1, Runs: 50, Seed: 1, Weights: map[string]float64{"C": 1000}},
+			fortified: []string{"C"},
+			score:     1000,
+		},
+		{
+			// every city is destroyed without fortification, the budget is spent on more cities than the candidates
+			name:      "Fewer candidates than the budget",
+			options:   Options{Aliens: 20, Iterations: 100, Budget: 5, Runs: 50, Seed: 1, Candidates: 1},
+			fortified: []string{"A", "B", "C", "D", "Hub"},
+			score:     5,
+		},
+		{
+			name:      "Every city fortified",
+			options:   Options{Aliens: 6, Iterations: 100, Budget: 5, Runs: 50, Seed: 1},
+			fortified: []string{"A", "B", "C", "D", "Hub"},
+			score:     5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Optimize(loadWorld(t, star), tt.options)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.fortified, result.Best.Fortified)
+			assert.Equal(t, tt.score, result.Best.Score)
+			assert.GreaterOrEqual(t, result.Best.Score, result.Greedy.Score)
+			assert.Less(t, result.Baseline, result.Best.Score)
+		})
+	}
+}
+
+func TestOptimize_improves(t *testing.T) {
+	world := loadWorld(t, star)
+	options := Options{Aliens: 4, Iterations: 100, Budget: 2, Runs: 100, Seed: 3, Steps: 20}
+	result, err := Optimize(world, options)
+	assert.NoError(t, err)
+	assert.Len(t, result.Best.Fortified, 2)
+	assert.Greater(t, result.Greedy.Score, result.Baseline)
+	assert.GreaterOrEqual(t, result.Best.Score, result.Greedy.Score)
+
+	// the same attacks give the same plans
+	again, err := Optimize(world, options)
+	assert.NoError(t, err)
+	assert.Equal(t, result, again)
+}
+
+func TestSearch_greedyRunsOutOfCandidates(t *testing.T) {
+	world := loadWorld(t, star)
+	s := newSearch(world, Options{Aliens: 4, Iterations: 100, Budget: 3, Runs: 10, Seed: 1})
+	assert.Equal(t, []int32{world.Cities[0]}, s.greedy([]int32{world.Cities[0]}))
+}
+
+func TestOptimize_errors(t *testing.T) {
+	world := loadWorld(t, star)
+	_, err := Optimize(world, Options{Aliens: 2, Iterations: 10})
+	assert.EqualError(t, err, "The budget must be at least one city")
+	_, err = Optimize(world, Options{Aliens: 2, Iterations: 10, Budget: 6})
+	assert.EqualError(t, err, "The budget of 6 cities is larger than the 5 cities of the world")
+}
+
+func TestReadWeights(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    map[string]float64
+		wantErr string
+	}{
+		{name: "Population", text: "# city population\nFoo 120000\n\nBar 3.5\n", want: map[string]float64{"Foo": 120000, "Bar": 3.5}},
+		{name: "Missing number", text: "Foo\n", wantErr: "Invalid weight on line 1, expected a city and a number: Foo"},
+		{name: "Negative number", text: "Foo 1\nBar -2\n", wantErr: "Invalid weight on line 2, expected a positive number: Bar -2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			weights, err := ReadWeights(strings.NewReader(tt.text))
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, weights)
+		})
+	}
+}
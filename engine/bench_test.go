@@ -0,0 +1,42 @@
+package engine
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rvsingh011/alien-invasion/generator"
+	"github.com/rvsingh011/alien-invasion/internal/worldtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func BenchmarkEngine_Run(b *testing.B) {
+	scales := []struct {
+		side, aliens, rounds int
+	}{
+		{side: 10, aliens: 10, rounds: 1000},
+		{side: 100, aliens: 1000, rounds: 1000},
+		{side: 300, aliens: 10000, rounds: 1000},
+		{side: 1000, aliens: 100000, rounds: 1000},
+	}
+	for _, scale := range scales {
+		worldMap := worldtest.Generated(b, generator.Options{Topology: generator.TopologyGrid, Width: scale.side, Height: scale.side, Density: 1, Seed: 1})
+		b.Run(fmt.Sprintf("cities=%d/aliens=%d/rounds=%d", scale.side*scale.side, scale.aliens, scale.rounds), func(b *testing.B) {
+			b.ReportAllocs()
+			rounds := 0
+			var running time.Duration
+			for n := 0; n < b.N; n++ {
+				b.StopTimer()
+				sim := newTestSimulation(b, worldMap, scale.aliens, scale.rounds, 1)
+				engine, err := New(sim)
+				assert.NoError(b, err)
+				b.StartTimer()
+				started := time.Now()
+				engine.Run()
+				running += time.Since(started)
+				rounds += engine.Round
+			}
+			b.ReportMetric(float64(rounds)/running.Seconds(), "rounds/s")
+		})
+	}
+}
@@ -0,0 +1,525 @@
+package engine
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"github.com/rvsingh011/alien-invasion/simulation"
+)
+
+/*
+	Engine runs the attack of a simulation on interned cities and aliens.
+	It makes the same random draws in the same order as simulation.Start, so for the same seed it destroys the same cities
+	and leaves the same aliens in the same places, but without printing, emitting events or calling round hooks.
+	Every table is indexed by city or alien ID, moving an alien and checking the occupancy of a city take constant time.
+*/
+type Engine struct {
+	World      *World
+	Iterations int
+	Round      int
+
+	random *rand.Rand
+
+	// by city ID
+	standing  []bool
+	degree    []int32
+	mapped    []bool
+	occupants []int32
+	// fortified cities are never destroyed, aliens fighting in them die all the same
+	fortified []bool
+	// round a city got a second alien in, to list every contested city once
+	contestedIn []int32
+
+	// Links of the world, roads destroyed during the attack are tombstoned
+	links         []int32
+	standingCount int
+	contested     []int32
+
+	// by alien index
+	aliens    []*simulation.Alien
+	alienCity []int32
+	arrival   []int64
+	stamp     int64
+	// living aliens in the order they move
+	order []int32
+
+	// result of the last stalemate check, valid until a city is destroyed
+	stalemate        bool
+	stalemateChecked bool
+	// alien owning every city during a stalemate check, the cities searched are in queue
+	owner []int32
+	queue []int32
+
+	// objects of the simulation the engine was built from, reused when writing the state back
+	cityObjects []*simulation.City
+	linkObjects []*simulation.City
+
+	// OnFight is called for every city destroyed by a fight, with the aliens in the order they entered the city, fights in
+	// fortified cities are not reported
+	OnFight func(round int, city string, aliens []*simulation.Alien)
+}
+
+/*
+	New builds an engine continuing the attack of a simulation from its current round.
+	The engine draws from the attack vector of the simulation, scheduled events are not supported.
+*/
+func New(sim *simulation.Simulation) (*Engine, error) {
+	if len(sim.ScheduledEvents) > 0 {
+		return nil, fmt.Errorf("The engine does not support scheduled events")
+	}
+	if sim.RandSeed == nil {
+		return nil, fmt.Errorf("The simulation has no attack vector, seed the simulation with SetSeed")
+	}
+	world, links, err := fromSimulation(sim)
+	if err != nil {
+		return nil, err
+	}
+	engine := newEngine(world, sim.Iterations, sim.RandSeed)
+	engine.Round = sim.Round
+	// the listed cities were interned first, in order
+	engine.cityObjects = sim.Cities
+	engine.linkObjects = links
+
+	engine.enlist(sim.Aliens)
+	byID := make(map[int]int32, len(sim.Aliens))
+	for idx, alien := range sim.Aliens {
+		byID[alien.ID] = int32(idx)
+	}
+	for city, aliens := range sim.CityAlienMapping {
+		id := world.City(city)
+		engine.grow()
+		engine.mapped[id] = true
+		for _, alien := range aliens {
+			idx, ok := byID[alien]
+			if !ok {
+				continue
+			}
+			engine.stamp++
+			engine.alienCity[idx] = id
+			engine.arrival[idx] = engine.stamp
+			engine.occupy(id)
+		}
+	}
+	return engine, nil
+}
+
+/*
+	newEngine prepares the tables of the cities of the world, every listed city is standing with all its roads.
+*/
+func newEngine(world *World, iterations int, random *rand.Rand) *Engine {
+	cities := len(world.Names)
+	engine := &Engine{
+		World:       world,
+		Iterations:  iterations,
+		random:      random,
+		standing:    make([]bool, cities),
+		degree:      make([]int32, cities),
+		mapped:      make([]bool, cities),
+		fortified:   make([]bool, cities),
+		owner:       make([]int32, cities),
+		occupants:   make([]int32, cities),
+		contestedIn: make([]int32, cities),
+		links:       append([]int32(nil), world.Links...),
+	}
+	for _, city := range world.Cities {
+		engine.standing[city] = true
+		engine.degree[city] = world.Start[city+1] - world.Start[city]
+	}
+	engine.standingCount = len(world.Cities)
+	for city := range engine.contestedIn {
+		engine.contestedIn[city] = -1
+		engine.owner[city] = tombstone
+	}
+	return engine
+}
+
+/*
+	NewAttack builds an engine running a new attack on a world, the aliens arrive in the first round.
+	The world is not changed by the attack, so many engines can attack the same world one after the other or at once.
+*/
+func NewAttack(world *World, aliens []*simulation.Alien, iterations int, random *rand.Rand) *Engine {
+	engine := newEngine(world, iterations, random)
+	engine.enlist(aliens)
+	return engine
+}
+
+/*
+	enlist adds aliens to the attack, they have not arrived in a city yet.
+*/
+func (engine *Engine) enlist(aliens []*simulation.Alien) {
+	for _, alien := range aliens {
+		engine.aliens = append(engine.aliens, alien)
+		engine.alienCity = append(engine.alienCity, tombstone)
+		engine.arrival = append(engine.arrival, 0)
+		engine.order = append(engine.order, int32(len(engine.aliens)-1))
+	}
+}
+
+/*
+	Fortify makes a standing city impossible to destroy: aliens meeting there still fight and die, but the city and its
+	roads stay. An attack with fortified cities does not follow the simulation anymore.
+*/
+func (engine *Engine) Fortify(name string) error {
+	city, ok := engine.World.Lookup(name)
+	if !ok || !engine.standing[city] {
+		return fmt.Errorf("There is no city %s left to fortify", name)
+	}
+	engine.fortified[city] = true
+	return nil
+}
+
+/*
+	grow extends the tables of the cities to cities interned after the engine was built, they are destroyed cities.
+*/
+func (engine *Engine) grow() {
+	for len(engine.standing) < len(engine.World.Names) {
+		engine.standing = append(engine.standing, false)
+		engine.degree = append(engine.degree, 0)
+		engine.mapped = append(engine.mapped, false)
+		engine.fortified = append(engine.fortified, false)
+		engine.occupants = append(engine.occupants, 0)
+		engine.contestedIn = append(engine.contestedIn, -1)
+		engine.owner = append(engine.owner, tombstone)
+		engine.World.Start = append(engine.World.Start, engine.World.Start[len(engine.World.Start)-1])
+	}
+}
+
+/*
+	Ended reports whether the attack is over, either all rounds were run, there is nothing left to fight for or no fight
+	can happen anymore.
+*/
+func (engine *Engine) Ended() bool {
+	return engine.Round >= engine.Iterations || len(engine.order) < 1 || engine.standingCount < 1 || engine.Stalemate()
+}
+
+/*
+	Stalemate reports whether no two aliens left can reach a common city, like simulation.Stalemate.
+	The answer is only computed again after cities were destroyed.
+*/
+func (engine *Engine) Stalemate() bool {
+	if engine.Round == 0 {
+		return false
+	}
+	if !engine.stalemateChecked {
+		engine.stalemate = !engine.fightPossible()
+		engine.stalemateChecked = true
+	}
+	return engine.stalemate
+}
+
+/*
+	fightPossible searches the world from every alien at once, each city belongs to the first alien reaching it, two aliens
+	can meet as soon as one of them reaches a city of the other.
+*/
+func (engine *Engine) fightPossible() bool {
+	defer func() {
+		for _, city := range engine.queue {
+			engine.owner[city] = tombstone
+		}
+		engine.queue = engine.queue[:0]
+	}()
+	for _, alien := range engine.order {
+		city := engine.alienCity[alien]
+		if engine.owner[city] != tombstone {
+			return true
+		}
+		engine.owner[city] = alien
+		engine.queue = append(engine.queue, city)
+	}
+	for idx := 0; idx < len(engine.queue); idx++ {
+		city := engine.queue[idx]
+		if !engine.standing[city] {
+			continue
+		}
+		for road := engine.World.Start[city]; road < engine.World.Start[city+1]; road++ {
+			neighbour := engine.links[road]
+			if neighbour == tombstone {
+				continue
+			}
+			if engine.owner[neighbour] == tombstone {
+				engine.owner[neighbour] = engine.owner[city]
+				engine.queue = append(engine.queue, neighbour)
+				continue
+			}
+			if engine.owner[neighbour] != engine.owner[city] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+/*
+	Run runs the rounds of attack until the attack is over.
+*/
+func (engine *Engine) Run() {
+	for !engine.Ended() {
+		engine.Step()
+	}
+}
+
+/*
+	Step runs the next round of the attack, aliens arrive in the first round and move in the following rounds, then they fight.
+*/
+func (engine *Engine) Step() {
+	engine.Round++
+	if engine.Round == 1 {
+		engine.prepareAttack()
+	} else {
+		engine.moveAliens()
+	}
+	engine.fight()
+}
+
+/*
+	prepareAttack makes every alien choose the city it attacks first.
+*/
+func (engine *Engine) prepareAttack() {
+	engine.stalemateChecked = false
+	cities := engine.World.Cities
+	for _, city := range cities {
+		engine.mapped[city] = true
+	}
+	for _, alien := range engine.order {
+		engine.place(alien, cities[engine.random.Intn(len(cities))])
+	}
+}
+
+/*
+	moveAliens moves every alien to a neighbour city or keeps it in place, aliens without roads are trapped.
+*/
+func (engine *Engine) moveAliens() {
+	for _, alien := range engine.order {
+		city := engine.alienCity[alien]
+		degree := int(engine.degree[city])
+		if degree == 0 {
+			continue
+		}
+		pick := engine.random.Intn(degree + 1)
+		if pick == degree {
+			continue
+		}
+		engine.occupants[city]--
+		engine.place(alien, engine.link(city, pick))
+	}
+}
+
+/*
+	link returns the target of the nth road of a city still standing, skipping the tombstones.
+*/
+func (engine *Engine) link(city int32, nth int) int32 {
+	for idx := engine.World.Start[city]; ; idx++ {
+		if engine.links[idx] == tombstone {
+			continue
+		}
+		if nth == 0 {
+			return engine.links[idx]
+		}
+		nth--
+	}
+}
+
+/*
+	place records an alien entering a city.
+*/
+func (engine *Engine) place(alien, city int32) {
+	engine.stamp++
+	engine.alienCity[alien] = city
+	engine.arrival[alien] = engine.stamp
+	engine.mapped[city] = true
+	engine.occupy(city)
+}
+
+/*
+	occupy counts one more alien in a city, a city with two aliens is contested.
+*/
+func (engine *Engine) occupy(city int32) {
+	engine.occupants[city]++
+	if engine.occupants[city] == 2 && engine.contestedIn[city] != int32(engine.Round) {
+		engine.contestedIn[city] = int32(engine.Round)
+		engine.contested = append(engine.contested, city)
+	}
+}
+
+/*
+	fight destroys the cities with more than one alien, together with the aliens in them, fortified cities are left standing.
+*/
+func (engine *Engine) fight() {
+	fighting := engine.contested[:0]
+	for _, city := range engine.contested {
+		if engine.occupants[city] > 1 {
+			fighting = append(fighting, city)
+		}
+	}
+	engine.contested = fighting[:0]
+	if len(fighting) == 0 {
+		return
+	}
+	engine.stalemateChecked = false
+
+	var fighters map[int32][]int32
+	if engine.OnFight != nil {
+		fighters = make(map[int32][]int32, len(fighting))
+	}
+	alive := engine.order[:0]
+	for _, alien := range engine.order {
+		city := engine.alienCity[alien]
+		if engine.occupants[city] < 2 {
+			alive = append(alive, alien)
+			continue
+		}
+		engine.alienCity[alien] = tombstone
+		if fighters != nil {
+			fighters[city] = append(fighters[city], alien)
+		}
+	}
+	engine.order = alive
+
+	// report the fights in a stable order
+	sort.Slice(fighting, func(i, j int) bool {
+		return engine.World.Names[fighting[i]] < engine.World.Names[fighting[j]]
+	})
+	for _, city := range fighting {
+		if engine.fortified[city] {
+			engine.occupants[city] = 0
+			continue
+		}
+		if fighters != nil {
+			aliens := fighters[city]
+			sort.Slice(aliens, func(i, j int) bool { return engine.arrival[aliens[i]] < engine.arrival[aliens[j]] })
+			fought := make([]*simulation.Alien, 0, len(aliens))
+			for _, alien := range aliens {
+				fought = append(fought, engine.aliens[alien])
+			}
+			engine.OnFight(engine.Round, engine.World.Names[city], fought)
+		}
+		engine.destroy(city)
+	}
+}
+
+/*
+	destroy removes a city and the roads leading back to it from its neighbours.
+	Like the simulation, only the roads listed by the destroyed city are removed from the neighbours.
+*/
+func (engine *Engine) destroy(city int32) {
+	engine.mapped[city] = false
+	engine.occupants[city] = 0
+	if !engine.standing[city] {
+		return
+	}
+	engine.standing[city] = false
+	engine.standingCount--
+	for idx := engine.World.Start[city]; idx < engine.World.Start[city+1]; idx++ {
+		neighbour := engine.links[idx]
+		if neighbour == tombstone || !engine.standing[neighbour] {
+			continue
+		}
+		for back := engine.World.Start[neighbour]; back < engine.World.Start[neighbour+1]; back++ {
+			if engine.links[back] == city {
+				engine.links[back] = tombstone
+				engine.degree[neighbour]--
+				break
+			}
+		}
+	}
+	engine.degree[city] = 0
+}
+
+/*
+	Standing returns the number of cities standing.
+*/
+func (engine *Engine) Standing() int {
+	return engine.standingCount
+}
+
+/*
+	Stands reports whether a city of the world is still standing.
+*/
+func (engine *Engine) Stands(city int32) bool {
+	return int(city) < len(engine.standing) && engine.standing[city]
+}
+
+/*
+	Alive returns the number of aliens alive.
+*/
+func (engine *Engine) Alive() int {
+	return len(engine.order)
+}
+
+/*
+	WriteBack stores the state of the attack in a simulation, as simulation.Start would have left it.
+*/
+func (engine *Engine) WriteBack(sim *simulation.Simulation) {
+	world := engine.World
+	sim.Round = engine.Round
+
+	sim.Cities = make([]*simulation.City, 0, engine.standingCount)
+	sim.World = make(map[string][]*simulation.City, engine.standingCount)
+	for _, city := range world.Cities {
+		if !engine.standing[city] {
+			continue
+		}
+		object := engine.object(city)
+		sim.Cities = append(sim.Cities, object)
+		links := make([]*simulation.City, 0, engine.degree[city])
+		for idx := world.Start[city]; idx < world.Start[city+1]; idx++ {
+			if engine.links[idx] == tombstone {
+				continue
+			}
+			if int(idx) < len(engine.linkObjects) {
+				links = append(links, engine.linkObjects[idx])
+				continue
+			}
+			links = append(links, simulation.NewCityWithDirections(world.Names[engine.links[idx]], world.DirectionNames[world.Directions[idx]]))
+		}
+		sim.World[object.Name] = links
+	}
+
+	sim.Aliens = make([]*simulation.Alien, 0, len(engine.order))
+	sim.AlienCityMapping = make(map[int]string, len(engine.order))
+	sim.CityAlienMapping = make(map[string][]int)
+	for city, mapped := range engine.mapped {
+		if mapped {
+			sim.CityAlienMapping[world.Names[city]] = make([]int, 0)
+		}
+	}
+	for _, alien := range engine.order {
+		sim.Aliens = append(sim.Aliens, engine.aliens[alien])
+		if city := engine.alienCity[alien]; city != tombstone {
+			sim.AlienCityMapping[engine.aliens[alien].ID] = world.Names[city]
+		}
+	}
+	// aliens are listed in a city in the order they entered it
+	arrived := append([]int32(nil), engine.order...)
+	sort.Slice(arrived, func(i, j int) bool { return engine.arrival[arrived[i]] < engine.arrival[arrived[j]] })
+	for _, alien := range arrived {
+		if city := engine.alienCity[alien]; city != tombstone {
+			name := world.Names[city]
+			sim.CityAlienMapping[name] = append(sim.CityAlienMapping[name], engine.aliens[alien].ID)
+		}
+	}
+}
+
+/*
+	object returns the city object of the simulation the engine was built from, or a new one.
+*/
+func (engine *Engine) object(city int32) *simulation.City {
+	if int(city) < len(engine.cityObjects) && engine.cityObjects[city] != nil {
+		return engine.cityObjects[city]
+	}
+	return simulation.NewCity(engine.World.Names[city])
+}
+
+/*
+	Run continues the attack of a simulation with the engine until it is over, and stores the final state in the simulation.
+*/
+func Run(sim *simulation.Simulation) error {
+	engine, err := New(sim)
+	if err != nil {
+		return err
+	}
+	engine.Run()
+	engine.WriteBack(sim)
+	return nil
+}
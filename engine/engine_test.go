@@ -0,0 +1,177 @@
+package engine
+
+import (
+	"io"
+	"math/rand"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/rvsingh011/alien-invasion/generator"
+	"github.com/rvsingh011/alien-invasion/internal/worldtest"
+	"github.com/rvsingh011/alien-invasion/simulation"
+	"github.com/rvsingh011/alien-invasion/utils"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func newTestSimulation(t testing.TB, worldMap string, aliens, iterations int, seed int64) *simulation.Simulation {
+	sim, err := simulation.NewSimulation(iterations, aliens, "", "", nil, zap.NewNop())
+	assert.NoError(t, err)
+	sim.Output = io.Discard
+	sim.SetSeed(seed)
+	assert.NoError(t, sim.LoadWorld(strings.NewReader(worldMap)))
+	assert.NoError(t, sim.CreateAliens())
+	return sim
+}
+
+func worldFile(t *testing.T, path string) string {
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	return string(data)
+}
+
+func assertSameState(t *testing.T, want, got *simulation.Simulation) {
+	assert.Equal(t, want.Round, got.Round)
+	assert.Equal(t, want.Cities, got.Cities)
+	assert.Equal(t, want.World, got.World)
+	assert.Equal(t, want.Aliens, got.Aliens)
+	assert.Equal(t, want.AlienCityMapping, got.AlienCityMapping)
+	assert.Equal(t, want.CityAlienMapping, got.CityAlienMapping)
+	wantSeed, wantDraws := want.RandSource.State()
+	gotSeed, gotDraws := got.RandSource.State()
+	assert.Equal(t, wantSeed, gotSeed)
+	assert.Equal(t, wantDraws, gotDraws)
+}
+
+func TestRun_sameAsSimulation(t *testing.T) {
+	tests := []struct {
+		name       string
+		worldMap   string
+		aliens     int
+		iterations int
+	}{
+		{name: "World example 1", worldMap: worldFile(t, "../data/world-example-1.txt"), aliens: 4, iterations: 10000},
+		{name: "World example 3", worldMap: worldFile(t, "../data/world-example-3.txt"), aliens: 8, iterations: 10000},
+		{name: "World example 4", worldMap: worldFile(t, "../data/world-example-4.txt"), aliens: 5, iterations: 10000},
+		// Bar lists Foo but Foo does not list Bar back, the road to a destroyed city is left dangling
+		{name: "One way roads", worldMap: "Bar north=Lee\nFoo east=Lee\nLee west=Foo south=Bar east=Bar\nBar west=Foo\nFoo south=Foo", aliens: 6, iterations: 500},
+		{name: "Grid", worldMap: worldtest.Generated(t, generator.Options{Topology: generator.TopologyGrid, Width: 20, Height: 20, Density: 0.8, Seed: 1}), aliens: 60, iterations: 10000},
+		{name: "Islands", worldMap: worldtest.Generated(t, generator.Options{Topology: generator.TopologyIslands, Width: 4, Height: 4, Count: 6, Density: 0.2, Seed: 2}), aliens: 30, iterations: 10000},
+		{name: "Hubs", worldMap: worldtest.Generated(t, generator.Options{Topology: generator.TopologyHubs, Width: 3, Height: 3, Count: 5, Spoke: 4, Density: 1, Seed: 3}), aliens: 40, iterations: 10000},
+		{name: "Few iterations", worldMap: worldtest.Generated(t, generator.Options{Topology: generator.TopologyHoles, Width: 15, Height: 15, Holes: 0.3, Density: 0.9, Seed: 4}), aliens: 80, iterations: 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for seed := int64(1); seed <= 20; seed++ {
+				want := newTestSimulation(t, tt.worldMap, tt.aliens, tt.iterations, seed)
+				fights := make([]string, 0)
+				want.OnEvent(func(event simulation.Event) {
+					if event.Kind == simulation.EventFight {
+						fights = append(fights, event.String())
+					}
+				})
+				assert.NoError(t, want.Start())
+
+				got := newTestSimulation(t, tt.worldMap, tt.aliens, tt.iterations, seed)
+				engine, err := New(got)
+				assert.NoError(t, err)
+				gotFights := make([]string, 0)
+				engine.OnFight = func(round int, city string, aliens []*simulation.Alien) {
+					event := simulation.Event{Kind: simulation.EventFight, City: city}
+					for _, alien := range aliens {
+						event.Aliens = append(event.Aliens, alien.String())
+					}
+					gotFights = append(gotFights, event.String())
+				}
+				engine.Run()
+				engine.WriteBack(got)
+
+				assertSameState(t, want, got)
+				assert.Equal(t, fights, gotFights)
+				assert.Equal(t, len(want.World), engine.Standing())
+				assert.Equal(t, len(want.Aliens), engine.Alive())
+			}
+		})
+	}
+}
+
+func TestRun_continuesSimulation(t *testing.T) {
+	worldMap := worldtest.Generated(t, generator.Options{Topology: generator.TopologyTree, Width: 12, Height: 12, Density: 0.3, Seed: 5})
+	for _, rounds := range []int{0, 1, 2, 5, 20} {
+		want := newTestSimulation(t, worldMap, 30, 1000, 9)
+		assert.NoError(t, want.Start())
+
+		got := newTestSimulation(t, worldMap, 30, 1000, 9)
+		for round := 0; round < rounds && !got.Ended(); round++ {
+			got.Step()
+		}
+		assert.NoError(t, Run(got))
+		assertSameState(t, want, got)
+	}
+}
+
+func TestNew_errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(sim *simulation.Simulation)
+	}{
+		{
+			name: "Scheduled events",
+			setup: func(sim *simulation.Simulation) {
+				assert.NoError(t, sim.Schedule(simulation.ScheduledEvent{Round: 2, Action: simulation.ActionDestroyCity, City: "Foo"}))
+			},
+		},
+		{
+			name:  "No attack vector",
+			setup: func(sim *simulation.Simulation) { sim.RandSeed = nil },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sim := newTestSimulation(t, "Foo north=Bar", 2, 10, 1)
+			tt.setup(sim)
+			_, err := New(sim)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestNewAttack_sameAsNew(t *testing.T) {
+	worldMap := worldtest.Generated(t, generator.Options{Topology: generator.TopologyGrid, Width: 10, Height: 10, Density: 0.8, Seed: 1})
+	for seed := int64(1); seed <= 10; seed++ {
+		sim := newTestSimulation(t, worldMap, 30, 1000, seed)
+		world, err := FromSimulation(sim)
+		assert.NoError(t, err)
+		attack := NewAttack(world, sim.Aliens, sim.Iterations, rand.New(utils.NewRandSource(seed)))
+		attack.Run()
+
+		assert.NoError(t, Run(sim))
+		assert.Equal(t, sim.Round, attack.Round)
+		assert.Equal(t, len(sim.Cities), attack.Standing())
+		assert.Equal(t, len(sim.Aliens), attack.Alive())
+		for _, city := range sim.Cities {
+			id, _ := world.Lookup(city.Name)
+			assert.True(t, attack.Stands(id), city.Name)
+		}
+	}
+}
+
+func TestFortify(t *testing.T) {
+	// both aliens arrive in Foo, the only city, and fight there
+	sim := newTestSimulation(t, "Foo", 2, 10, 2)
+	world, err := FromSimulation(sim)
+	assert.NoError(t, err)
+
+	attack := NewAttack(world, sim.Aliens, sim.Iterations, rand.New(utils.NewRandSource(2)))
+	assert.NoError(t, attack.Fortify("Foo"))
+	fights := 0
+	attack.OnFight = func(int, string, []*simulation.Alien) { fights++ }
+	attack.Run()
+	assert.Equal(t, 1, attack.Round)
+	assert.Equal(t, 0, attack.Alive())
+	assert.Equal(t, 1, attack.Standing())
+	assert.Equal(t, 0, fights)
+
+	assert.EqualError(t, attack.Fortify("Bar"), "There is no city Bar left to fortify")
+}
@@ -0,0 +1,149 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/rvsingh011/alien-invasion/simulation"
+)
+
+// tombstone marks a removed road in the adjacency array
+const tombstone = -1
+
+/*
+	World is a world map with the city names interned to ints.
+	The roads of city c are Links[Start[c]:Start[c+1]], in the order of the world file, with the direction of each road in Directions.
+	A destroyed road is not removed from the array but replaced by a tombstone, so the other roads keep their place.
+*/
+type World struct {
+	Names      []string
+	Start      []int32
+	Links      []int32
+	Directions []uint8
+
+	// DirectionNames holds the text of every direction code
+	DirectionNames []string
+
+	// Cities lists the cities standing when the world was built, in the order they were discovered
+	Cities []int32
+
+	index      map[string]int32
+	directions map[string]uint8
+}
+
+/*
+	NewWorld returns an empty world, cities and directions are interned with City and Direction before Build.
+*/
+func NewWorld() *World {
+	return &World{index: make(map[string]int32), directions: make(map[string]uint8)}
+}
+
+/*
+	City returns the ID of a city, interning its name on first use.
+*/
+func (world *World) City(name string) int32 {
+	id, ok := world.index[name]
+	if !ok {
+		id = int32(len(world.Names))
+		world.index[name] = id
+		world.Names = append(world.Names, name)
+	}
+	return id
+}
+
+/*
+	Lookup returns the ID of a city known to the world.
+*/
+func (world *World) Lookup(name string) (int32, bool) {
+	id, ok := world.index[name]
+	return id, ok
+}
+
+/*
+	Direction returns the code of a direction, interning it on first use.
+*/
+func (world *World) Direction(name string) (uint8, error) {
+	code, ok := world.directions[name]
+	if !ok {
+		if len(world.DirectionNames) > 255 {
+			return 0, fmt.Errorf("Too many different directions, at most 256 are supported")
+		}
+		code = uint8(len(world.DirectionNames))
+		world.directions[name] = code
+		world.DirectionNames = append(world.DirectionNames, name)
+	}
+	return code, nil
+}
+
+/*
+	Build packs the roads of every city into the flat adjacency arrays.
+	targets[c] and directions[c] hold the targets and direction codes of the roads of city c, in order.
+*/
+func (world *World) Build(targets [][]int32, directions [][]uint8) {
+	world.Start = make([]int32, len(world.Names)+1)
+	total := 0
+	for city := range world.Names {
+		world.Start[city] = int32(total)
+		if city < len(targets) {
+			total += len(targets[city])
+		}
+	}
+	world.Start[len(world.Names)] = int32(total)
+	world.Links = make([]int32, 0, total)
+	world.Directions = make([]uint8, 0, total)
+	for city := range world.Names {
+		if city < len(targets) {
+			world.Links = append(world.Links, targets[city]...)
+			world.Directions = append(world.Directions, directions[city]...)
+		}
+	}
+}
+
+/*
+	FromSimulation interns the world of a simulation.
+	Cities which are referenced by a road or an alien but are not standing anymore are interned as destroyed cities.
+*/
+func FromSimulation(sim *simulation.Simulation) (*World, error) {
+	world, _, err := fromSimulation(sim)
+	return world, err
+}
+
+/*
+	fromSimulation interns the world of a simulation and returns the road objects in the order of the adjacency array.
+*/
+func fromSimulation(sim *simulation.Simulation) (*World, []*simulation.City, error) {
+	world := NewWorld()
+	world.index = make(map[string]int32, len(sim.Cities))
+	roads := make([][]*simulation.City, len(sim.Cities))
+	total := 0
+	for idx, city := range sim.Cities {
+		world.Cities = append(world.Cities, world.City(city.Name))
+		roads[idx] = sim.World[city.Name]
+		total += len(roads[idx])
+	}
+
+	// the roads are packed in the order of the cities, every listed city was interned first
+	objects := make([]*simulation.City, 0, total)
+	world.Links = make([]int32, 0, total)
+	world.Directions = make([]uint8, 0, total)
+	world.Start = make([]int32, 0, len(sim.Cities)+1)
+	for idx := range sim.Cities {
+		world.Start = append(world.Start, int32(len(world.Links)))
+		for _, link := range roads[idx] {
+			code, err := world.Direction(link.Direction)
+			if err != nil {
+				return nil, nil, err
+			}
+			objects = append(objects, link)
+			world.Links = append(world.Links, world.City(link.Name))
+			world.Directions = append(world.Directions, code)
+		}
+	}
+	for _, city := range sim.AlienCityMapping {
+		world.City(city)
+	}
+	// destroyed cities have no road
+	for len(world.Start) <= len(world.Names) {
+		world.Start = append(world.Start, int32(len(world.Links)))
+	}
+	return world, objects, nil
+}
@@ -0,0 +1,379 @@
+package generator
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"strings"
+)
+
+const (
+	// TopologyGrid a full grid, every road kept with the road density
+	TopologyGrid = "grid"
+	// TopologyHoles a grid with random cities missing
+	TopologyHoles = "holes"
+	// TopologyTree a random spanning tree of a grid, with extra roads added with the road density
+	TopologyTree = "tree"
+	// TopologyIslands disconnected islands, each a random spanning tree of a small grid
+	TopologyIslands = "islands"
+	// TopologyChain a single long chain of cities winding through a grid
+	TopologyChain = "chain"
+	// TopologyHubs dense blocks of cities linked by chains
+	TopologyHubs = "hubs"
+)
+
+// Topologies lists the supported topologies
+var Topologies = []string{TopologyGrid, TopologyHoles, TopologyTree, TopologyIslands, TopologyChain, TopologyHubs}
+
+/*
+	Options describes the world to generate.
+*/
+type Options struct {
+	Topology string
+	// Width and Height of the grid, of every island for islands and of every block for hubs
+	Width  int
+	Height int
+	// Density is the probability of keeping a road which is not required to connect the cities
+	Density float64
+	// Holes is the probability of a city of the grid to be missing, for holes
+	Holes float64
+	// Count is the number of islands or of hubs
+	Count int
+	// Spoke is the number of cities of the chains linking the hubs
+	Spoke int
+	Seed  int64
+}
+
+/*
+	Validate checks the options describe a world which can be generated.
+*/
+func (options Options) Validate() error {
+	known := false
+	for _, topology := range Topologies {
+		known = known || topology == options.Topology
+	}
+	if !known {
+		return fmt.Errorf("Unknown topology %q, supported topologies: %s", options.Topology, strings.Join(Topologies, ", "))
+	}
+	if options.Width < 1 || options.Height < 1 {
+		return fmt.Errorf("The size of the grid must be at least 1x1, got %dx%d", options.Width, options.Height)
+	}
+	if options.Density < 0 || options.Density > 1 {
+		return fmt.Errorf("The road density must be between 0 and 1, got %g", options.Density)
+	}
+	if options.Holes < 0 || options.Holes >= 1 {
+		return fmt.Errorf("The probability of a hole must be at least 0 and below 1, got %g", options.Holes)
+	}
+	if (options.Topology == TopologyIslands || options.Topology == TopologyHubs) && options.Count < 1 {
+		return fmt.Errorf("At least one %s is needed, got %d", options.Topology, options.Count)
+	}
+	if options.Topology == TopologyHubs && options.Spoke < 0 {
+		return fmt.Errorf("The chains linking the hubs cannot be negative, got %d", options.Spoke)
+	}
+	return nil
+}
+
+/*
+	point is a cell of the grid.
+*/
+type point struct {
+	x, y int
+}
+
+/*
+	road links a cell to its neighbour to the east or to the south, so every road has a single key.
+*/
+type road struct {
+	from  point
+	south bool
+}
+
+func (r road) to() point {
+	if r.south {
+		return point{r.from.x, r.from.y + 1}
+	}
+	return point{r.from.x + 1, r.from.y}
+}
+
+/*
+	World is a generated world, every city sits on a cell of a grid and roads only link neighbour cells,
+	so the directions of the roads are always consistent.
+*/
+type World struct {
+	width, height int
+	cities        map[point]bool
+	roads         map[road]bool
+}
+
+func newWorld() *World {
+	return &World{cities: make(map[point]bool), roads: make(map[road]bool)}
+}
+
+/*
+	addCity adds a city on a cell, growing the grid if needed.
+*/
+func (world *World) addCity(at point) {
+	world.cities[at] = true
+	if at.x+1 > world.width {
+		world.width = at.x + 1
+	}
+	if at.y+1 > world.height {
+		world.height = at.y + 1
+	}
+}
+
+/*
+	addRoad links two neighbour cells.
+*/
+func (world *World) addRoad(a, b point) {
+	switch {
+	case b.x == a.x+1 && b.y == a.y:
+		world.roads[road{from: a}] = true
+	case a.x == b.x+1 && a.y == b.y:
+		world.roads[road{from: b}] = true
+	case b.y == a.y+1 && b.x == a.x:
+		world.roads[road{from: a, south: true}] = true
+	case a.y == b.y+1 && a.x == b.x:
+		world.roads[road{from: b, south: true}] = true
+	}
+}
+
+/*
+	Cities returns the number of cities of the world.
+*/
+func (world *World) Cities() int {
+	return len(world.cities)
+}
+
+/*
+	Roads returns the number of roads of the world, a road is counted once for both directions.
+*/
+func (world *World) Roads() int {
+	return len(world.roads)
+}
+
+/*
+	Generate builds a random world, the same options always build the same world.
+*/
+func Generate(options Options) (*World, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+	random := rand.New(rand.NewSource(options.Seed))
+	world := newWorld()
+	width, height := options.Width, options.Height
+
+	switch options.Topology {
+	case TopologyGrid:
+		block(world, random, point{}, width, height, nil, options.Density)
+	case TopologyHoles:
+		keep := func(point) bool { return random.Float64() >= options.Holes }
+		block(world, random, point{}, width, height, keep, options.Density)
+	case TopologyTree:
+		tree(world, random, point{}, width, height, options.Density)
+	case TopologyIslands:
+		// islands are laid out in rows, a line of empty cells between two islands
+		columns := int(math.Ceil(math.Sqrt(float64(options.Count))))
+		for island := 0; island < options.Count; island++ {
+			origin := point{(island % columns) * (width + 1), (island / columns) * (height + 1)}
+			tree(world, random, origin, width, height, options.Density)
+		}
+	case TopologyChain:
+		chain(world, width, height)
+	case TopologyHubs:
+		hubs(world, random, options)
+	}
+	return world, nil
+}
+
+/*
+	block adds the cells of a rectangle kept by keep, all of them if keep is nil, and the roads between them kept with the density.
+*/
+func block(world *World, random *rand.Rand, origin point, width, height int, keep func(point) bool, density float64) {
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			at := point{origin.x + x, origin.y + y}
+			if keep == nil || keep(at) {
+				world.addCity(at)
+			}
+		}
+	}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			at := point{origin.x + x, origin.y + y}
+			if !world.cities[at] {
+				continue
+			}
+			for _, next := range []point{{at.x + 1, at.y}, {at.x, at.y + 1}} {
+				if next.x < origin.x+width && next.y < origin.y+height && world.cities[next] && random.Float64() < density {
+					world.addRoad(at, next)
+				}
+			}
+		}
+	}
+}
+
+/*
+	tree adds a rectangle of cells linked by a random spanning tree, the other roads of the rectangle are kept with the density.
+	The tree is built with Kruskal's algorithm over the roads of the rectangle in random order.
+*/
+func tree(world *World, random *rand.Rand, origin point, width, height int, density float64) {
+	parent := make(map[point]point, width*height)
+	var find func(at point) point
+	find = func(at point) point {
+		if parent[at] != at {
+			parent[at] = find(parent[at])
+		}
+		return parent[at]
+	}
+
+	candidates := make([][2]point, 0, 2*width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			at := point{origin.x + x, origin.y + y}
+			world.addCity(at)
+			parent[at] = at
+			if x+1 < width {
+				candidates = append(candidates, [2]point{at, {at.x + 1, at.y}})
+			}
+			if y+1 < height {
+				candidates = append(candidates, [2]point{at, {at.x, at.y + 1}})
+			}
+		}
+	}
+	random.Shuffle(len(candidates), func(i, j int) { candidates[i], candidates[j] = candidates[j], candidates[i] })
+	for _, candidate := range candidates {
+		a, b := find(candidate[0]), find(candidate[1])
+		if a != b {
+			parent[a] = b
+			world.addRoad(candidate[0], candidate[1])
+		} else if random.Float64() < density {
+			world.addRoad(candidate[0], candidate[1])
+		}
+	}
+}
+
+/*
+	chain adds a single chain of width x height cities winding through the grid row by row.
+*/
+func chain(world *World, width, height int) {
+	var previous *point
+	for y := 0; y < height; y++ {
+		for step := 0; step < width; step++ {
+			x := step
+			if y%2 == 1 {
+				x = width - 1 - step
+			}
+			at := point{x, y}
+			world.addCity(at)
+			if previous != nil {
+				world.addRoad(*previous, at)
+			}
+			previous = &at
+		}
+	}
+}
+
+/*
+	hubs adds blocks of cities laid out in rows, neighbour blocks are linked by chains through the middle of their sides.
+	The roads inside a block are kept with the density, the blocks are dense with the default density of 1.
+*/
+func hubs(world *World, random *rand.Rand, options Options) {
+	width, height, spoke := options.Width, options.Height, options.Spoke
+	columns := int(math.Ceil(math.Sqrt(float64(options.Count))))
+	stepX, stepY := width+spoke, height+spoke
+	origin := func(hub int) point {
+		return point{(hub % columns) * stepX, (hub / columns) * stepY}
+	}
+	for hub := 0; hub < options.Count; hub++ {
+		block(world, random, origin(hub), width, height, nil, options.Density)
+	}
+	for hub := 0; hub < options.Count; hub++ {
+		from := origin(hub)
+		// chain to the hub to the east
+		if hub%columns+1 < columns && hub+1 < options.Count {
+			start := point{from.x + width - 1, from.y + height/2}
+			link(world, start, point{1, 0}, spoke)
+		}
+		// chain to the hub to the south
+		if hub+columns < options.Count {
+			start := point{from.x + width/2, from.y + height - 1}
+			link(world, start, point{0, 1}, spoke)
+		}
+	}
+}
+
+/*
+	link adds a straight chain of spoke cities from a city to the city spoke + 1 cells away.
+*/
+func link(world *World, from, step point, spoke int) {
+	previous := from
+	for idx := 0; idx <= spoke; idx++ {
+		at := point{previous.x + step.x, previous.y + step.y}
+		world.addCity(at)
+		world.addRoad(previous, at)
+		previous = at
+	}
+}
+
+/*
+	name returns the name of the city on a cell.
+*/
+func name(at point) string {
+	return fmt.Sprintf("c%d-%d", at.x, at.y)
+}
+
+/*
+	WriteTo writes the world in the world file format, one line per city in row order, every road listed from both of its cities.
+	The lines are written one after the other through a buffer, the world is never held in memory as text.
+*/
+func (world *World) WriteTo(w io.Writer) (int64, error) {
+	buffered := bufio.NewWriter(w)
+	var written int64
+	var line strings.Builder
+	for y := 0; y < world.height; y++ {
+		for x := 0; x < world.width; x++ {
+			at := point{x, y}
+			if !world.cities[at] {
+				continue
+			}
+			line.Reset()
+			line.WriteString(name(at))
+			if world.roads[road{from: point{x, y - 1}, south: true}] {
+				line.WriteString(" north=" + name(point{x, y - 1}))
+			}
+			if world.roads[road{from: at}] {
+				line.WriteString(" east=" + name(point{x + 1, y}))
+			}
+			if world.roads[road{from: at, south: true}] {
+				line.WriteString(" south=" + name(point{x, y + 1}))
+			}
+			if world.roads[road{from: point{x - 1, y}}] {
+				line.WriteString(" west=" + name(point{x - 1, y}))
+			}
+			line.WriteString("\n")
+			count, err := buffered.WriteString(line.String())
+			written += int64(count)
+			if err != nil {
+				// what is still buffered never reached w
+				return written - int64(buffered.Buffered()), err
+			}
+		}
+	}
+	if err := buffered.Flush(); err != nil {
+		return written - int64(buffered.Buffered()), err
+	}
+	return written, nil
+}
+
+/*
+	String returns the world in the world file format, as written by WriteTo.
+*/
+func (world *World) String() string {
+	var text strings.Builder
+	// writing to a strings.Builder never fails
+	_, _ = world.WriteTo(&text)
+	return text.String()
+}
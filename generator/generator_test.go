@@ -0,0 +1,162 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/rvsingh011/alien-invasion/simulation"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func load(t *testing.T, world *World) *simulation.Simulation {
+	var text bytes.Buffer
+	_, err := world.WriteTo(&text)
+	assert.NoError(t, err)
+	sim, err := simulation.NewSimulation(0, 0, "", "", nil, zap.NewNop())
+	assert.NoError(t, err)
+	assert.NoError(t, sim.LoadWorld(&text))
+	return sim
+}
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		name           string
+		options        Options
+		wantCities     int
+		wantRoads      int
+		wantComponents int
+	}{
+		{
+			name:           "Full grid",
+			options:        Options{Topology: TopologyGrid, Width: 4, Height: 3, Density: 1},
+			wantCities:     12,
+			wantRoads:      17,
+			wantComponents: 1,
+		},
+		{
+			name:           "Grid without roads",
+			options:        Options{Topology: TopologyGrid, Width: 3, Height: 3, Density: 0},
+			wantCities:     9,
+			wantRoads:      0,
+			wantComponents: 9,
+		},
+		{
+			name:           "Spanning tree",
+			options:        Options{Topology: TopologyTree, Width: 6, Height: 5, Density: 0, Seed: 7},
+			wantCities:     30,
+			wantRoads:      29,
+			wantComponents: 1,
+		},
+		{
+			name:           "Islands",
+			options:        Options{Topology: TopologyIslands, Width: 3, Height: 2, Density: 0, Count: 5, Seed: 7},
+			wantCities:     30,
+			wantRoads:      25,
+			wantComponents: 5,
+		},
+		{
+			name:           "Chain",
+			options:        Options{Topology: TopologyChain, Width: 5, Height: 4},
+			wantCities:     20,
+			wantRoads:      19,
+			wantComponents: 1,
+		},
+		{
+			name:           "Hubs",
+			options:        Options{Topology: TopologyHubs, Width: 2, Height: 2, Density: 1, Count: 3, Spoke: 2},
+			wantCities:     3*4 + 2*2,
+			wantRoads:      3*4 + 2*3,
+			wantComponents: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			world, err := Generate(tt.options)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantCities, world.Cities())
+			assert.Equal(t, tt.wantRoads, world.Roads())
+
+			sim := load(t, world)
+			assert.Len(t, sim.Cities, tt.wantCities)
+			layout := sim.InferLayout()
+			assert.Empty(t, layout.Conflicts)
+			assert.Len(t, layout.Components, tt.wantComponents)
+		})
+	}
+}
+
+func TestGenerate_holes(t *testing.T) {
+	world, err := Generate(Options{Topology: TopologyHoles, Width: 20, Height: 20, Density: 0.7, Holes: 0.3, Seed: 11})
+	assert.NoError(t, err)
+	assert.Less(t, world.Cities(), 400)
+	assert.Greater(t, world.Cities(), 0)
+	assert.Empty(t, load(t, world).InferLayout().Conflicts)
+}
+
+func TestGenerate_seed(t *testing.T) {
+	options := Options{Topology: TopologyTree, Width: 15, Height: 15, Density: 0.2, Seed: 3}
+	write := func(options Options) string {
+		world, err := Generate(options)
+		assert.NoError(t, err)
+		return world.String()
+	}
+	first := write(options)
+	assert.Equal(t, first, write(options))
+	options.Seed = 4
+	assert.NotEqual(t, first, write(options))
+}
+
+func TestOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		options Options
+		wantErr bool
+	}{
+		{name: "Valid", options: Options{Topology: TopologyGrid, Width: 2, Height: 2, Density: 0.5}},
+		{name: "Unknown topology", options: Options{Topology: "ring", Width: 2, Height: 2}, wantErr: true},
+		{name: "Empty grid", options: Options{Topology: TopologyGrid, Width: 0, Height: 2}, wantErr: true},
+		{name: "Density above 1", options: Options{Topology: TopologyGrid, Width: 2, Height: 2, Density: 1.5}, wantErr: true},
+		{name: "Only holes", options: Options{Topology: TopologyHoles, Width: 2, Height: 2, Holes: 1}, wantErr: true},
+		{name: "No island", options: Options{Topology: TopologyIslands, Width: 2, Height: 2}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.options.Validate()
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+// failingWriter accepts left bytes then fails
+type failingWriter struct {
+	left int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.left {
+		written := w.left
+		w.left = 0
+		return written, fmt.Errorf("disk full")
+	}
+	w.left -= len(p)
+	return len(p), nil
+}
+
+func TestWorld_WriteTo(t *testing.T) {
+	world, err := Generate(Options{Topology: TopologyGrid, Width: 100, Height: 100, Density: 1, Seed: 1})
+	assert.NoError(t, err)
+
+	var text bytes.Buffer
+	written, err := world.WriteTo(&text)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(text.Len()), written)
+	assert.True(t, strings.HasPrefix(text.String(), "c0-0 east=c1-0 south=c0-1\nc1-0 east=c2-0 south=c1-1 west=c0-0\n"))
+	assert.True(t, strings.HasSuffix(text.String(), "\nc99-99 north=c99-98 west=c98-99\n"))
+
+	written, err = world.WriteTo(&failingWriter{left: 5000})
+	assert.EqualError(t, err, "disk full")
+	assert.Equal(t, int64(5000), written)
+}
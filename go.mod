@@ -2,13 +2,15 @@ module github.com/rvsingh011/alien-invasion
 
 go 1.17
 
-require go.uber.org/zap v1.21.0
+require (
+	github.com/stretchr/testify v1.7.1
+	go.uber.org/zap v1.21.0
+)
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/golang/mock v1.6.0 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	github.com/stretchr/testify v1.7.1 // indirect
 	go.uber.org/atomic v1.7.0 // indirect
 	go.uber.org/multierr v1.6.0 // indirect
 	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b // indirect
@@ -0,0 +1,69 @@
+package graphio
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/rvsingh011/alien-invasion/simulation"
+)
+
+/*
+	WriteDOT exports the world as a Graphviz digraph, one edge per road labelled with its direction.
+	Cities and roads of base missing from state are drawn greyed out and dashed, positions come from the inferred layout.
+*/
+func WriteDOT(w io.Writer, base, state *simulation.WorldSnapshot) error {
+	layout := simulation.InferLayout(base.World, base.Cities)
+	aliens := state.AliensIn()
+
+	var dot strings.Builder
+	dot.WriteString("digraph world {\n")
+	dot.WriteString("\tnode [shape=circle, style=filled, fillcolor=steelblue, fontcolor=white];\n")
+	for _, city := range base.Cities {
+		position := layout.Positions[city.Name]
+		attributes := []string{
+			fmt.Sprintf("label=%s", strconv.Quote(city.Name)),
+			// graphviz y axis grows to the north
+			fmt.Sprintf(`pos="%d,%d!"`, position.X, -position.Y),
+		}
+		if _, alive := state.World[city.Name]; !alive {
+			attributes = append(attributes, "fillcolor=lightgrey", "fontcolor=grey", `xlabel="destroyed"`)
+		} else if inCity := aliens[city.Name]; len(inCity) > 0 {
+			attributes = append(attributes, "color=crimson", "penwidth=3", fmt.Sprintf("xlabel=%s", strconv.Quote(strings.Join(inCity, ", "))))
+		}
+		dot.WriteString(fmt.Sprintf("\t%s [%s];\n", strconv.Quote(city.Name), strings.Join(attributes, ", ")))
+	}
+	for _, road := range roads(base) {
+		attributes := []string{fmt.Sprintf("label=%s", strconv.Quote(road.direction))}
+		if !state.HasRoad(road.from, road.to) {
+			attributes = append(attributes, "style=dashed", "color=grey", "fontcolor=grey")
+		}
+		dot.WriteString(fmt.Sprintf("\t%s -> %s [%s];\n", strconv.Quote(road.from), strconv.Quote(road.to), strings.Join(attributes, ", ")))
+	}
+	dot.WriteString("}\n")
+	_, err := io.WriteString(w, dot.String())
+	return err
+}
+
+/*
+	road is a one way road of the world, the city to is in direction of the city from.
+*/
+type road struct {
+	from      string
+	to        string
+	direction string
+}
+
+/*
+	roads lists the roads of the snapshot in the order the cities were discovered.
+*/
+func roads(snapshot *simulation.WorldSnapshot) []road {
+	all := make([]road, 0)
+	for _, city := range snapshot.Cities {
+		for _, link := range snapshot.World[city.Name] {
+			all = append(all, road{from: city.Name, to: link.Name, direction: link.Direction})
+		}
+	}
+	return all
+}
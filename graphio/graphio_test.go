@@ -0,0 +1,129 @@
+package graphio
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/rvsingh011/alien-invasion/simulation"
+	"github.com/stretchr/testify/assert"
+)
+
+const testWorld = "Foo north=Bar west=Baz south=Qu-ux\nBar south=Foo west=Bee north=Lee\nBaz east=Foo\nQu-ux north=Foo\nBee east=Bar\nLee south=Bar\n"
+
+func newTestSimulation(t *testing.T) *simulation.Simulation {
+	sim, err := simulation.NewSimulation(10, 0, "", "", nil, nil)
+	assert.NoError(t, err)
+	sim.Output = io.Discard
+	assert.NoError(t, sim.LoadWorld(strings.NewReader(testWorld)))
+	return sim
+}
+
+func TestWriteDOT(t *testing.T) {
+	sim := newTestSimulation(t)
+	before := sim.SnapshotWorld()
+	sim.Round = 1
+	assert.NoError(t, sim.DestroyCity("Bar"))
+	after := sim.SnapshotWorld()
+
+	var dot bytes.Buffer
+	assert.NoError(t, WriteDOT(&dot, before, after))
+	assert.True(t, strings.HasPrefix(dot.String(), "digraph world {"))
+	assert.Contains(t, dot.String(), `"Bar" [label="Bar", pos="1,-1!", fillcolor=lightgrey, fontcolor=grey, xlabel="destroyed"];`)
+	assert.Contains(t, dot.String(), `"Foo" -> "Bar" [label="north", style=dashed, color=grey, fontcolor=grey];`)
+	assert.Contains(t, dot.String(), `"Foo" -> "Baz" [label="west"];`)
+}
+
+func TestGraphMLRoundTrip(t *testing.T) {
+	tests := []struct {
+		name      string
+		destroy   string
+		wantWorld string
+	}{
+		{
+			name:      "Whole world",
+			wantWorld: testWorld,
+		},
+		{
+			name:      "Destroyed cities and roads are left out",
+			destroy:   "Bar",
+			wantWorld: "Foo west=Baz south=Qu-ux\nBaz east=Foo\nQu-ux north=Foo\nBee\nLee\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sim := newTestSimulation(t)
+			before := sim.SnapshotWorld()
+			if tt.destroy != "" {
+				assert.NoError(t, sim.DestroyCity(tt.destroy))
+			}
+			var graph bytes.Buffer
+			assert.NoError(t, WriteGraphML(&graph, before, sim.SnapshotWorld()))
+
+			world, err := ReadGraphML(&graph)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantWorld, world)
+		})
+	}
+}
+
+func TestReadGraphML(t *testing.T) {
+	tests := []struct {
+		name      string
+		graph     string
+		wantWorld string
+		wantErr   bool
+	}{
+		{
+			name: "Undirected graph with labels and directions",
+			graph: `<graphml><key id="d0" for="node" attr.name="label"/><key id="d1" for="edge" attr.name="direction"/>
+				<graph edgedefault="undirected">
+				<node id="n0"><data key="d0">Foo</data></node><node id="n1"><data key="d0">Bar</data></node>
+				<edge source="n0" target="n1"><data key="d1">north</data></edge>
+				</graph></graphml>`,
+			wantWorld: "Foo north=Bar\nBar south=Foo\n",
+		},
+		{
+			name: "Directions inferred from positions",
+			graph: `<graphml><key id="x" for="node" attr.name="x"/><key id="y" for="node" attr.name="y"/>
+				<graph edgedefault="directed">
+				<node id="Foo"><data key="x">10</data><data key="y">10</data></node>
+				<node id="Bar"><data key="x">90</data><data key="y">15</data></node>
+				<node id="Baz"><data key="x">12</data><data key="y">-70</data></node>
+				<edge source="Foo" target="Bar"/><edge source="Foo" target="Baz"/>
+				</graph></graphml>`,
+			wantWorld: "Foo east=Bar north=Baz\nBar\nBaz\n",
+		},
+		{
+			name: "Edge without direction nor positions",
+			graph: `<graphml><graph edgedefault="directed">
+				<node id="Foo"/><node id="Bar"/><edge source="Foo" target="Bar"/>
+				</graph></graphml>`,
+			wantErr: true,
+		},
+		{
+			name:    "Not GraphML",
+			graph:   `digraph world {}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			world, err := ReadGraphML(strings.NewReader(tt.graph))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ReadGraphML() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			assert.Equal(t, tt.wantWorld, world)
+		})
+	}
+}
+
+func TestReadGraphML_sameName(t *testing.T) {
+	_, err := ReadGraphML(strings.NewReader(`<graphml><key id="d0" for="node" attr.name="name"/>
+		<graph edgedefault="undirected">
+		<node id="n0"><data key="d0">Foo</data></node><node id="n1"><data key="d0">Bar</data></node>
+		<node id="n2"><data key="d0">Foo</data></node>
+		</graph></graphml>`))
+	assert.EqualError(t, err, `The city name "Foo" is given to the nodes n0 and n2`)
+}
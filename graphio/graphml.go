@@ -0,0 +1,282 @@
+package graphio
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/rvsingh011/alien-invasion/simulation"
+	"github.com/rvsingh011/alien-invasion/utils"
+)
+
+// keys of the data attached to the nodes and edges of an exported world
+const (
+	keyName      = "name"
+	keyX         = "x"
+	keyY         = "y"
+	keyDestroyed = "destroyed"
+	keyAliens    = "aliens"
+	keyDirection = "direction"
+)
+
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	Xmlns   string       `xml:"xmlns,attr,omitempty"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr,omitempty"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	ID     string        `xml:"id,attr,omitempty"`
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+/*
+	WriteGraphML exports the world as a directed GraphML graph, one edge per road with its direction.
+	Nodes carry their name, grid position, aliens and destruction state, edges their direction and destruction state.
+*/
+func WriteGraphML(w io.Writer, base, state *simulation.WorldSnapshot) error {
+	layout := simulation.InferLayout(base.World, base.Cities)
+	aliens := state.AliensIn()
+
+	document := graphML{
+		Xmlns: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: keyName, For: "node", AttrName: keyName, AttrType: "string"},
+			{ID: keyX, For: "node", AttrName: keyX, AttrType: "int"},
+			{ID: keyY, For: "node", AttrName: keyY, AttrType: "int"},
+			{ID: keyAliens, For: "node", AttrName: keyAliens, AttrType: "string"},
+			{ID: keyDestroyed, For: "all", AttrName: keyDestroyed, AttrType: "boolean"},
+			{ID: keyDirection, For: "edge", AttrName: keyDirection, AttrType: "string"},
+		},
+		Graph: graphMLGraph{ID: "world", EdgeDefault: "directed"},
+	}
+	for _, city := range base.Cities {
+		_, alive := state.World[city.Name]
+		position := layout.Positions[city.Name]
+		document.Graph.Nodes = append(document.Graph.Nodes, graphMLNode{
+			ID: city.Name,
+			Data: []graphMLData{
+				{Key: keyName, Value: city.Name},
+				{Key: keyX, Value: strconv.Itoa(position.X)},
+				{Key: keyY, Value: strconv.Itoa(position.Y)},
+				{Key: keyAliens, Value: strings.Join(aliens[city.Name], ",")},
+				{Key: keyDestroyed, Value: strconv.FormatBool(!alive)},
+			},
+		})
+	}
+	for idx, road := range roads(base) {
+		document.Graph.Edges = append(document.Graph.Edges, graphMLEdge{
+			ID:     fmt.Sprintf("e%d", idx),
+			Source: road.from,
+			Target: road.to,
+			Data: []graphMLData{
+				{Key: keyDirection, Value: road.direction},
+				{Key: keyDestroyed, Value: strconv.FormatBool(!state.HasRoad(road.from, road.to))},
+			},
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(document); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+/*
+	ReadGraphML converts a GraphML graph into a world in the world file format.
+	Nodes are named by their "name" or "label" data, their id otherwise, two nodes cannot have the same name. Edges of undirected graphs are roads in
+	both directions. Edges need a "direction" data,
+	when it is missing the direction is inferred from the "x" and "y" data of the nodes (y grows to the south).
+	Destroyed nodes and edges are left out.
+*/
+func ReadGraphML(r io.Reader) (string, error) {
+	var document graphML
+	if err := xml.NewDecoder(r).Decode(&document); err != nil {
+		return "", fmt.Errorf("Error Parsing the GraphML, Error: %s", err.Error())
+	}
+	// data keys are referenced by id, the attribute names tell what they hold
+	names := make(map[string]string)
+	for _, key := range document.Keys {
+		names[key.ID] = strings.ToLower(key.AttrName)
+		if names[key.ID] == "" {
+			names[key.ID] = strings.ToLower(key.ID)
+		}
+	}
+	data := func(values []graphMLData) map[string]string {
+		byName := make(map[string]string)
+		for _, value := range values {
+			name, ok := names[value.Key]
+			if !ok {
+				name = strings.ToLower(value.Key)
+			}
+			byName[name] = strings.TrimSpace(value.Value)
+		}
+		return byName
+	}
+
+	type node struct {
+		name   string
+		x, y   float64
+		placed bool
+	}
+	nodes := make(map[string]*node)
+	order := make([]string, 0, len(document.Graph.Nodes))
+	// the node named after each city, two nodes with the same name would be merged into one city
+	named := make(map[string]string)
+	for _, graphNode := range document.Graph.Nodes {
+		values := data(graphNode.Data)
+		if values[keyDestroyed] == "true" {
+			continue
+		}
+		city := &node{name: graphNode.ID}
+		if label := firstOf(values, keyName, "label"); label != "" {
+			city.name = label
+		}
+		if strings.ContainsAny(city.name, " =\t") {
+			return "", fmt.Errorf("The city name %q cannot contain spaces or =", city.name)
+		}
+		if other, ok := named[city.name]; ok {
+			return "", fmt.Errorf("The city name %q is given to the nodes %s and %s", city.name, other, graphNode.ID)
+		}
+		named[city.name] = graphNode.ID
+		x, errX := strconv.ParseFloat(values[keyX], 64)
+		y, errY := strconv.ParseFloat(values[keyY], 64)
+		city.x, city.y, city.placed = x, y, errX == nil && errY == nil
+		nodes[graphNode.ID] = city
+		order = append(order, graphNode.ID)
+	}
+
+	links := make(map[string][]string)
+	for _, edge := range document.Graph.Edges {
+		values := data(edge.Data)
+		if values[keyDestroyed] == "true" {
+			continue
+		}
+		from, to := nodes[edge.Source], nodes[edge.Target]
+		if from == nil || to == nil {
+			continue
+		}
+		direction := strings.ToLower(values[keyDirection])
+		if direction == "" {
+			if !from.placed || !to.placed {
+				return "", fmt.Errorf("The road from %s to %s has no direction and the cities have no position", from.name, to.name)
+			}
+			direction = directionBetween(from.x, from.y, to.x, to.y)
+		}
+		switch direction {
+		case "north", "south", "east", "west":
+		default:
+			return "", fmt.Errorf("The road from %s to %s has the unknown direction %q", from.name, to.name, direction)
+		}
+		links[edge.Source] = append(links[edge.Source], fmt.Sprintf("%s=%s", direction, to.name))
+		if document.Graph.EdgeDefault == "undirected" {
+			links[edge.Target] = append(links[edge.Target], fmt.Sprintf("%s=%s", utils.GetOppositeDirection(direction), from.name))
+		}
+	}
+
+	var world strings.Builder
+	for _, id := range order {
+		world.WriteString(nodes[id].name)
+		for _, link := range links[id] {
+			world.WriteString(" " + link)
+		}
+		world.WriteString("\n")
+	}
+	return world.String(), nil
+}
+
+/*
+	LoadGraphML converts a GraphML file into a world in the world file format.
+*/
+func LoadGraphML(path string) (string, error) {
+	graphFile, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("Error Reading the GraphML file : %s, Error: %s", path, err.Error())
+	}
+	defer graphFile.Close()
+	return ReadGraphML(graphFile)
+}
+
+/*
+	directionBetween returns the compass direction of the second point seen from the first one, y grows to the south.
+*/
+func directionBetween(fromX, fromY, toX, toY float64) string {
+	dx, dy := toX-fromX, toY-fromY
+	if abs(dx) >= abs(dy) {
+		if dx >= 0 {
+			return "east"
+		}
+		return "west"
+	}
+	if dy >= 0 {
+		return "south"
+	}
+	return "north"
+}
+
+func firstOf(values map[string]string, keys ...string) string {
+	for _, key := range keys {
+		if value := values[key]; value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func abs(value float64) float64 {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
+
+/*
+	LoadWorld creates the world of the simulation from its world file, GraphML files are recognised by their .graphml extension.
+*/
+func LoadWorld(sim *simulation.Simulation) error {
+	if !strings.EqualFold(filepath.Ext(sim.WorldFile), ".graphml") {
+		return sim.CreateWorld()
+	}
+	world, err := LoadGraphML(sim.WorldFile)
+	if err != nil {
+		return err
+	}
+	return sim.LoadWorld(strings.NewReader(world))
+}
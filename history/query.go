@@ -0,0 +1,68 @@
+package history
+
+/*
+	Query selects runs of the store, unset fields are not checked.
+*/
+type Query struct {
+	// Destroyed is a city the run destroyed, before the round Before if it is set
+	Destroyed string
+	Before    int
+	// Survived is a city which survived the run
+	Survived string
+	Seed     *int64
+	World    string
+	Source   string
+	// MaxRounds keeps the runs which ended by this round
+	MaxRounds int
+}
+
+/*
+	Match reports whether the record of a run meets the query.
+*/
+func (query Query) Match(record *Record) bool {
+	if query.Destroyed != "" {
+		round, ok := record.Destroyed[query.Destroyed]
+		if !ok || (query.Before > 0 && round >= query.Before) {
+			return false
+		}
+	}
+	if query.Survived != "" {
+		survived := false
+		for _, city := range record.Survived {
+			survived = survived || city == query.Survived
+		}
+		if !survived {
+			return false
+		}
+	}
+	if query.Seed != nil && (!record.Seeded || record.Seed != *query.Seed) {
+		return false
+	}
+	if query.World != "" && record.WorldFile != query.World {
+		return false
+	}
+	if query.Source != "" && record.Source != query.Source {
+		return false
+	}
+	if query.MaxRounds > 0 && record.Rounds > query.MaxRounds {
+		return false
+	}
+	return true
+}
+
+/*
+	Find returns the runs of the store meeting the query, oldest first.
+*/
+func (store *Store) Find(query Query) ([]*Record, error) {
+	records, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+	found := make([]*Record, 0)
+	for _, record := range records {
+		if query.Match(record) {
+			found = append(found, record)
+		}
+	}
+	return found, nil
+}
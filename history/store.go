@@ -0,0 +1,355 @@
+package history
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rvsingh011/alien-invasion/simulation"
+)
+
+const (
+	// DefaultDir is the directory of the run store if not specified
+	DefaultDir = "./alien-invasion-history"
+
+	// name of the index file, one json line per run
+	indexFile = "index.jsonl"
+	// name of the file holding the record of a run, in the directory of the run
+	runFile = "run.json"
+	// name of the gzip compressed event log of a run, one json line per event
+	eventsFile = "events.jsonl.gz"
+)
+
+/*
+	Record holds the parameters and the outcome of a run.
+*/
+type Record struct {
+	ID         string                      `json:"id"`
+	Source     string                      `json:"source"`
+	Started    time.Time                   `json:"started"`
+	Duration   time.Duration               `json:"duration"`
+	Iterations int                         `json:"iterations"`
+	Aliens     int                         `json:"aliens"`
+	AlienNames string                      `json:"alienNames,omitempty"`
+	WorldFile  string                      `json:"worldFile,omitempty"`
+	Seed       int64                       `json:"seed"`
+	Seeded     bool                        `json:"seeded"`
+	Events     []simulation.ScheduledEvent `json:"events,omitempty"`
+
+	// StartRound is the round the run started from, 0 unless it was resumed
+	StartRound int `json:"startRound"`
+	// Rounds is the last round which was run
+	Rounds int `json:"rounds"`
+	// Destroyed maps every destroyed city to the round it was destroyed in
+	Destroyed   map[string]int `json:"destroyed"`
+	Survived    []string       `json:"survived"`
+	AliensAlive int            `json:"aliensAlive"`
+	Status      string         `json:"status"`
+	// EndReason tells why the run ended, see Simulation.EndReason
+	EndReason string `json:"endReason,omitempty"`
+}
+
+/*
+	DestroyedCities returns the destroyed cities in the order they were destroyed.
+*/
+func (record *Record) DestroyedCities() []string {
+	cities := make([]string, 0, len(record.Destroyed))
+	for city := range record.Destroyed {
+		cities = append(cities, city)
+	}
+	sort.Slice(cities, func(i, j int) bool {
+		if record.Destroyed[cities[i]] != record.Destroyed[cities[j]] {
+			return record.Destroyed[cities[i]] < record.Destroyed[cities[j]]
+		}
+		return cities[i] < cities[j]
+	})
+	return cities
+}
+
+/*
+	Store keeps the runs in a directory, one sub directory per run and an append-only index of their records.
+*/
+type Store struct {
+	dir string
+	mu  sync.Mutex
+}
+
+/*
+	Open opens the run store in dir, creating it if needed.
+*/
+func Open(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("Error Creating the run store : %s, Error: %s", dir, err.Error())
+	}
+	return &Store{dir: dir}, nil
+}
+
+/*
+	Entry collects a run until it is saved to the store, the events are written to the event log of the run as they happen.
+*/
+type Entry struct {
+	store  *Store
+	record Record
+	cities map[string]bool
+	events *eventLog
+	// first error writing the event log, reported by Save
+	err error
+}
+
+/*
+	Track starts collecting the run of the simulation, Save must be called once the simulation ended.
+	The source tells where the run comes from, e.g. cli, scenario or server.
+*/
+func (store *Store) Track(sim *simulation.Simulation, source string) *Entry {
+	entry := &Entry{
+		store: store,
+		record: Record{
+			ID:         newID(),
+			Source:     source,
+			Started:    time.Now().UTC().Round(0),
+			Iterations: sim.Iterations,
+			Aliens:     len(sim.Aliens),
+			AlienNames: sim.AlienNames,
+			WorldFile:  sim.WorldFile,
+			Events:     append([]simulation.ScheduledEvent(nil), sim.ScheduledEvents...),
+			StartRound: sim.Round,
+			Destroyed:  make(map[string]int),
+		},
+		cities: make(map[string]bool, len(sim.World)),
+	}
+	if sim.RandSource != nil {
+		entry.record.Seed, _ = sim.RandSource.State()
+		entry.record.Seeded = true
+	}
+	for city := range sim.World {
+		entry.cities[city] = true
+	}
+	entry.events, entry.err = createEventLog(filepath.Join(store.dir, entry.record.ID))
+	sim.OnEvent(func(event simulation.Event) {
+		if entry.err == nil {
+			entry.err = entry.events.write(event)
+		}
+	})
+	sim.OnRound(func(sim *simulation.Simulation) {
+		for city := range entry.cities {
+			if _, ok := sim.World[city]; !ok {
+				entry.record.Destroyed[city] = sim.Round
+				delete(entry.cities, city)
+			}
+		}
+	})
+	return entry
+}
+
+/*
+	Save records the outcome of the simulation and writes the run to the store.
+*/
+func (entry *Entry) Save(sim *simulation.Simulation, status string) (*Record, error) {
+	record := &entry.record
+	record.Duration = time.Since(record.Started)
+	record.Rounds = sim.Round
+	record.AliensAlive = len(sim.Aliens)
+	record.Status = status
+	record.EndReason = sim.EndReason()
+	record.Survived = make([]string, 0, len(entry.cities))
+	for city := range entry.cities {
+		record.Survived = append(record.Survived, city)
+	}
+	sort.Strings(record.Survived)
+
+	if entry.events != nil {
+		if err := entry.events.close(); err != nil && entry.err == nil {
+			entry.err = err
+		}
+		entry.events = nil
+	}
+	if entry.err != nil {
+		return nil, entry.err
+	}
+	if err := entry.store.save(record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+/*
+	save writes the record of a run next to its event log, then appends the record to the index.
+*/
+func (store *Store) save(record *Record) error {
+	runDir := filepath.Join(store.dir, record.ID)
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return fmt.Errorf("Error Encoding the run %s, Error: %s", record.ID, err.Error())
+	}
+	if err := os.WriteFile(filepath.Join(runDir, runFile), append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("Error Writing the run %s, Error: %s", record.ID, err.Error())
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("Error Encoding the run %s, Error: %s", record.ID, err.Error())
+	}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	index, err := os.OpenFile(filepath.Join(store.dir, indexFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("Error Opening the run index, Error: %s", err.Error())
+	}
+	if _, err := index.Write(append(line, '\n')); err != nil {
+		index.Close()
+		return fmt.Errorf("Error Writing the run index, Error: %s", err.Error())
+	}
+	return index.Close()
+}
+
+/*
+	eventLog is the event log of a run being written, as gzip compressed json lines.
+*/
+type eventLog struct {
+	path    string
+	file    *os.File
+	writer  *gzip.Writer
+	encoder *json.Encoder
+}
+
+/*
+	createEventLog creates the directory of a run and its event log.
+*/
+func createEventLog(runDir string) (*eventLog, error) {
+	if err := os.MkdirAll(runDir, 0o755); err != nil {
+		return nil, fmt.Errorf("Error Creating the run directory : %s, Error: %s", runDir, err.Error())
+	}
+	path := filepath.Join(runDir, eventsFile)
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error Creating the event log : %s, Error: %s", path, err.Error())
+	}
+	writer := gzip.NewWriter(file)
+	return &eventLog{path: path, file: file, writer: writer, encoder: json.NewEncoder(writer)}, nil
+}
+
+/*
+	write appends an event to the log.
+*/
+func (events *eventLog) write(event simulation.Event) error {
+	if err := events.encoder.Encode(event); err != nil {
+		return fmt.Errorf("Error Writing the event log : %s, Error: %s", events.path, err.Error())
+	}
+	return nil
+}
+
+/*
+	close flushes the log and closes its file.
+*/
+func (events *eventLog) close() error {
+	if err := events.writer.Close(); err != nil {
+		events.file.Close()
+		return fmt.Errorf("Error Writing the event log : %s, Error: %s", events.path, err.Error())
+	}
+	return events.file.Close()
+}
+
+/*
+	List returns the records of the index, oldest first, a missing index means an empty store.
+*/
+func (store *Store) List() ([]*Record, error) {
+	index, err := os.Open(filepath.Join(store.dir, indexFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error Reading the run index, Error: %s", err.Error())
+	}
+	defer index.Close()
+
+	records := make([]*Record, 0)
+	scanner := bufio.NewScanner(index)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("Error Parsing the run index at line %d, Error: %s", line, err.Error())
+		}
+		records = append(records, &record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("Error Reading the run index, Error: %s", err.Error())
+	}
+	return records, nil
+}
+
+/*
+	Get returns the record of a run, a unique prefix of its id is enough.
+*/
+func (store *Store) Get(id string) (*Record, error) {
+	records, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+	var found *Record
+	for _, record := range records {
+		if record.ID == id {
+			return record, nil
+		}
+		if len(id) > 0 && len(record.ID) > len(id) && record.ID[:len(id)] == id {
+			if found != nil {
+				return nil, fmt.Errorf("The id %s matches several runs", id)
+			}
+			found = record
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("There is no run %s", id)
+	}
+	return found, nil
+}
+
+/*
+	Events reads the event log of a run.
+*/
+func (store *Store) Events(id string) ([]simulation.Event, error) {
+	path := filepath.Join(store.dir, id, eventsFile)
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error Reading the event log : %s, Error: %s", path, err.Error())
+	}
+	defer file.Close()
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("Error Reading the event log : %s, Error: %s", path, err.Error())
+	}
+	defer reader.Close()
+
+	events := make([]simulation.Event, 0)
+	decoder := json.NewDecoder(reader)
+	for {
+		var event simulation.Event
+		if err := decoder.Decode(&event); err == io.EOF {
+			return events, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("Error Parsing the event log : %s, Error: %s", path, err.Error())
+		}
+		events = append(events, event)
+	}
+}
+
+/*
+	newID returns an id sorting runs by their start time.
+*/
+func newID() string {
+	suffix := make([]byte, 3)
+	rand.Read(suffix)
+	return time.Now().UTC().Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+}
@@ -0,0 +1,123 @@
+package history
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rvsingh011/alien-invasion/simulation"
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+	runInto runs a simulation on the world with the aliens and keeps it in the store.
+*/
+func runInto(t *testing.T, store *Store, world, aliens string, seed int64, events ...simulation.ScheduledEvent) *Record {
+	sim, err := simulation.NewSimulation(5, strings.Count(aliens, "\n")+1, "names.txt", "world.txt", nil, nil)
+	assert.NoError(t, err)
+	sim.Output = io.Discard
+	sim.SetSeed(seed)
+	assert.NoError(t, sim.LoadWorld(strings.NewReader(world)))
+	assert.NoError(t, sim.LoadAliens(strings.NewReader(aliens)))
+	for _, event := range events {
+		assert.NoError(t, sim.Schedule(event))
+	}
+	entry := store.Track(sim, "test")
+	assert.NoError(t, sim.Start())
+	record, err := entry.Save(sim, "finished")
+	assert.NoError(t, err)
+	return record
+}
+
+func TestStore(t *testing.T) {
+	store, err := Open(t.TempDir())
+	assert.NoError(t, err)
+	records, err := store.List()
+	assert.NoError(t, err)
+	assert.Empty(t, records)
+
+	// the aliens fight on arrival in the only city
+	fight := runInto(t, store, "Foo", "A\nB", 1)
+	// the alien survives, Bar is destroyed by a scheduled event in round 3 and the lone alien ends the run
+	scheduled := runInto(t, store, "Foo east=Bar", "A", 2, simulation.ScheduledEvent{Round: 3, Action: simulation.ActionDestroyCity, City: "Bar"})
+
+	assert.Equal(t, "test", fight.Source)
+	assert.Equal(t, int64(1), fight.Seed)
+	assert.True(t, fight.Seeded)
+	assert.Equal(t, 1, fight.Rounds)
+	assert.Equal(t, map[string]int{"Foo": 1}, fight.Destroyed)
+	assert.Equal(t, []string{}, fight.Survived)
+	assert.Equal(t, "all the aliens are dead", fight.EndReason)
+	assert.Equal(t, 3, scheduled.Rounds)
+	assert.Equal(t, "stalemate, no two aliens left can ever meet", scheduled.EndReason)
+	assert.Equal(t, map[string]int{"Bar": 3}, scheduled.Destroyed)
+	assert.Equal(t, []string{"Foo"}, scheduled.Survived)
+	assert.Equal(t, 1, scheduled.AliensAlive)
+
+	records, err = store.List()
+	assert.NoError(t, err)
+	assert.Equal(t, []*Record{fight, scheduled}, records)
+
+	got, err := store.Get(scheduled.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, scheduled, got)
+	_, err = store.Get("nope")
+	assert.Error(t, err)
+
+	events, err := store.Events(fight.ID)
+	assert.NoError(t, err)
+	assert.Len(t, events, 3)
+	assert.Equal(t, "Foo was destroyed by A and B", events[2].String())
+
+	seed := int64(2)
+	tests := []struct {
+		name  string
+		query Query
+		want  []*Record
+	}{
+		{name: "Every run", query: Query{}, want: []*Record{fight, scheduled}},
+		{name: "Destroyed a city", query: Query{Destroyed: "Bar"}, want: []*Record{scheduled}},
+		{name: "Destroyed a city before a round", query: Query{Destroyed: "Bar", Before: 3}, want: []*Record{}},
+		{name: "Destroyed a city in time", query: Query{Destroyed: "Bar", Before: 4}, want: []*Record{scheduled}},
+		{name: "Survived", query: Query{Survived: "Foo"}, want: []*Record{scheduled}},
+		{name: "Seed", query: Query{Seed: &seed}, want: []*Record{scheduled}},
+		{name: "Short runs", query: Query{MaxRounds: 2}, want: []*Record{fight}},
+		{name: "Other world", query: Query{World: "other.txt"}, want: []*Record{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, err := store.Find(tt.query)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, found)
+		})
+	}
+}
+
+func TestStore_EventsAsTheyHappen(t *testing.T) {
+	dir := t.TempDir()
+	store, err := Open(dir)
+	assert.NoError(t, err)
+	sim, err := simulation.NewSimulation(5, 2, "", "world.txt", nil, nil)
+	assert.NoError(t, err)
+	sim.Output = io.Discard
+	sim.SetSeed(1)
+	assert.NoError(t, sim.LoadWorld(strings.NewReader("Foo")))
+	assert.NoError(t, sim.CreateAliens())
+	entry := store.Track(sim, "test")
+	assert.NoError(t, sim.Start())
+
+	// the event log is written during the run, the run is only listed once saved
+	_, err = os.Stat(filepath.Join(dir, entry.record.ID, eventsFile))
+	assert.NoError(t, err)
+	records, err := store.List()
+	assert.NoError(t, err)
+	assert.Empty(t, records)
+
+	record, err := entry.Save(sim, "finished")
+	assert.NoError(t, err)
+	events, err := store.Events(record.ID)
+	assert.NoError(t, err)
+	assert.Len(t, events, 3)
+}
@@ -0,0 +1,32 @@
+package worldtest
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/rvsingh011/alien-invasion/generator"
+	"github.com/rvsingh011/alien-invasion/simulation"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+/*
+	Load returns a simulation of the world map without aliens, printing nothing, for the tests of the other packages.
+*/
+func Load(t testing.TB, worldMap string) *simulation.Simulation {
+	sim, err := simulation.NewSimulation(1, 1, "", "", nil, zap.NewNop())
+	assert.NoError(t, err)
+	sim.Output = io.Discard
+	assert.NoError(t, sim.LoadWorld(strings.NewReader(worldMap)))
+	return sim
+}
+
+/*
+	Generated returns the world map of a generated world.
+*/
+func Generated(t testing.TB, options generator.Options) string {
+	world, err := generator.Generate(options)
+	assert.NoError(t, err)
+	return world.String()
+}
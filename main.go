@@ -3,10 +3,18 @@ package main
 import (
 	"flag"
 	"fmt"
-	"math/rand"
+	"net/http"
 	"os"
+	"os/signal"
 	"time"
 
+	"github.com/rvsingh011/alien-invasion/commands"
+	"github.com/rvsingh011/alien-invasion/engine"
+	"github.com/rvsingh011/alien-invasion/graphio"
+	"github.com/rvsingh011/alien-invasion/history"
+	"github.com/rvsingh011/alien-invasion/metrics"
+	"github.com/rvsingh011/alien-invasion/render"
+	"github.com/rvsingh011/alien-invasion/report"
 	"github.com/rvsingh011/alien-invasion/simulation"
 	"github.com/rvsingh011/alien-invasion/utils"
 	"go.uber.org/zap"
@@ -23,19 +31,38 @@ const (
 	WorldFile = "./data/world-example-1.txt"
 	// Default log level is info
 	LogLevel = "info"
+	// CheckpointFile used for checkpoints if not specified
+	CheckpointFile = "./alien-invasion.checkpoint.json"
 )
 
 var (
-	iterations, alienNumber int
-	worldFile, alienNames   string
+	iterations, alienNumber, checkpointEvery int
+	worldFile, alienNames                    string
+	checkpointFile, resumeFile, recordFile   string
+	svgFile, reportFile, metricsAddr         string
+	historyDir                               string
+	sampleNames, fast, progress              bool
+	seed                                     int64
 )
 
 // init cli flags
 func init() {
 	flag.IntVar(&iterations, "iterations", DefaultIterations, "number of iterations")
 	flag.IntVar(&alienNumber, "aliens", DefaultNumberOfAliens, "number of aliens invading")
-	flag.StringVar(&alienNames, "names", AlienNames, "a file used as alien names input")
+	flag.StringVar(&alienNames, "names", AlienNames, "a file used as alien names input, aliens are only known by their ID if empty")
+	flag.BoolVar(&sampleNames, "sample-names", false, "take the alien names at random from the names file instead of the first ones")
 	flag.StringVar(&worldFile, "world", WorldFile, "a file used as world map input")
+	flag.BoolVar(&progress, "progress", false, "report the progress and memory use while the world is loaded")
+	flag.Int64Var(&seed, "seed", 0, "seed of the random generator, the current time is used if 0")
+	flag.StringVar(&checkpointFile, "checkpoint", CheckpointFile, "a file the simulation is checkpointed to")
+	flag.IntVar(&checkpointEvery, "checkpoint-every", 0, "checkpoint the simulation every n rounds, never if 0")
+	flag.StringVar(&resumeFile, "resume", "", "a checkpoint file to resume the simulation from")
+	flag.StringVar(&recordFile, "record", "", "a file the run is recorded to, see the replay command")
+	flag.StringVar(&svgFile, "svg", "", "a file the world before and after the invasion is drawn to")
+	flag.StringVar(&reportFile, "report", "", "a html file the report of the run is written to")
+	flag.StringVar(&historyDir, "history", "", "a directory the run is kept in, e.g. "+history.DefaultDir+", see the history command, not kept if empty")
+	flag.BoolVar(&fast, "fast", false, "run the attack with the fast engine, without printing every round, recording, checkpoints, report, metrics or history")
+	flag.StringVar(&metricsAddr, "metrics", "", "an address the metrics of the run are served on while it runs, e.g. :9090")
 	// flag.StringVar(&logLevel, "loglevel", LogLevel, "log level for the program")
 	flag.Parse()
 }
@@ -55,32 +82,201 @@ func main() {
 
 	defer logger.Sync()
 
+	// Any argument left after the flags names a sub command, e.g. "scenario run file.json"
+	if flag.NArg() > 0 {
+		if err := commands.Run(flag.Args(), logger); err != nil {
+			fmt.Println("Command failed, Reason: ", err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
+	// a checkpointed simulation resumes where it stopped
+	if resumeFile != "" {
+		simulation, err := simulation.LoadCheckpoint(resumeFile, logger)
+		if err != nil {
+			fmt.Println("Error Resuming the simulation: ", err.Error())
+			os.Exit(1)
+		}
+		fmt.Printf("Resuming the simulation after round %d\n", simulation.Round)
+		run(simulation)
+		return
+	}
+
 	// Validte the user input
 	if err := utils.ValidateInput(iterations, alienNumber, alienNames, worldFile); err != nil {
 		fmt.Println("Invalid User Input, Reason: ", err.Error())
 		os.Exit(1)
 	}
 
-	// Create the Seed for the psedudo random genrator
-	randomSeed := buildSeed()
-
 	// create the simulation for the alien invasion
-	simulation, err := simulation.NewSimulation(iterations, alienNumber, alienNames, worldFile, randomSeed, logger)
+	simulation, err := simulation.NewSimulation(iterations, alienNumber, alienNames, worldFile, nil, logger)
 	if err != nil {
 		fmt.Println("Error Initiating a world: ", err.Error())
 		os.Exit(1)
 	}
-	simulation.CreateWorld()
+
+	// Create the Seed for the psedudo random genrator
+	simulation.SetSeed(buildSeed())
+	fmt.Printf("Using the seed %d\n", seed)
+
+	if progress {
+		simulation.OnLoadProgress(reportLoadProgress)
+	}
+	if err := graphio.LoadWorld(simulation); err != nil {
+		fmt.Println("Error Creating the world: ", err.Error())
+		os.Exit(1)
+	}
 	simulation.ViewWorld()
+	simulation.SampleNames = sampleNames
 	simulation.CreateAliens()
 	simulation.ViewAliens()
+	run(simulation)
+}
+
+// reportLoadProgress prints how far the loading of the world went
+func reportLoadProgress(progress simulation.LoadProgress) {
+	state := "Loading"
+	if progress.Done {
+		state = "Loaded"
+	}
+	fmt.Fprintf(os.Stderr, "%s the world: %d lines, %d MB read, %d cities, %d roads, %d MB in use\n",
+		state, progress.Lines, progress.Bytes>>20, progress.Cities, progress.Roads, progress.HeapAlloc>>20)
+}
+
+// run the attack until the end, checkpointing and recording the simulation on request
+func run(simulation *simulation.Simulation) {
+	if fast {
+		runFast(simulation)
+		return
+	}
+	if recordFile != "" {
+		replayFile, err := os.Create(recordFile)
+		if err != nil {
+			fmt.Println("Unable to create the replay file: ", err.Error())
+			os.Exit(1)
+		}
+		defer replayFile.Close()
+		recorder, err := simulation.Record(replayFile)
+		if err != nil {
+			fmt.Println("Unable to record the simulation: ", err.Error())
+			os.Exit(1)
+		}
+		defer func() {
+			if err := recorder.Close(); err != nil {
+				fmt.Println("Unable to record the simulation: ", err.Error())
+			}
+		}()
+	}
+
+	simulation.CheckpointEvery(checkpointEvery, checkpointFile)
+	if len(utils.CheckpointSignals) > 0 {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, utils.CheckpointSignals...)
+		defer signal.Stop(signals)
+		simulation.CheckpointOn(signals, checkpointFile)
+	}
+
+	var runReport *report.Report
+	if reportFile != "" {
+		runReport = report.Collect(simulation)
+	}
+
+	if metricsAddr != "" {
+		runMetrics := metrics.New()
+		go func() {
+			if err := http.ListenAndServe(metricsAddr, runMetrics); err != nil {
+				fmt.Println("Unable to serve the metrics: ", err.Error())
+			}
+		}()
+		runMetrics.RunStarted()
+		runMetrics.Track(simulation)
+		defer func(started time.Time) { runMetrics.RunFinished("finished", time.Since(started)) }(time.Now())
+	}
+
+	var entry *history.Entry
+	if historyDir != "" {
+		store, err := history.Open(historyDir)
+		if err != nil {
+			fmt.Println("Unable to keep the run: ", err.Error())
+		} else {
+			entry = store.Track(simulation, "cli")
+		}
+	}
+
+	before := simulation.SnapshotWorld()
 	simulation.Start()
+	remains := simulation.EndAndConclude()
+
+	if entry != nil {
+		if record, err := entry.Save(simulation, "finished"); err != nil {
+			fmt.Println("Unable to keep the run: ", err.Error())
+		} else {
+			fmt.Printf("The run was kept as %s\n", record.ID)
+		}
+	}
+
+	if svgFile != "" {
+		if err := drawWorld(svgFile, before, simulation.SnapshotWorld()); err != nil {
+			fmt.Println("Unable to draw the world: ", err.Error())
+		}
+	}
+	if runReport != nil {
+		runReport.Finish(simulation, remains)
+		if err := writeReport(reportFile, runReport); err != nil {
+			fmt.Println("Unable to write the report: ", err.Error())
+		}
+	}
+}
+
+// runFast runs the attack until the end with the fast engine, it makes the same choices as the simulation for the same seed
+func runFast(simulation *simulation.Simulation) {
+	if recordFile != "" || reportFile != "" || checkpointEvery > 0 || metricsAddr != "" || historyDir != "" {
+		fmt.Println("Invalid User Input, Reason: -fast cannot be combined with -record, -report, -checkpoint-every, -metrics or -history")
+		os.Exit(1)
+	}
+	before := simulation.SnapshotWorld()
+	started := time.Now()
+	if err := engine.Run(simulation); err != nil {
+		fmt.Println("Unable to run the fast engine: ", err.Error())
+		os.Exit(1)
+	}
+	fmt.Printf("The invasion ended after round %d in %s, %d cities and %d aliens left\n", simulation.Round, time.Since(started), len(simulation.Cities), len(simulation.Aliens))
 	simulation.EndAndConclude()
 
+	if svgFile != "" {
+		if err := drawWorld(svgFile, before, simulation.SnapshotWorld()); err != nil {
+			fmt.Println("Unable to draw the world: ", err.Error())
+		}
+	}
+}
+
+// writeReport writes the report of the run to an html file
+func writeReport(path string, runReport *report.Report) error {
+	page, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := runReport.WriteHTML(page); err != nil {
+		page.Close()
+		return err
+	}
+	return page.Close()
+}
+
+// drawWorld draws the world before and after the invasion to an svg file
+func drawWorld(path string, before, after *simulation.WorldSnapshot) error {
+	svg, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer svg.Close()
+	return render.NewScene(before).WriteSVG(svg, before, after)
 }
 
-func buildSeed() *rand.Rand {
-	seed := time.Now().UnixNano()
-	source := rand.NewSource(seed)
-	return rand.New(source)
+func buildSeed() int64 {
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return seed
 }
@@ -0,0 +1,608 @@
+package markov
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rvsingh011/alien-invasion/simulation"
+)
+
+// DefaultMaxStates is the largest number of states of the attack analysed when no other limit is given
+const DefaultMaxStates = 200000
+
+// Tolerance is the probability of the attack still going on below which the analysis stops before the last round
+const Tolerance = 1e-12
+
+// end reasons of the final states, as simulation.EndReason tells them
+const (
+	reasonAliensDead = "all the aliens are dead"
+	reasonDestroyed  = "all the cities are destroyed"
+	reasonStalemate  = "stalemate, no two aliens left can ever meet"
+)
+
+/*
+	Analysis holds the exact outcome of an attack, computed on the Markov chain of the joint positions of the aliens.
+	Survival is the probability of every city standing when the analysis started to still stand at the end of the attack.
+	FightProbability is the probability of at least one more fight, and ExpectedFirstFight the expected round of the first
+	one when it happens. ExpectedRounds is the expected round the attack ends after. Finals lists the states the attack can
+	end in, the most likely first. Unresolved is the probability left to attacks still going on when the analysis stopped,
+	at most Tolerance, it is not counted in the other figures.
+*/
+type Analysis struct {
+	States             int                `json:"states"`
+	Rounds             int                `json:"rounds"`
+	Survival           map[string]float64 `json:"survival"`
+	FightProbability   float64            `json:"fightProbability"`
+	ExpectedFirstFight float64            `json:"expectedFirstFight"`
+	ExpectedRounds     float64            `json:"expectedRounds"`
+	Finals             []*Final           `json:"finals"`
+	Unresolved         float64            `json:"unresolved"`
+}
+
+/*
+	Final is a state the attack can end in: the cities destroyed since the analysis started and the number of aliens left
+	in every city.
+*/
+type Final struct {
+	Probability float64        `json:"probability"`
+	Reason      string         `json:"reason"`
+	Destroyed   []string       `json:"destroyed"`
+	Aliens      map[string]int `json:"aliens"`
+}
+
+/*
+	state is a state of the chain: the destroyed cities, including the ones destroyed before the analysis, and the sorted
+	cities of the living aliens. Aliens are alike, only the number of aliens in every city matters.
+*/
+type state struct {
+	destroyed []bool
+	positions []int32
+	standing  int
+	// end reason if no other round is run from this state, empty while the attack goes on
+	reason string
+	// the states reached after one more round, computed on the first visit
+	next     []transition
+	expanded bool
+}
+
+type transition struct {
+	to          int
+	probability float64
+}
+
+/*
+	chain explores the states of an attack.
+	The roads of a city are the roads it had when the analysis started, a road is lost once its other end is destroyed and
+	lists a road back, which is how the simulation removes the roads of a destroyed city.
+*/
+type chain struct {
+	names      []string
+	index      map[string]int32
+	links      [][]int32
+	back       [][]bool
+	iterations int
+	maxStates  int
+	states     []*state
+	ids        map[string]int
+	key        []byte
+}
+
+/*
+	Analyze computes the exact outcome of the attack of a simulation, from the arrival of the aliens if it did not start yet
+	or from the current round otherwise. Every round every alien either stays or takes one of the roads of its city, each
+	with the same probability, trapped aliens stay. It fails without analysing anything once the attack has more than
+	maxStates states, DefaultMaxStates if not positive, which only small worlds with a few aliens stay under.
+	Scheduled events are not supported.
+*/
+func Analyze(sim *simulation.Simulation, maxStates int) (*Analysis, error) {
+	if maxStates <= 0 {
+		maxStates = DefaultMaxStates
+	}
+	for _, event := range sim.ScheduledEvents {
+		if event.Round > sim.Round {
+			return nil, fmt.Errorf("Scheduled events are not supported by the exact analysis")
+		}
+	}
+	c := newChain(sim, maxStates)
+	if sim.Ended() {
+		return c.ended(sim), nil
+	}
+
+	var start map[int]float64
+	var err error
+	if sim.Round == 0 {
+		start, err = c.arrival(sim)
+	} else {
+		start, err = c.current(sim)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c.run(sim, start)
+}
+
+func newChain(sim *simulation.Simulation, maxStates int) *chain {
+	seen := make(map[string]bool)
+	names := make([]string, 0, len(sim.World))
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	for _, city := range sim.Cities {
+		add(city.Name)
+	}
+	for city, links := range sim.World {
+		add(city)
+		for _, link := range links {
+			add(link.Name)
+		}
+	}
+	sort.Strings(names)
+	index := make(map[string]int32, len(names))
+	for id, name := range names {
+		index[name] = int32(id)
+	}
+
+	c := &chain{
+		names:      names,
+		index:      index,
+		links:      make([][]int32, len(names)),
+		back:       make([][]bool, len(names)),
+		iterations: sim.Iterations,
+		maxStates:  maxStates,
+		ids:        make(map[string]int),
+	}
+	for id, name := range names {
+		for _, link := range sim.World[name] {
+			c.links[id] = append(c.links[id], index[link.Name])
+		}
+	}
+	for city, links := range c.links {
+		c.back[city] = make([]bool, len(links))
+		for idx, to := range links {
+			for _, from := range c.links[to] {
+				if from == int32(city) {
+					c.back[city][idx] = true
+					break
+				}
+			}
+		}
+	}
+	return c
+}
+
+/*
+	destroyedAtStart marks the cities which are not standing when the analysis starts, they are only known by the roads
+	still leading to them.
+*/
+func (c *chain) destroyedAtStart(sim *simulation.Simulation) ([]bool, int) {
+	destroyed := make([]bool, len(c.names))
+	for id := range destroyed {
+		destroyed[id] = true
+	}
+	for _, city := range sim.Cities {
+		destroyed[c.index[city.Name]] = false
+	}
+	return destroyed, len(sim.Cities)
+}
+
+/*
+	ended returns the analysis of an attack which is already over, it ends as it is.
+*/
+func (c *chain) ended(sim *simulation.Simulation) *Analysis {
+	final := &Final{Probability: 1, Reason: sim.EndReason(), Destroyed: make([]string, 0), Aliens: make(map[string]int)}
+	for _, city := range sim.AlienCityMapping {
+		final.Aliens[city]++
+	}
+	analysis := &Analysis{Survival: make(map[string]float64), ExpectedRounds: float64(sim.Round), Finals: []*Final{final}}
+	for _, city := range sim.Cities {
+		analysis.Survival[city.Name] = 1
+	}
+	return analysis
+}
+
+/*
+	arrival returns the states after the first round, the aliens choose their city among the standing ones and fight.
+*/
+func (c *chain) arrival(sim *simulation.Simulation) (map[int]float64, error) {
+	destroyed, standing := c.destroyedAtStart(sim)
+	cities := make([]int32, 0, standing)
+	for city, gone := range destroyed {
+		if !gone {
+			cities = append(cities, int32(city))
+		}
+	}
+	choices := make([][]int32, len(sim.Aliens))
+	for alien := range choices {
+		choices[alien] = cities
+	}
+	outcomes, err := c.combine(choices, func(int32, int) float64 { return 1 / float64(len(cities)) })
+	if err != nil {
+		return nil, err
+	}
+	start := make(map[int]float64)
+	for _, outcome := range outcomes {
+		id, err := c.fight(destroyed, standing, outcome.positions)
+		if err != nil {
+			return nil, err
+		}
+		start[id] += outcome.probability
+	}
+	return start, nil
+}
+
+/*
+	current returns the state of a simulation which already started.
+*/
+func (c *chain) current(sim *simulation.Simulation) (map[int]float64, error) {
+	destroyed, standing := c.destroyedAtStart(sim)
+	positions := make([]int32, 0, len(sim.Aliens))
+	for _, alien := range sim.Aliens {
+		city, arrived := sim.AlienCityMapping[alien.ID]
+		if !arrived {
+			return nil, fmt.Errorf("The alien %s did not arrive in a city", alien)
+		}
+		id, ok := c.index[city]
+		if !ok {
+			return nil, fmt.Errorf("The alien %s is in %s, which is not a city of the world", alien, city)
+		}
+		positions = append(positions, id)
+	}
+	sort.Slice(positions, func(i, j int) bool { return positions[i] < positions[j] })
+	id, err := c.intern(destroyed, standing, positions)
+	if err != nil {
+		return nil, err
+	}
+	return map[int]float64{id: 1}, nil
+}
+
+/*
+	run propagates the probabilities of the states round after round, until the attack is over or the last round was run.
+*/
+func (c *chain) run(sim *simulation.Simulation, start map[int]float64) (*Analysis, error) {
+	startRound := sim.Round
+	if startRound == 0 {
+		startRound = 1
+	}
+	aliens := len(sim.Aliens)
+	analysis := &Analysis{Survival: make(map[string]float64), Finals: make([]*Final, 0)}
+	finals := make(map[string]*Final)
+
+	// a fight always kills aliens, so the attack had no fight yet as long as every alien lives
+	fought := func(id int) bool { return len(c.states[id].positions) < aliens }
+	firstFight := 0.0
+	if sim.Round == 0 {
+		for id, probability := range start {
+			if fought(id) {
+				analysis.FightProbability += probability
+				firstFight += probability
+			}
+		}
+	}
+
+	// probabilities of the states by id, states reached for the first time are appended
+	round := startRound
+	current := make([]float64, len(c.states))
+	for id, probability := range start {
+		current[id] += probability
+	}
+	for {
+		pending := 0.0
+		for id, probability := range current {
+			if probability == 0 {
+				continue
+			}
+			st := c.states[id]
+			if st.reason == "" && round < c.iterations {
+				pending += probability
+				continue
+			}
+			reason := st.reason
+			if reason == "" {
+				reason = fmt.Sprintf("all the %d rounds were run", c.iterations)
+			}
+			c.conclude(finals, st, reason, probability, sim)
+			analysis.ExpectedRounds += probability * float64(round)
+			current[id] = 0
+		}
+		if pending <= Tolerance {
+			analysis.Unresolved = pending
+			break
+		}
+
+		round++
+		next := make([]float64, len(c.states))
+		for id, probability := range current {
+			if probability == 0 {
+				continue
+			}
+			if err := c.expand(id); err != nil {
+				return nil, err
+			}
+			if len(next) < len(c.states) {
+				next = append(next, make([]float64, len(c.states)-len(next))...)
+			}
+			for _, step := range c.states[id].next {
+				next[step.to] += probability * step.probability
+				if !fought(id) && fought(step.to) {
+					analysis.FightProbability += probability * step.probability
+					firstFight += probability * step.probability * float64(round)
+				}
+			}
+		}
+		current = next
+	}
+	analysis.Rounds = round - sim.Round
+	analysis.States = len(c.states)
+	if analysis.FightProbability > 0 {
+		analysis.ExpectedFirstFight = firstFight / analysis.FightProbability
+	}
+
+	for _, final := range finals {
+		analysis.Finals = append(analysis.Finals, final)
+	}
+	sort.Slice(analysis.Finals, func(i, j int) bool {
+		if analysis.Finals[i].Probability != analysis.Finals[j].Probability {
+			return analysis.Finals[i].Probability > analysis.Finals[j].Probability
+		}
+		return finalKey(analysis.Finals[i]) < finalKey(analysis.Finals[j])
+	})
+	for _, city := range sim.Cities {
+		survival := 0.0
+		for _, final := range analysis.Finals {
+			if !contains(final.Destroyed, city.Name) {
+				survival += final.Probability
+			}
+		}
+		analysis.Survival[city.Name] = survival
+	}
+	return analysis, nil
+}
+
+/*
+	conclude adds the probability of ending in a state to its final state.
+*/
+func (c *chain) conclude(finals map[string]*Final, st *state, reason string, probability float64, sim *simulation.Simulation) {
+	final := &Final{Reason: reason, Destroyed: make([]string, 0), Aliens: make(map[string]int)}
+	for _, city := range sim.Cities {
+		if st.destroyed[c.index[city.Name]] {
+			final.Destroyed = append(final.Destroyed, city.Name)
+		}
+	}
+	sort.Strings(final.Destroyed)
+	for _, city := range st.positions {
+		final.Aliens[c.names[city]]++
+	}
+	key := finalKey(final)
+	if known, ok := finals[key]; ok {
+		known.Probability += probability
+		return
+	}
+	final.Probability = probability
+	finals[key] = final
+}
+
+func finalKey(final *Final) string {
+	cities := make([]string, 0, len(final.Aliens))
+	for city, aliens := range final.Aliens {
+		cities = append(cities, fmt.Sprintf("%s=%d", city, aliens))
+	}
+	sort.Strings(cities)
+	return final.Reason + "|" + strings.Join(final.Destroyed, ",") + "|" + strings.Join(cities, ",")
+}
+
+func contains(names []string, name string) bool {
+	for _, each := range names {
+		if each == name {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+	roads returns the cities an alien in a city can move to in a state.
+*/
+func (c *chain) roads(destroyed []bool, city int32) []int32 {
+	if destroyed[city] {
+		return nil
+	}
+	roads := make([]int32, 0, len(c.links[city]))
+	for idx, to := range c.links[city] {
+		if destroyed[to] && c.back[city][idx] {
+			continue
+		}
+		roads = append(roads, to)
+	}
+	return roads
+}
+
+/*
+	expand computes the states reached from a state after a round of moves and fights.
+*/
+func (c *chain) expand(id int) error {
+	st := c.states[id]
+	if st.expanded {
+		return nil
+	}
+	choices := make([][]int32, len(st.positions))
+	for alien, city := range st.positions {
+		// staying is the last choice
+		choices[alien] = append(c.roads(st.destroyed, city), city)
+	}
+	outcomes, err := c.combine(choices, func(_ int32, alien int) float64 { return 1 / float64(len(choices[alien])) })
+	if err != nil {
+		return err
+	}
+	reached := make(map[int]int)
+	for _, outcome := range outcomes {
+		to, err := c.fight(st.destroyed, st.standing, outcome.positions)
+		if err != nil {
+			return err
+		}
+		if idx, ok := reached[to]; ok {
+			st.next[idx].probability += outcome.probability
+			continue
+		}
+		reached[to] = len(st.next)
+		st.next = append(st.next, transition{to: to, probability: outcome.probability})
+	}
+	st.expanded = true
+	return nil
+}
+
+type outcome struct {
+	positions   []int32
+	probability float64
+}
+
+/*
+	combine returns the distribution of the sorted cities of the aliens when every alien picks one of its choices, which
+	are taken with the given probability. The aliens are added one after the other and alike outcomes merged on the way.
+*/
+func (c *chain) combine(choices [][]int32, probability func(city int32, alien int) float64) ([]outcome, error) {
+	outcomes := []outcome{{positions: []int32{}, probability: 1}}
+	for alien, cities := range choices {
+		merged := make(map[string]int)
+		next := make([]outcome, 0, len(outcomes))
+		for _, partial := range outcomes {
+			for _, city := range cities {
+				positions := insert(partial.positions, city)
+				key := c.encode(nil, positions)
+				if idx, ok := merged[key]; ok {
+					next[idx].probability += partial.probability * probability(city, alien)
+					continue
+				}
+				if len(next) >= c.maxStates {
+					return nil, c.tooBig()
+				}
+				merged[key] = len(next)
+				next = append(next, outcome{positions: positions, probability: partial.probability * probability(city, alien)})
+			}
+		}
+		outcomes = next
+	}
+	return outcomes, nil
+}
+
+/*
+	insert returns a copy of sorted cities with one more city.
+*/
+func insert(positions []int32, city int32) []int32 {
+	inserted := make([]int32, 0, len(positions)+1)
+	idx := sort.Search(len(positions), func(i int) bool { return positions[i] > city })
+	inserted = append(inserted, positions[:idx]...)
+	inserted = append(inserted, city)
+	return append(inserted, positions[idx:]...)
+}
+
+/*
+	fight destroys the cities with more than one alien and the aliens in them, and returns the state reached.
+*/
+func (c *chain) fight(destroyed []bool, standing int, positions []int32) (int, error) {
+	survivors := make([]int32, 0, len(positions))
+	// the destroyed cities are shared by the states, they are copied before a city is destroyed
+	after, copied := destroyed, false
+	for idx := 0; idx < len(positions); {
+		end := idx + 1
+		for end < len(positions) && positions[end] == positions[idx] {
+			end++
+		}
+		if end-idx == 1 {
+			survivors = append(survivors, positions[idx])
+		} else if !destroyed[positions[idx]] {
+			// aliens fighting in a city destroyed before die without destroying anything
+			if !copied {
+				after, copied = append([]bool(nil), destroyed...), true
+			}
+			after[positions[idx]] = true
+			standing--
+		}
+		idx = end
+	}
+	return c.intern(after, standing, survivors)
+}
+
+/*
+	intern returns the id of a state, adding it to the chain the first time it is reached.
+*/
+func (c *chain) intern(destroyed []bool, standing int, positions []int32) (int, error) {
+	key := c.encode(destroyed, positions)
+	if id, ok := c.ids[key]; ok {
+		return id, nil
+	}
+	if len(c.states) >= c.maxStates {
+		return 0, c.tooBig()
+	}
+	st := &state{destroyed: destroyed, positions: positions, standing: standing}
+	switch {
+	case len(positions) == 0:
+		st.reason = reasonAliensDead
+	case standing == 0:
+		st.reason = reasonDestroyed
+	case !c.fightPossible(st):
+		st.reason = reasonStalemate
+	}
+	c.ids[key] = len(c.states)
+	c.states = append(c.states, st)
+	return len(c.states) - 1, nil
+}
+
+/*
+	encode writes the destroyed cities and the cities of the aliens as a key.
+*/
+func (c *chain) encode(destroyed []bool, positions []int32) string {
+	key := c.key[:0]
+	for idx := 0; idx < len(destroyed); idx += 8 {
+		var bits byte
+		for bit := 0; bit < 8 && idx+bit < len(destroyed); bit++ {
+			if destroyed[idx+bit] {
+				bits |= 1 << bit
+			}
+		}
+		key = append(key, bits)
+	}
+	for _, city := range positions {
+		key = append(key, byte(city>>24), byte(city>>16), byte(city>>8), byte(city))
+	}
+	c.key = key
+	return string(key)
+}
+
+/*
+	fightPossible tells whether two aliens of a state can still meet, like Simulation.Stalemate it searches the world from
+	every alien at once and stops as soon as the search of an alien reaches a city of another one.
+*/
+func (c *chain) fightPossible(st *state) bool {
+	owner := make(map[int32]int, len(st.positions))
+	queue := make([]int32, 0, len(st.positions))
+	for alien, city := range st.positions {
+		if _, taken := owner[city]; taken {
+			return true
+		}
+		owner[city] = alien
+		queue = append(queue, city)
+	}
+	for idx := 0; idx < len(queue); idx++ {
+		city := queue[idx]
+		for _, link := range c.roads(st.destroyed, city) {
+			reached, ok := owner[link]
+			if !ok {
+				owner[link] = owner[city]
+				queue = append(queue, link)
+				continue
+			}
+			if reached != owner[city] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c *chain) tooBig() error {
+	return fmt.Errorf("The attack has more than %d states, the world is too big for an exact analysis", c.maxStates)
+}
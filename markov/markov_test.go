@@ -0,0 +1,197 @@
+package markov
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/rvsingh011/alien-invasion/simulation"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func newSimulation(t *testing.T, worldMap string, iterations, aliens int) *simulation.Simulation {
+	sim, err := simulation.NewSimulation(iterations, aliens, "", "", nil, zap.NewNop())
+	assert.NoError(t, err)
+	sim.Output = io.Discard
+	assert.NoError(t, sim.LoadWorld(strings.NewReader(worldMap)))
+	assert.NoError(t, sim.CreateAliens())
+	return sim
+}
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		name               string
+		worldMap           string
+		iterations         int
+		aliens             int
+		survival           map[string]float64
+		fightProbability   float64
+		expectedFirstFight float64
+		expectedRounds     float64
+		finals             []*Final
+	}{
+		{
+			// the aliens land in the same city half of the time, and meet half of the time in every round after
+			name:               "Two cities and a road",
+			worldMap:           "A east=B\nB west=A",
+			iterations:         100,
+			aliens:             2,
+			survival:           map[string]float64{"A": 0.5, "B": 0.5},
+			fightProbability:   1,
+			expectedFirstFight: 2,
+			expectedRounds:     2,
+			finals: []*Final{
+				{Probability: 0.5, Reason: "all the aliens are dead", Destroyed: []string{"A"}, Aliens: map[string]int{}},
+				{Probability: 0.5, Reason: "all the aliens are dead", Destroyed: []string{"B"}, Aliens: map[string]int{}},
+			},
+		},
+		{
+			name:               "Rounds run out",
+			worldMap:           "A east=B\nB west=A",
+			iterations:         2,
+			aliens:             2,
+			survival:           map[string]float64{"A": 0.625, "B": 0.625},
+			fightProbability:   0.75,
+			expectedFirstFight: 4.0 / 3,
+			expectedRounds:     1.5,
+			finals: []*Final{
+				{Probability: 0.375, Reason: "all the aliens are dead", Destroyed: []string{"A"}, Aliens: map[string]int{}},
+				{Probability: 0.375, Reason: "all the aliens are dead", Destroyed: []string{"B"}, Aliens: map[string]int{}},
+				{Probability: 0.25, Reason: "all the 2 rounds were run", Destroyed: []string{}, Aliens: map[string]int{"A": 1, "B": 1}},
+			},
+		},
+		{
+			name:               "Cities without roads",
+			worldMap:           "A\nB",
+			iterations:         100,
+			aliens:             2,
+			survival:           map[string]float64{"A": 0.75, "B": 0.75},
+			fightProbability:   0.5,
+			expectedFirstFight: 1,
+			expectedRounds:     1,
+			finals: []*Final{
+				{Probability: 0.5, Reason: "stalemate, no two aliens left can ever meet", Destroyed: []string{}, Aliens: map[string]int{"A": 1, "B": 1}},
+				{Probability: 0.25, Reason: "all the aliens are dead", Destroyed: []string{"A"}, Aliens: map[string]int{}},
+				{Probability: 0.25, Reason: "all the aliens are dead", Destroyed: []string{"B"}, Aliens: map[string]int{}},
+			},
+		},
+		{
+			name:           "A lone alien",
+			worldMap:       "A east=B\nB west=A",
+			iterations:     100,
+			aliens:         1,
+			survival:       map[string]float64{"A": 1, "B": 1},
+			expectedRounds: 1,
+			finals: []*Final{
+				{Probability: 0.5, Reason: "stalemate, no two aliens left can ever meet", Destroyed: []string{}, Aliens: map[string]int{"A": 1}},
+				{Probability: 0.5, Reason: "stalemate, no two aliens left can ever meet", Destroyed: []string{}, Aliens: map[string]int{"B": 1}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analysis, err := Analyze(newSimulation(t, tt.worldMap, tt.iterations, tt.aliens), 0)
+			assert.NoError(t, err)
+			assert.Len(t, analysis.Survival, len(tt.survival))
+			for city, survival := range tt.survival {
+				assert.InDelta(t, survival, analysis.Survival[city], 1e-9, city)
+			}
+			assert.InDelta(t, tt.fightProbability, analysis.FightProbability, 1e-9)
+			assert.InDelta(t, tt.expectedFirstFight, analysis.ExpectedFirstFight, 1e-9)
+			assert.InDelta(t, tt.expectedRounds, analysis.ExpectedRounds, 1e-9)
+			assert.LessOrEqual(t, analysis.Unresolved, Tolerance)
+			assert.Len(t, analysis.Finals, len(tt.finals))
+			for idx, final := range tt.finals {
+				assert.InDelta(t, final.Probability, analysis.Finals[idx].Probability, 1e-9)
+				assert.Equal(t, final.Reason, analysis.Finals[idx].Reason)
+				assert.Equal(t, final.Destroyed, analysis.Finals[idx].Destroyed)
+				assert.Equal(t, final.Aliens, analysis.Finals[idx].Aliens)
+			}
+		})
+	}
+}
+
+func TestAnalyze_FromCurrentRound(t *testing.T) {
+	// Foo was destroyed, Bar still has a road to it and an alien arriving there is trapped
+	sim := newSimulation(t, "Foo east=Bar\nBar west=Foo south=Baz\nBaz north=Bar east=Lee\nLee west=Baz", 1000, 0)
+	for _, city := range []string{"Bar", "Lee"} {
+		alien := sim.AddAlien("")
+		sim.AlienCityMapping[alien.ID] = city
+		sim.CityAlienMapping[city] = append(sim.CityAlienMapping[city], alien.ID)
+	}
+	sim.Round = 3
+	sim.World["Bar"] = sim.World["Bar"][1:]
+	sim.World["Bar"] = append(sim.World["Bar"], simulation.NewCity("Ghost"))
+	for idx, city := range sim.Cities {
+		if city.Name == "Foo" {
+			sim.Cities = append(sim.Cities[:idx], sim.Cities[idx+1:]...)
+		}
+	}
+	delete(sim.World, "Foo")
+
+	analysis, err := Analyze(sim, 0)
+	assert.NoError(t, err)
+	assert.Len(t, analysis.Survival, 3)
+	assert.InDelta(t, 1, analysis.FightProbability+finalProbability(analysis, "stalemate, no two aliens left can ever meet"), 1e-9)
+	assert.Greater(t, analysis.ExpectedFirstFight, 3.0)
+	for _, final := range analysis.Finals {
+		assert.NotContains(t, final.Destroyed, "Foo")
+		assert.NotContains(t, final.Destroyed, "Ghost")
+	}
+}
+
+func finalProbability(analysis *Analysis, reason string) float64 {
+	probability := 0.0
+	for _, final := range analysis.Finals {
+		if final.Reason == reason {
+			probability += final.Probability
+		}
+	}
+	return probability
+}
+
+func TestAnalyze_MatchesSimulation(t *testing.T) {
+	worldMap, err := os.ReadFile("../data/world-example-1.txt")
+	assert.NoError(t, err)
+	analysis, err := Analyze(newSimulation(t, string(worldMap), 100, 3), 0)
+	assert.NoError(t, err)
+
+	const runs = 4000
+	survived := make(map[string]float64)
+	rounds := 0.0
+	for seed := int64(1); seed <= runs; seed++ {
+		sim := newSimulation(t, string(worldMap), 100, 3)
+		sim.SetSeed(seed)
+		assert.NoError(t, sim.Start())
+		for _, city := range sim.Cities {
+			survived[city.Name] += 1.0 / runs
+		}
+		rounds += float64(sim.Round) / runs
+	}
+	for city, survival := range analysis.Survival {
+		assert.InDelta(t, survival, survived[city], 0.03, city)
+	}
+	assert.InDelta(t, analysis.ExpectedRounds, rounds, 0.5)
+}
+
+func TestAnalyze_Refuses(t *testing.T) {
+	sim := newSimulation(t, "A east=B\nB east=C\nC east=D\nD east=A", 100, 6)
+	_, err := Analyze(sim, 10)
+	assert.EqualError(t, err, "The attack has more than 10 states, the world is too big for an exact analysis")
+
+	sim = newSimulation(t, "A east=B", 100, 2)
+	assert.NoError(t, sim.Schedule(simulation.ScheduledEvent{Round: 2, Action: simulation.ActionLand, City: "A", Count: 1}))
+	_, err = Analyze(sim, 0)
+	assert.EqualError(t, err, "Scheduled events are not supported by the exact analysis")
+}
+
+func TestAnalyze_Ended(t *testing.T) {
+	sim := newSimulation(t, "A east=B\nB west=A", 0, 2)
+	analysis, err := Analyze(sim, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]float64{"A": 1, "B": 1}, analysis.Survival)
+	assert.Len(t, analysis.Finals, 1)
+	assert.Equal(t, "all the 0 rounds were run", analysis.Finals[0].Reason)
+}
@@ -0,0 +1,218 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rvsingh011/alien-invasion/simulation"
+)
+
+// upper bounds in seconds of the buckets of the run duration histogram
+var durationBuckets = []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60, 300}
+
+// number of seconds the rounds per second are averaged over
+const rateWindow = 10
+
+/*
+	Metrics counts what the simulations did, in the Prometheus text exposition format.
+*/
+type Metrics struct {
+	mu sync.Mutex
+
+	runsStarted    uint64
+	runsFinished   map[string]uint64
+	activeRuns     int
+	queuedRuns     int
+	rounds         uint64
+	citiesDestroys uint64
+	aliensKilled   uint64
+
+	// rounds run during each of the last seconds, indexed by the unix second modulo the window
+	recentRounds [rateWindow]uint64
+	recentSecond [rateWindow]int64
+
+	durationCounts []uint64
+	durationSum    float64
+	durationCount  uint64
+
+	// now returns the current time, replaced by the tests
+	now func() time.Time
+}
+
+/*
+	New returns metrics with every count at zero.
+*/
+func New() *Metrics {
+	return &Metrics{
+		runsFinished:   make(map[string]uint64),
+		durationCounts: make([]uint64, len(durationBuckets)),
+		now:            time.Now,
+	}
+}
+
+/*
+	RunQueued counts a run waiting for a free slot.
+*/
+func (metrics *Metrics) RunQueued() {
+	metrics.update(func() { metrics.queuedRuns++ })
+}
+
+/*
+	RunDequeued counts a run which stopped waiting, because it started or was cancelled.
+*/
+func (metrics *Metrics) RunDequeued() {
+	metrics.update(func() { metrics.queuedRuns-- })
+}
+
+/*
+	RunStarted counts a run being simulated.
+*/
+func (metrics *Metrics) RunStarted() {
+	metrics.update(func() {
+		metrics.runsStarted++
+		metrics.activeRuns++
+	})
+}
+
+/*
+	RunFinished counts a run which ended with the status, after being simulated for duration.
+*/
+func (metrics *Metrics) RunFinished(status string, duration time.Duration) {
+	metrics.update(func() {
+		metrics.runsFinished[status]++
+		metrics.activeRuns--
+		seconds := duration.Seconds()
+		for idx, bound := range durationBuckets {
+			if seconds <= bound {
+				metrics.durationCounts[idx]++
+			}
+		}
+		metrics.durationSum += seconds
+		metrics.durationCount++
+	})
+}
+
+/*
+	update changes the metrics with the lock held.
+*/
+func (metrics *Metrics) update(change func()) {
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	change()
+}
+
+/*
+	Track counts the rounds of the simulation, the cities destroyed and the aliens killed in them.
+*/
+func (metrics *Metrics) Track(sim *simulation.Simulation) {
+	cities, aliens, landed := len(sim.World), len(sim.Aliens), 0
+	sim.OnEvent(func(event simulation.Event) {
+		if event.Kind == simulation.EventLand {
+			landed++
+		}
+	})
+	sim.OnRound(func(sim *simulation.Simulation) {
+		destroyed := cities - len(sim.World)
+		killed := aliens + landed - len(sim.Aliens)
+		cities, aliens, landed = len(sim.World), len(sim.Aliens), 0
+
+		metrics.mu.Lock()
+		defer metrics.mu.Unlock()
+		metrics.rounds++
+		if destroyed > 0 {
+			metrics.citiesDestroys += uint64(destroyed)
+		}
+		if killed > 0 {
+			metrics.aliensKilled += uint64(killed)
+		}
+		second := metrics.now().Unix()
+		slot := second % rateWindow
+		if metrics.recentSecond[slot] != second {
+			metrics.recentSecond[slot] = second
+			metrics.recentRounds[slot] = 0
+		}
+		metrics.recentRounds[slot]++
+	})
+}
+
+/*
+	roundsPerSecond averages the rounds run during the last complete seconds of the window.
+*/
+func (metrics *Metrics) roundsPerSecond() float64 {
+	now := metrics.now().Unix()
+	var total uint64
+	for slot := range metrics.recentSecond {
+		if second := metrics.recentSecond[slot]; second < now && second >= now-rateWindow {
+			total += metrics.recentRounds[slot]
+		}
+	}
+	return float64(total) / rateWindow
+}
+
+/*
+	WriteTo writes the metrics in the Prometheus text exposition format.
+*/
+func (metrics *Metrics) WriteTo(w io.Writer) (int64, error) {
+	metrics.mu.Lock()
+	var text strings.Builder
+	family := func(name, kind, help string) {
+		text.WriteString(fmt.Sprintf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind))
+	}
+	sample := func(name, labels string, value float64) {
+		text.WriteString(name + labels + " " + strconv.FormatFloat(value, 'g', -1, 64) + "\n")
+	}
+
+	family("alien_invasion_runs_started_total", "counter", "Runs which started being simulated.")
+	sample("alien_invasion_runs_started_total", "", float64(metrics.runsStarted))
+
+	family("alien_invasion_runs_finished_total", "counter", "Runs which ended, by final status.")
+	statuses := make([]string, 0, len(metrics.runsFinished))
+	for status := range metrics.runsFinished {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+	for _, status := range statuses {
+		sample("alien_invasion_runs_finished_total", fmt.Sprintf("{status=%q}", status), float64(metrics.runsFinished[status]))
+	}
+
+	family("alien_invasion_active_runs", "gauge", "Runs being simulated.")
+	sample("alien_invasion_active_runs", "", float64(metrics.activeRuns))
+	family("alien_invasion_queued_runs", "gauge", "Runs waiting to be simulated.")
+	sample("alien_invasion_queued_runs", "", float64(metrics.queuedRuns))
+
+	family("alien_invasion_rounds_total", "counter", "Rounds of attack run.")
+	sample("alien_invasion_rounds_total", "", float64(metrics.rounds))
+	family("alien_invasion_rounds_per_second", "gauge", fmt.Sprintf("Rounds of attack run per second, averaged over the last %d seconds.", rateWindow))
+	sample("alien_invasion_rounds_per_second", "", metrics.roundsPerSecond())
+
+	family("alien_invasion_cities_destroyed_total", "counter", "Cities destroyed.")
+	sample("alien_invasion_cities_destroyed_total", "", float64(metrics.citiesDestroys))
+	family("alien_invasion_aliens_killed_total", "counter", "Aliens killed.")
+	sample("alien_invasion_aliens_killed_total", "", float64(metrics.aliensKilled))
+
+	family("alien_invasion_run_duration_seconds", "histogram", "Time spent simulating a run.")
+	for idx, bound := range durationBuckets {
+		sample("alien_invasion_run_duration_seconds_bucket", fmt.Sprintf("{le=%q}", strconv.FormatFloat(bound, 'g', -1, 64)), float64(metrics.durationCounts[idx]))
+	}
+	sample("alien_invasion_run_duration_seconds_bucket", `{le="+Inf"}`, float64(metrics.durationCount))
+	sample("alien_invasion_run_duration_seconds_sum", "", metrics.durationSum)
+	sample("alien_invasion_run_duration_seconds_count", "", float64(metrics.durationCount))
+	metrics.mu.Unlock()
+
+	written, err := io.WriteString(w, text.String())
+	return int64(written), err
+}
+
+/*
+	ServeHTTP exposes the metrics to a Prometheus scraper.
+*/
+func (metrics *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	metrics.WriteTo(w)
+}
@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rvsingh011/alien-invasion/simulation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	metrics := New()
+	clock := time.Unix(1000, 0)
+	metrics.now = func() time.Time { return clock }
+
+	// two aliens destroy the only city on arrival, a third one lands on it in the same round
+	sim, err := simulation.NewSimulation(10, 2, "", "", nil, nil)
+	assert.NoError(t, err)
+	sim.Output = io.Discard
+	sim.SetSeed(1)
+	assert.NoError(t, sim.LoadWorld(strings.NewReader("Foo")))
+	assert.NoError(t, sim.LoadAliens(strings.NewReader("A\nB")))
+	assert.NoError(t, sim.Schedule(simulation.ScheduledEvent{Round: 1, Action: simulation.ActionLand, City: "Foo", Count: 1}))
+
+	metrics.RunQueued()
+	metrics.RunQueued()
+	metrics.RunDequeued()
+	metrics.RunStarted()
+	metrics.Track(sim)
+	assert.NoError(t, sim.Start())
+	metrics.RunFinished("finished", 2*time.Second)
+
+	clock = clock.Add(time.Second)
+	var text bytes.Buffer
+	_, err = metrics.WriteTo(&text)
+	assert.NoError(t, err)
+
+	tests := []string{
+		"# TYPE alien_invasion_runs_started_total counter\nalien_invasion_runs_started_total 1\n",
+		"alien_invasion_runs_finished_total{status=\"finished\"} 1\n",
+		"# TYPE alien_invasion_active_runs gauge\nalien_invasion_active_runs 0\n",
+		"alien_invasion_queued_runs 1\n",
+		"alien_invasion_rounds_total 1\n",
+		"alien_invasion_rounds_per_second 0.1\n",
+		"alien_invasion_cities_destroyed_total 1\n",
+		"alien_invasion_aliens_killed_total 3\n",
+		"# TYPE alien_invasion_run_duration_seconds histogram\n",
+		"alien_invasion_run_duration_seconds_bucket{le=\"1\"} 0\n",
+		"alien_invasion_run_duration_seconds_bucket{le=\"5\"} 1\n",
+		"alien_invasion_run_duration_seconds_bucket{le=\"+Inf\"} 1\n",
+		"alien_invasion_run_duration_seconds_sum 2\n",
+		"alien_invasion_run_duration_seconds_count 1\n",
+	}
+	for _, want := range tests {
+		assert.Contains(t, text.String(), want)
+	}
+
+	// the rate only covers the last seconds
+	clock = clock.Add(time.Minute)
+	text.Reset()
+	metrics.WriteTo(&text)
+	assert.Contains(t, text.String(), "alien_invasion_rounds_per_second 0\n")
+}
@@ -0,0 +1,181 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"io"
+	"sort"
+
+	"github.com/rvsingh011/alien-invasion/simulation"
+)
+
+const (
+	// DefaultFrameDelay is the delay between two frames of the animation, in hundredths of a second
+	DefaultFrameDelay = 50
+	// the last frame of the animation is held this many times longer
+	lastFrameHold = 6
+	// height in pixels of the bar showing the progress of the invasion
+	progressHeight = 8
+	// radius in pixels of an alien
+	alienRadius = 4
+)
+
+// indexes of the colors of the animation palette
+const (
+	colorBackground = iota
+	colorRoad
+	colorDestroyedRoad
+	colorCity
+	colorDestroyedCity
+	colorAlien
+	colorCross
+	colorProgress
+)
+
+var palette = color.Palette{
+	colorBackground:    color.White,
+	colorRoad:          color.RGBA{0x55, 0x55, 0x55, 0xff},
+	colorDestroyedRoad: color.RGBA{0xdd, 0xdd, 0xdd, 0xff},
+	colorCity:          color.RGBA{0x46, 0x82, 0xb4, 0xff},
+	colorDestroyedCity: color.RGBA{0xcc, 0xcc, 0xcc, 0xff},
+	colorAlien:         color.RGBA{0xdc, 0x14, 0x3c, 0xff},
+	colorCross:         color.RGBA{0xbb, 0x22, 0x22, 0xff},
+	colorProgress:      color.RGBA{0x99, 0x99, 0x99, 0xff},
+}
+
+/*
+	WriteGIF draws one frame per state of the world to an animated GIF, delay is the time between two frames in hundredths of a second.
+	The standard library cannot draw text, so the bar at the top shows how far in the invasion each frame is
+	and every alien is drawn as a red dot next to its city.
+*/
+func (scene *Scene) WriteGIF(w io.Writer, states []*simulation.WorldSnapshot, delay int) error {
+	if len(states) == 0 {
+		return fmt.Errorf("There is no state of the world to animate")
+	}
+	if delay < 1 {
+		delay = DefaultFrameDelay
+	}
+	width, height := scene.panelSize()
+	last := states[len(states)-1].Round
+
+	animation := &gif.GIF{Config: image.Config{ColorModel: palette, Width: width, Height: height}}
+	for idx, state := range states {
+		frame := image.NewPaletted(image.Rect(0, 0, width, height), palette)
+		scene.drawFrame(frame, state, last)
+		frameDelay := delay
+		if idx == len(states)-1 {
+			frameDelay *= lastFrameHold
+		}
+		animation.Image = append(animation.Image, frame)
+		animation.Delay = append(animation.Delay, frameDelay)
+	}
+	if err := gif.EncodeAll(w, animation); err != nil {
+		return fmt.Errorf("Error Encoding the animation, Error: %s", err.Error())
+	}
+	return nil
+}
+
+/*
+	drawFrame draws a single state of the world, last is the round of the last frame.
+*/
+func (scene *Scene) drawFrame(frame *image.Paletted, state *simulation.WorldSnapshot, last int) {
+	if last > 0 {
+		width := frame.Rect.Dx() * state.Round / last
+		fillRect(frame, 0, 0, width, progressHeight, colorProgress)
+	}
+
+	for _, road := range scene.roads {
+		x1, y1 := scene.position(road[0])
+		x2, y2 := scene.position(road[1])
+		if standing(state, road) {
+			drawLine(frame, x1, y1, x2, y2, 3, colorRoad)
+			continue
+		}
+		drawLine(frame, x1, y1, x2, y2, 1, colorDestroyedRoad)
+	}
+
+	aliens := state.AliensIn()
+	for _, city := range scene.base.Cities {
+		x, y := scene.position(city.Name)
+		if _, alive := state.World[city.Name]; alive {
+			fillCircle(frame, x, y, cityRadius, colorCity)
+		} else {
+			fillCircle(frame, x, y, cityRadius, colorDestroyedCity)
+			drawLine(frame, x-cityRadius, y-cityRadius, x+cityRadius, y+cityRadius, 2, colorCross)
+			drawLine(frame, x-cityRadius, y+cityRadius, x+cityRadius, y-cityRadius, 2, colorCross)
+		}
+		inCity := aliens[city.Name]
+		sort.Strings(inCity)
+		for idx := range inCity {
+			// aliens are lined up above the right of the city, wrapping after a few of them
+			ax := x + cityRadius + (idx%4)*(2*alienRadius+1)
+			ay := y - cityRadius - (idx/4)*(2*alienRadius+1)
+			fillCircle(frame, ax, ay, alienRadius, colorAlien)
+		}
+	}
+}
+
+/*
+	fillRect fills the rectangle from (x1, y1) included to (x2, y2) excluded.
+*/
+func fillRect(frame *image.Paletted, x1, y1, x2, y2 int, index uint8) {
+	for y := y1; y < y2; y++ {
+		for x := x1; x < x2; x++ {
+			if (image.Point{X: x, Y: y}).In(frame.Rect) {
+				frame.SetColorIndex(x, y, index)
+			}
+		}
+	}
+}
+
+/*
+	fillCircle fills the disc of radius r centred on (cx, cy).
+*/
+func fillCircle(frame *image.Paletted, cx, cy, r int, index uint8) {
+	for y := -r; y <= r; y++ {
+		for x := -r; x <= r; x++ {
+			if x*x+y*y <= r*r {
+				fillRect(frame, cx+x, cy+y, cx+x+1, cy+y+1, index)
+			}
+		}
+	}
+}
+
+/*
+	drawLine draws a line of the given width from (x1, y1) to (x2, y2) with Bresenham's algorithm.
+*/
+func drawLine(frame *image.Paletted, x1, y1, x2, y2, width int, index uint8) {
+	dx, dy := absInt(x2-x1), -absInt(y2-y1)
+	sx, sy := 1, 1
+	if x1 > x2 {
+		sx = -1
+	}
+	if y1 > y2 {
+		sy = -1
+	}
+	offset := (width - 1) / 2
+	for err := dx + dy; ; {
+		fillRect(frame, x1-offset, y1-offset, x1-offset+width, y1-offset+width, index)
+		if x1 == x2 && y1 == y2 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x1 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y1 += sy
+		}
+	}
+}
+
+func absInt(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
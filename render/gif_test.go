@@ -0,0 +1,59 @@
+package render
+
+import (
+	"bytes"
+	"image/gif"
+	"testing"
+
+	"github.com/rvsingh011/alien-invasion/simulation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScene_WriteGIF(t *testing.T) {
+	sim := newTestSimulation(t)
+	before := sim.SnapshotWorld()
+	sim.Round = 2
+	zork := sim.AddAlien("Zork")
+	sim.AlienCityMapping[zork.ID] = "Foo"
+	middle := sim.SnapshotWorld()
+	sim.Round = 4
+	assert.NoError(t, sim.DestroyCity("Bar"))
+	after := sim.SnapshotWorld()
+
+	scene := NewScene(before)
+	var animation bytes.Buffer
+	assert.NoError(t, scene.WriteGIF(&animation, []*simulation.WorldSnapshot{before, middle, after}, 10))
+
+	decoded, err := gif.DecodeAll(&animation)
+	assert.NoError(t, err)
+	assert.Len(t, decoded.Image, 3)
+	assert.Equal(t, []int{10, 10, 10 * lastFrameHold}, decoded.Delay)
+
+	colorAt := func(frame int, x, y int) uint8 {
+		return decoded.Image[frame].ColorIndexAt(x, y)
+	}
+	fooX, fooY := scene.position("Foo")
+	barX, barY := scene.position("Bar")
+	tests := []struct {
+		name  string
+		frame int
+		x, y  int
+		want  uint8
+	}{
+		{name: "City standing before the invasion", frame: 0, x: barX, y: barY, want: colorCity},
+		{name: "No alien before the invasion", frame: 0, x: fooX + cityRadius, y: fooY - cityRadius - alienRadius + 1, want: colorBackground},
+		{name: "Alien next to its city", frame: 1, x: fooX + cityRadius, y: fooY - cityRadius, want: colorAlien},
+		{name: "Progress half way", frame: 1, x: decoded.Config.Width/2 - 1, y: 0, want: colorProgress},
+		{name: "Progress not further", frame: 1, x: decoded.Config.Width/2 + 1, y: 0, want: colorBackground},
+		{name: "Destroyed city", frame: 2, x: barX, y: barY - cityRadius/2, want: colorDestroyedCity},
+		{name: "Destroyed road", frame: 2, x: fooX, y: (fooY + barY) / 2, want: colorDestroyedRoad},
+		{name: "Standing road", frame: 1, x: fooX, y: (fooY + barY) / 2, want: colorRoad},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, colorAt(tt.frame, tt.x, tt.y))
+		})
+	}
+
+	assert.Error(t, scene.WriteGIF(&animation, nil, 10))
+}
@@ -0,0 +1,176 @@
+package render
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/rvsingh011/alien-invasion/simulation"
+)
+
+const (
+	// distance in pixels between two neighbour cities on the grid
+	cellSize = 90
+	// margin in pixels around the grid of a panel
+	margin = 50
+	// height in pixels of the title of a panel
+	titleHeight = 30
+	// radius in pixels of a city
+	cityRadius = 14
+)
+
+/*
+	Scene places the cities of the world on a grid inferred from the compass directions of their roads.
+	The scene is built from the world before the invasion, later states of the world are drawn over it
+	so destroyed cities and roads keep their place.
+*/
+type Scene struct {
+	base   *simulation.WorldSnapshot
+	layout *simulation.Layout
+	roads  [][2]string
+}
+
+/*
+	NewScene lays out the world of the snapshot.
+*/
+func NewScene(base *simulation.WorldSnapshot) *Scene {
+	scene := &Scene{base: base, layout: simulation.InferLayout(base.World, base.Cities)}
+	known := make(map[[2]string]bool)
+	for city, links := range base.World {
+		for _, link := range links {
+			road := [2]string{city, link.Name}
+			if road[0] > road[1] {
+				road[0], road[1] = road[1], road[0]
+			}
+			if !known[road] {
+				known[road] = true
+				scene.roads = append(scene.roads, road)
+			}
+		}
+	}
+	sort.Slice(scene.roads, func(i, j int) bool {
+		if scene.roads[i][0] != scene.roads[j][0] {
+			return scene.roads[i][0] < scene.roads[j][0]
+		}
+		return scene.roads[i][1] < scene.roads[j][1]
+	})
+	return scene
+}
+
+/*
+	Layout returns the grid layout of the scene.
+*/
+func (scene *Scene) Layout() *simulation.Layout {
+	return scene.layout
+}
+
+/*
+	panelSize returns the size in pixels of a panel showing the whole grid.
+*/
+func (scene *Scene) panelSize() (int, int) {
+	width := 2*margin + maxInt(scene.layout.Width-1, 0)*cellSize
+	height := titleHeight + 2*margin + maxInt(scene.layout.Height-1, 0)*cellSize
+	return width, height
+}
+
+/*
+	position returns the centre in pixels of a city in a panel.
+*/
+func (scene *Scene) position(city string) (int, int) {
+	point := scene.layout.Positions[city]
+	return margin + point.X*cellSize, titleHeight + margin + point.Y*cellSize
+}
+
+/*
+	WriteSVG draws one panel per state of the world side by side, e.g. the world before and after the invasion.
+	Destroyed cities are greyed out and crossed, destroyed roads are dashed and the aliens are marked in red.
+*/
+func (scene *Scene) WriteSVG(w io.Writer, states ...*simulation.WorldSnapshot) error {
+	panelWidth, panelHeight := scene.panelSize()
+	var svg strings.Builder
+	svg.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="12">`+"\n",
+		panelWidth*len(states), panelHeight, panelWidth*len(states), panelHeight))
+	svg.WriteString(`<rect width="100%" height="100%" fill="white"/>` + "\n")
+	for _, conflict := range scene.layout.Conflicts {
+		svg.WriteString(fmt.Sprintf("<!-- layout conflict: %s -->\n", strings.ReplaceAll(conflict.Reason, "--", "-")))
+	}
+	for idx, state := range states {
+		svg.WriteString(fmt.Sprintf(`<g transform="translate(%d,0)">`+"\n", idx*panelWidth))
+		scene.writePanel(&svg, state)
+		svg.WriteString("</g>\n")
+	}
+	svg.WriteString("</svg>\n")
+	_, err := io.WriteString(w, svg.String())
+	return err
+}
+
+/*
+	writePanel draws a single state of the world.
+*/
+func (scene *Scene) writePanel(svg *strings.Builder, state *simulation.WorldSnapshot) {
+	svg.WriteString(fmt.Sprintf(`<text x="%d" y="20" font-size="16" font-weight="bold">%s</text>`+"\n", margin/2, caption(state)))
+
+	for _, road := range scene.roads {
+		x1, y1 := scene.position(road[0])
+		x2, y2 := scene.position(road[1])
+		if standing(state, road) {
+			svg.WriteString(fmt.Sprintf(`<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#555" stroke-width="3"/>`+"\n", x1, y1, x2, y2))
+			continue
+		}
+		svg.WriteString(fmt.Sprintf(`<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#ccc" stroke-width="2" stroke-dasharray="6,4"/>`+"\n", x1, y1, x2, y2))
+	}
+
+	aliens := state.AliensIn()
+	for _, city := range scene.base.Cities {
+		x, y := scene.position(city.Name)
+		name := html.EscapeString(city.Name)
+		if _, alive := state.World[city.Name]; alive {
+			svg.WriteString(fmt.Sprintf(`<circle cx="%d" cy="%d" r="%d" fill="steelblue"><title>%s</title></circle>`+"\n", x, y, cityRadius, name))
+			svg.WriteString(fmt.Sprintf(`<text x="%d" y="%d" text-anchor="middle">%s</text>`+"\n", x, y+cityRadius+14, name))
+		} else {
+			svg.WriteString(fmt.Sprintf(`<circle cx="%d" cy="%d" r="%d" fill="#ddd" stroke="#aaa"><title>%s (destroyed)</title></circle>`+"\n", x, y, cityRadius, name))
+			svg.WriteString(fmt.Sprintf(`<path d="M%d %d L%d %d M%d %d L%d %d" stroke="#b22" stroke-width="2"/>`+"\n",
+				x-cityRadius, y-cityRadius, x+cityRadius, y+cityRadius, x-cityRadius, y+cityRadius, x+cityRadius, y-cityRadius))
+			svg.WriteString(fmt.Sprintf(`<text x="%d" y="%d" text-anchor="middle" fill="#aaa" text-decoration="line-through">%s</text>`+"\n", x, y+cityRadius+14, name))
+		}
+
+		if inCity := aliens[city.Name]; len(inCity) > 0 {
+			svg.WriteString(fmt.Sprintf(`<circle cx="%d" cy="%d" r="9" fill="crimson"><title>%s</title></circle>`+"\n",
+				x+cityRadius, y-cityRadius, html.EscapeString(strings.Join(inCity, ", "))))
+			svg.WriteString(fmt.Sprintf(`<text x="%d" y="%d" text-anchor="middle" fill="white" font-size="10">%d</text>`+"\n", x+cityRadius, y-cityRadius+4, len(inCity)))
+		}
+	}
+}
+
+/*
+	standing reports whether a road and both of its cities are still standing in a state of the world.
+	A one way road can outlive the city it leads to, it is drawn as destroyed.
+*/
+func standing(state *simulation.WorldSnapshot, road [2]string) bool {
+	if _, ok := state.World[road[0]]; !ok {
+		return false
+	}
+	if _, ok := state.World[road[1]]; !ok {
+		return false
+	}
+	return state.HasRoad(road[0], road[1]) || state.HasRoad(road[1], road[0])
+}
+
+/*
+	caption returns the title of the panel showing a state of the world.
+*/
+func caption(state *simulation.WorldSnapshot) string {
+	if state.Round == 0 {
+		return "Before the invasion"
+	}
+	return fmt.Sprintf("After round %d: %d cities, %d aliens", state.Round, len(state.World), len(state.AlienCityMapping))
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
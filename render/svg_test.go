@@ -0,0 +1,61 @@
+package render
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/rvsingh011/alien-invasion/simulation"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSimulation(t *testing.T) *simulation.Simulation {
+	sim, err := simulation.NewSimulation(10, 0, "", "", nil, nil)
+	assert.NoError(t, err)
+	sim.Output = io.Discard
+	assert.NoError(t, sim.LoadWorld(strings.NewReader("Foo north=Bar west=Baz south=Qu-ux\nBar west=Bee")))
+	return sim
+}
+
+func TestScene_WriteSVG(t *testing.T) {
+	sim := newTestSimulation(t)
+	before := sim.SnapshotWorld()
+	sim.Round = 3
+	assert.NoError(t, sim.DestroyCity("Bar"))
+	zork := sim.AddAlien("Zork")
+	sim.AlienCityMapping[zork.ID] = "Foo"
+	after := sim.SnapshotWorld()
+
+	tests := []struct {
+		name         string
+		states       []*simulation.WorldSnapshot
+		wantContains []string
+		wantMissing  []string
+	}{
+		{
+			name:         "World before the invasion",
+			states:       []*simulation.WorldSnapshot{before},
+			wantContains: []string{"Before the invasion", `<title>Bar</title>`, `<title>Qu-ux</title>`},
+			wantMissing:  []string{"destroyed", "stroke-dasharray", "crimson"},
+		},
+		{
+			name:         "World before and after the invasion",
+			states:       []*simulation.WorldSnapshot{before, after},
+			wantContains: []string{"Before the invasion", "After round 3: 4 cities, 1 aliens", "<title>Bar (destroyed)</title>", "stroke-dasharray", "<title>Zork</title>"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var svg bytes.Buffer
+			assert.NoError(t, NewScene(before).WriteSVG(&svg, tt.states...))
+			assert.True(t, strings.HasPrefix(svg.String(), "<svg"))
+			for _, want := range tt.wantContains {
+				assert.Contains(t, svg.String(), want)
+			}
+			for _, missing := range tt.wantMissing {
+				assert.NotContains(t, svg.String(), missing)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package report
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"strings"
+
+	"github.com/rvsingh011/alien-invasion/render"
+)
+
+const (
+	// size in pixels of the charts
+	chartWidth  = 640
+	chartHeight = 220
+	// margin in pixels around the plot area of the charts
+	chartMargin = 36
+)
+
+var page = template.Must(template.New("report").Funcs(template.FuncMap{
+	"join": strings.Join,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Alien invasion report{{if .Seeded}} - seed {{.Seed}}{{end}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+th { background: #f2f2f2; }
+.map { overflow-x: auto; }
+pre { background: #f7f7f7; padding: 1em; }
+</style>
+</head>
+<body>
+<h1>Alien invasion report</h1>
+
+<h2>Parameters</h2>
+<table>
+<tr><th>Seed</th><td>{{if .Seeded}}{{.Seed}}{{else}}not reproducible{{end}}</td></tr>
+<tr><th>World file</th><td>{{.WorldFile}}</td></tr>
+<tr><th>Alien names file</th><td>{{.AlienNames}}</td></tr>
+<tr><th>Aliens</th><td>{{.NumberOfAliens}}</td></tr>
+<tr><th>Iterations</th><td>{{.Iterations}}</td></tr>
+<tr><th>Rounds run</th><td>{{len .Rounds}}</td></tr>
+{{if .EndReason}}<tr><th>Ended because</th><td>{{.EndReason}}</td></tr>
+{{end}}{{range .ScheduledEvents}}<tr><th>Scheduled event</th><td>{{.}}</td></tr>
+{{end}}</table>
+
+<h2>Summary</h2>
+{{.Summary}}
+{{.Chart}}
+
+<h2>Maps</h2>
+<div class="map">{{.Maps}}</div>
+
+<h2>Timeline</h2>
+{{with .Active}}<table>
+<tr><th>Round</th><th>Destroyed cities</th><th>Dead aliens</th><th>Scheduled events</th><th>Cities left</th><th>Aliens left</th></tr>
+{{range .}}<tr><td>{{.Round}}</td><td>{{range .Fights}}{{.City}} by {{join .Aliens ", "}}<br>{{end}}{{join .Razed ", "}}</td><td>{{join .Dead ", "}}</td><td>{{join .Scheduled "; "}}</td><td>{{.Cities}}</td><td>{{.Aliens}}</td></tr>
+{{end}}</table>{{else}}<p>Nothing was destroyed.</p>{{end}}
+
+<h2>Alien paths</h2>
+<table>
+<tr><th>Alien</th><th>Path</th><th>Fate</th></tr>
+{{range .Paths}}<tr><td>{{.Alien}}</td><td>{{range $idx, $step := .Steps}}{{if $idx}} &rarr; {{end}}{{$step.City}} ({{$step.Round}}){{end}}</td><td>{{.Fate}}{{if .DiedIn}} in round {{.DiedIn}}{{end}}</td></tr>
+{{end}}</table>
+
+<h2>Remains of the world</h2>
+<pre>{{.Remains}}</pre>
+</body>
+</html>
+`))
+
+/*
+	WriteHTML writes the report as a single HTML page, the maps and charts are inline SVG.
+*/
+func (report *Report) WriteHTML(w io.Writer) error {
+	if report.After == nil {
+		return fmt.Errorf("The report is not finished, the run has not ended")
+	}
+	var maps strings.Builder
+	if err := render.NewScene(report.Before).WriteSVG(&maps, report.Before, report.After); err != nil {
+		return err
+	}
+	data := struct {
+		*Report
+		Active  []Round
+		Maps    template.HTML
+		Chart   template.HTML
+		Summary template.HTML
+	}{
+		Report:  report,
+		Active:  report.ActiveRounds(),
+		Maps:    template.HTML(maps.String()),
+		Chart:   template.HTML(report.chart()),
+		Summary: template.HTML(report.summary()),
+	}
+	if err := page.Execute(w, data); err != nil {
+		return fmt.Errorf("Error Writing the report, Error: %s", err.Error())
+	}
+	return nil
+}
+
+/*
+	chart draws the cities standing and the aliens alive after every round.
+*/
+func (report *Report) chart() string {
+	cities := []int{len(report.Before.World)}
+	aliens := []int{len(report.Before.AlienCityMapping)}
+	if report.Before.Round == 0 {
+		// the aliens have not landed yet, count the ones about to
+		aliens[0] = len(report.Paths)
+	}
+	for _, round := range report.Rounds {
+		cities = append(cities, round.Cities)
+		aliens = append(aliens, round.Aliens)
+	}
+	top := 1
+	for idx := range cities {
+		top = maxInt(top, maxInt(cities[idx], aliens[idx]))
+	}
+
+	plotWidth, plotHeight := chartWidth-2*chartMargin, chartHeight-2*chartMargin
+	polyline := func(values []int) string {
+		points := make([]string, 0, len(values))
+		for idx, value := range values {
+			x := chartMargin
+			if len(values) > 1 {
+				x += idx * plotWidth / (len(values) - 1)
+			}
+			y := chartMargin + plotHeight - value*plotHeight/top
+			points = append(points, fmt.Sprintf("%d,%d", x, y))
+		}
+		return strings.Join(points, " ")
+	}
+
+	var svg strings.Builder
+	svg.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="12">`+"\n", chartWidth, chartHeight))
+	svg.WriteString(fmt.Sprintf(`<text x="%d" y="20" font-weight="bold">Cities standing and aliens alive per round</text>`+"\n", chartMargin))
+	svg.WriteString(fmt.Sprintf(`<path d="M%d %d V%d H%d" fill="none" stroke="#999"/>`+"\n", chartMargin, chartMargin, chartMargin+plotHeight, chartMargin+plotWidth))
+	svg.WriteString(fmt.Sprintf(`<text x="%d" y="%d" text-anchor="end">%d</text>`+"\n", chartMargin-4, chartMargin+4, top))
+	svg.WriteString(fmt.Sprintf(`<text x="%d" y="%d" text-anchor="end">0</text>`+"\n", chartMargin-4, chartMargin+plotHeight+4))
+	svg.WriteString(fmt.Sprintf(`<text x="%d" y="%d" text-anchor="end">round %d</text>`+"\n", chartMargin+plotWidth, chartMargin+plotHeight+18, report.Before.Round+len(report.Rounds)))
+	svg.WriteString(fmt.Sprintf(`<polyline points="%s" fill="none" stroke="steelblue" stroke-width="2"><title>cities</title></polyline>`+"\n", polyline(cities)))
+	svg.WriteString(fmt.Sprintf(`<polyline points="%s" fill="none" stroke="crimson" stroke-width="2"><title>aliens</title></polyline>`+"\n", polyline(aliens)))
+	svg.WriteString(fmt.Sprintf(`<text x="%d" y="%d" fill="steelblue">cities</text><text x="%d" y="%d" fill="crimson">aliens</text>`+"\n",
+		chartMargin+plotWidth-90, chartMargin-8, chartMargin+plotWidth-40, chartMargin-8))
+	svg.WriteString("</svg>\n")
+	return svg.String()
+}
+
+/*
+	summary draws how many cities and aliens were lost as stacked bars.
+*/
+func (report *Report) summary() string {
+	cities := len(report.Before.World)
+	aliens := len(report.Paths)
+	bars := []struct {
+		label                string
+		lost, kept           int
+		lostLabel, keptLabel string
+	}{
+		{"Cities", cities - len(report.After.World), len(report.After.World), "destroyed", "standing"},
+		{"Aliens", aliens - len(report.After.AlienCityMapping), len(report.After.AlienCityMapping), "dead", "alive"},
+	}
+
+	const barHeight, labelWidth = 24, 70
+	plotWidth := chartWidth - labelWidth - chartMargin
+	var svg strings.Builder
+	svg.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="12">`+"\n", chartWidth, len(bars)*(barHeight+12)+8))
+	for idx, bar := range bars {
+		y := 8 + idx*(barHeight+12)
+		total := maxInt(bar.lost+bar.kept, 1)
+		lostWidth := bar.lost * plotWidth / total
+		svg.WriteString(fmt.Sprintf(`<text x="0" y="%d">%s</text>`+"\n", y+barHeight-8, bar.label))
+		svg.WriteString(fmt.Sprintf(`<rect x="%d" y="%d" width="%d" height="%d" fill="#bbb"><title>%d %s</title></rect>`+"\n",
+			labelWidth, y, lostWidth, barHeight, bar.lost, bar.lostLabel))
+		svg.WriteString(fmt.Sprintf(`<rect x="%d" y="%d" width="%d" height="%d" fill="steelblue"><title>%d %s</title></rect>`+"\n",
+			labelWidth+lostWidth, y, plotWidth-lostWidth, barHeight, bar.kept, bar.keptLabel))
+		svg.WriteString(fmt.Sprintf(`<text x="%d" y="%d">%s</text>`+"\n", labelWidth+4, y+barHeight-8,
+			html.EscapeString(fmt.Sprintf("%d %s, %d %s", bar.lost, bar.lostLabel, bar.kept, bar.keptLabel))))
+	}
+	svg.WriteString("</svg>\n")
+	return svg.String()
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
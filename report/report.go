@@ -0,0 +1,250 @@
+package report
+
+import (
+	"sort"
+
+	"github.com/rvsingh011/alien-invasion/simulation"
+)
+
+/*
+	Report collects what happens during a run of the simulation, to be written as a self-contained HTML page.
+*/
+type Report struct {
+	Seed            int64
+	Seeded          bool
+	Iterations      int
+	NumberOfAliens  int
+	AlienNames      string
+	WorldFile       string
+	ScheduledEvents []simulation.ScheduledEvent
+
+	// the world when the report started and when the run ended
+	Before *simulation.WorldSnapshot
+	After  *simulation.WorldSnapshot
+
+	// one entry per round of attack, in order
+	Rounds []Round
+
+	// path of every alien, in the order the aliens joined the invasion
+	Paths []*AlienPath
+
+	// Remains is what is left of the world in the world file format, as concluded at the end of the run
+	Remains string
+
+	// EndReason tells why the run ended, see Simulation.EndReason
+	EndReason string
+
+	// paths and living aliens by alien ID
+	paths map[int]*AlienPath
+	alive map[int]bool
+}
+
+/*
+	Round holds what happened during a round of attack.
+*/
+type Round struct {
+	Round     int
+	Fights    []Fight
+	Dead      []string
+	Destroyed []string
+	Scheduled []string
+	Cities    int
+	Aliens    int
+}
+
+/*
+	Fight is a city destroyed by the aliens fighting in it.
+*/
+type Fight struct {
+	City   string
+	Aliens []string
+}
+
+/*
+	AlienPath is the list of cities an alien went through and how it ended.
+*/
+type AlienPath struct {
+	Alien string
+	Steps []Step
+	// round the alien died in, 0 if it survived
+	DiedIn int
+	// Fate describes how the alien ended
+	Fate string
+}
+
+/*
+	Step is a city an alien entered during a round.
+*/
+type Step struct {
+	Round int
+	City  string
+}
+
+/*
+	Collect starts collecting the report of the simulation, Finish must be called once the simulation ended.
+*/
+func Collect(sim *simulation.Simulation) *Report {
+	report := &Report{
+		Iterations:      sim.Iterations,
+		NumberOfAliens:  sim.NumberOfAliens,
+		AlienNames:      sim.AlienNames,
+		WorldFile:       sim.WorldFile,
+		ScheduledEvents: append([]simulation.ScheduledEvent(nil), sim.ScheduledEvents...),
+		Before:          sim.SnapshotWorld(),
+		paths:           make(map[int]*AlienPath),
+		alive:           make(map[int]bool),
+	}
+	if sim.RandSource != nil {
+		report.Seed, _ = sim.RandSource.State()
+		report.Seeded = true
+	}
+	for _, alien := range sim.Aliens {
+		path := report.path(alien.ID, alien.String())
+		report.alive[alien.ID] = true
+		if city, ok := sim.AlienCityMapping[alien.ID]; ok {
+			path.Steps = append(path.Steps, Step{Round: sim.Round, City: city})
+		}
+	}
+
+	round := Round{}
+	sim.OnEvent(func(event simulation.Event) {
+		switch event.Kind {
+		case simulation.EventArrive, simulation.EventLand:
+			report.alive[event.AlienID] = true
+			path := report.path(event.AlienID, event.Alien)
+			path.Steps = append(path.Steps, Step{Round: event.Round, City: event.City})
+		case simulation.EventMove:
+			path := report.path(event.AlienID, event.Alien)
+			path.Steps = append(path.Steps, Step{Round: event.Round, City: event.To})
+		case simulation.EventFight:
+			round.Fights = append(round.Fights, Fight{City: event.City, Aliens: event.Aliens})
+			for idx, alien := range event.AlienIDs {
+				path := report.path(alien, event.Aliens[idx])
+				path.Fate = "destroyed " + event.City + " fighting " + others(event.Aliens, idx)
+			}
+		case simulation.EventScheduled:
+			round.Scheduled = append(round.Scheduled, event.Scheduled.String())
+		}
+	})
+	standing := cityNames(sim)
+	sim.OnRound(func(sim *simulation.Simulation) {
+		round.Round = sim.Round
+		for alien := range report.alive {
+			if _, ok := sim.AlienCityMapping[alien]; ok {
+				continue
+			}
+			delete(report.alive, alien)
+			path := report.paths[alien]
+			round.Dead = append(round.Dead, path.Alien)
+			path.DiedIn = sim.Round
+			if path.Fate == "" && len(path.Steps) > 0 {
+				path.Fate = "killed when " + path.Steps[len(path.Steps)-1].City + " was destroyed"
+			}
+		}
+		sort.Strings(round.Dead)
+		now := cityNames(sim)
+		for city := range standing {
+			if !now[city] {
+				round.Destroyed = append(round.Destroyed, city)
+			}
+		}
+		sort.Strings(round.Destroyed)
+		standing = now
+		round.Cities = len(sim.World)
+		round.Aliens = len(sim.AlienCityMapping)
+		report.Rounds = append(report.Rounds, round)
+		round = Round{}
+	})
+	return report
+}
+
+/*
+	Finish records the end of the simulation, remains is what EndAndConclude returned.
+*/
+func (report *Report) Finish(sim *simulation.Simulation, remains string) {
+	report.After = sim.SnapshotWorld()
+	report.Remains = remains
+	report.EndReason = sim.EndReason()
+	for _, path := range report.Paths {
+		if path.DiedIn > 0 {
+			continue
+		}
+		switch {
+		case len(path.Steps) == 0:
+			path.Fate = "never landed"
+		default:
+			path.Fate = "survived in " + path.Steps[len(path.Steps)-1].City
+		}
+	}
+}
+
+/*
+	path returns the path of an alien, creating it when the alien joins the invasion.
+*/
+func (report *Report) path(alien int, name string) *AlienPath {
+	path, ok := report.paths[alien]
+	if !ok {
+		path = &AlienPath{Alien: name}
+		report.paths[alien] = path
+		report.Paths = append(report.Paths, path)
+	}
+	return path
+}
+
+/*
+	ActiveRounds returns the rounds during which cities were destroyed, aliens died or events were injected.
+*/
+func (report *Report) ActiveRounds() []Round {
+	active := make([]Round, 0)
+	for _, round := range report.Rounds {
+		if len(round.Destroyed) > 0 || len(round.Dead) > 0 || len(round.Scheduled) > 0 {
+			active = append(active, round)
+		}
+	}
+	return active
+}
+
+/*
+	Razed returns the cities destroyed during the round without a fight, e.g. by a scheduled event.
+*/
+func (round Round) Razed() []string {
+	razed := make([]string, 0)
+	for _, city := range round.Destroyed {
+		fought := false
+		for _, fight := range round.Fights {
+			fought = fought || fight.City == city
+		}
+		if !fought {
+			razed = append(razed, city)
+		}
+	}
+	return razed
+}
+
+/*
+	cityNames returns the set of cities still standing.
+*/
+func cityNames(sim *simulation.Simulation) map[string]bool {
+	names := make(map[string]bool, len(sim.World))
+	for city := range sim.World {
+		names[city] = true
+	}
+	return names
+}
+
+/*
+	others lists the aliens of a fight except the one at the given index.
+*/
+func others(aliens []string, alien int) string {
+	list := ""
+	for idx, other := range aliens {
+		if idx == alien {
+			continue
+		}
+		if list != "" {
+			list += ", "
+		}
+		list += other
+	}
+	return list
+}
@@ -0,0 +1,100 @@
+package report
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/rvsingh011/alien-invasion/simulation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReport(t *testing.T) {
+	tests := []struct {
+		name         string
+		world        string
+		aliens       string
+		events       []simulation.ScheduledEvent
+		wantRounds   int
+		wantFates    map[string]string
+		wantActive   []Round
+		wantContains []string
+	}{
+		{
+			name:       "Aliens fight on arrival",
+			world:      "Foo",
+			aliens:     "A\nB",
+			wantRounds: 1,
+			wantFates:  map[string]string{"A": "destroyed Foo fighting B", "B": "destroyed Foo fighting A"},
+			wantActive: []Round{{Round: 1, Fights: []Fight{{City: "Foo", Aliens: []string{"A", "B"}}}, Dead: []string{"A", "B"}, Destroyed: []string{"Foo"}}},
+			wantContains: []string{
+				"<td>Foo by A, B<br></td><td>A, B</td>", "<td>A</td><td>Foo (1)</td><td>destroyed Foo fighting B in round 1</td>",
+				"1 destroyed, 0 standing", "2 dead, 0 alive",
+			},
+		},
+		{
+			name:       "Alien survives",
+			world:      "Foo",
+			aliens:     "A",
+			wantRounds: 1,
+			wantFates:  map[string]string{"A": "survived in Foo"},
+			wantActive: []Round{},
+			wantContains: []string{
+				"Nothing was destroyed.", "<td>A</td><td>Foo (1)</td><td>survived in Foo</td>", "0 destroyed, 1 standing", "<pre>Foo\n</pre>",
+				"<th>Ended because</th><td>stalemate, no two aliens left can ever meet</td>",
+			},
+		},
+		{
+			name:   "Aliens killed by a scheduled event",
+			world:  "Foo east=Bar",
+			aliens: "A",
+			events: []simulation.ScheduledEvent{
+				{Round: 2, Action: simulation.ActionDestroyCity, City: "Foo"},
+				{Round: 2, Action: simulation.ActionDestroyCity, City: "Bar"},
+			},
+			wantRounds: 2,
+			wantActive: []Round{{Round: 2, Dead: []string{"A"}, Destroyed: []string{"Bar", "Foo"},
+				Scheduled: []string{"round 2: destroy city Foo", "round 2: destroy city Bar"}}},
+			wantContains: []string{"<td>Bar, Foo</td><td>A</td><td>round 2: destroy city Foo; round 2: destroy city Bar</td>", "was destroyed in round 2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sim, err := simulation.NewSimulation(3, strings.Count(tt.aliens, "\n")+1, "", "", nil, nil)
+			assert.NoError(t, err)
+			sim.Output = io.Discard
+			sim.SetSeed(7)
+			assert.NoError(t, sim.LoadWorld(strings.NewReader(tt.world)))
+			assert.NoError(t, sim.LoadAliens(strings.NewReader(tt.aliens)))
+			for _, event := range tt.events {
+				assert.NoError(t, sim.Schedule(event))
+			}
+
+			report := Collect(sim)
+			assert.NoError(t, sim.Start())
+			report.Finish(sim, sim.EndAndConclude())
+
+			assert.Len(t, report.Rounds, tt.wantRounds)
+			for alien, fate := range tt.wantFates {
+				for _, path := range report.Paths {
+					if path.Alien == alien {
+						assert.Equal(t, fate, path.Fate)
+					}
+				}
+			}
+			active := report.ActiveRounds()
+			for idx := range active {
+				active[idx].Cities, active[idx].Aliens = 0, 0
+			}
+			assert.Equal(t, tt.wantActive, active)
+
+			var page bytes.Buffer
+			assert.NoError(t, report.WriteHTML(&page))
+			assert.Contains(t, page.String(), "<svg")
+			for _, want := range tt.wantContains {
+				assert.Contains(t, page.String(), want)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package route
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/rvsingh011/alien-invasion/simulation"
+)
+
+/*
+	Route is a shortest safe path from the city evacuated to a destination, both included.
+	Length is the number of roads taken, Risk the number of cities of the path an alien can reach as soon as the evacuees
+	or before them, when everybody takes one road per round.
+*/
+type Route struct {
+	Destination string   `json:"destination"`
+	Path        []string `json:"path"`
+	Length      int      `json:"length"`
+	Risk        int      `json:"risk"`
+}
+
+/*
+	Plan holds the routes from a city to every destination it can safely reach, the safest first, the destinations which
+	cannot be reached without going through a danger zone and the destinations which are not standing anymore.
+*/
+type Plan struct {
+	From        string   `json:"from"`
+	Routes      []Route  `json:"routes"`
+	Unreachable []string `json:"unreachable"`
+	Destroyed   []string `json:"destroyed"`
+}
+
+/*
+	Find plans the evacuation of a city to safe destinations on the current state of an attack, e.g. Simulation.World and
+	Simulation.AlienCityMapping or a WorldSnapshot.
+	Roads are taken in the direction they are listed, like the aliens do. The cities occupied by aliens and the cities an
+	alien can move to in the next round are the danger zone, routes never go through it, only the city evacuated may lie
+	in it. Among the shortest routes to a destination the one with the lowest risk is taken, routes are ranked by risk,
+	then length, then destination. Destinations which are not in the world are listed as destroyed.
+*/
+func Find(world map[string][]*simulation.City, aliens map[int]string, from string, to []string) (*Plan, error) {
+	if _, ok := world[from]; !ok {
+		return nil, fmt.Errorf("There is no city %s left", from)
+	}
+
+	reach := alienDistances(world, aliens)
+	danger := func(city string) bool {
+		hops, ok := reach[city]
+		return ok && hops <= 1
+	}
+	// a city of the path is at risk when an alien can get there in the round the evacuees do, or earlier
+	atRisk := func(city string, round int) int {
+		if hops, ok := reach[city]; ok && hops <= round {
+			return 1
+		}
+		return 0
+	}
+
+	// breadth first search from the city evacuated, keeping the safest of the shortest ways to every city
+	distance := map[string]int{from: 0}
+	risk := map[string]int{from: 0}
+	previous := make(map[string]string)
+	layer := []string{from}
+	for round := 1; len(layer) > 0; round++ {
+		next := make([]string, 0)
+		for _, city := range layer {
+			for _, link := range world[city] {
+				target := link.Name
+				if _, standing := world[target]; !standing || danger(target) {
+					continue
+				}
+				known, seen := distance[target]
+				if seen && known < round {
+					continue
+				}
+				candidate := risk[city] + atRisk(target, round)
+				if !seen {
+					distance[target] = round
+					next = append(next, target)
+				} else if candidate > risk[target] || (candidate == risk[target] && city >= previous[target]) {
+					continue
+				}
+				risk[target] = candidate
+				previous[target] = city
+			}
+		}
+		// the next layer is searched in name order, the routes do not depend on the order of the roads
+		sort.Strings(next)
+		layer = next
+	}
+
+	plan := &Plan{From: from, Routes: make([]Route, 0, len(to)), Unreachable: make([]string, 0), Destroyed: make([]string, 0)}
+	seen := make(map[string]bool, len(to))
+	for _, destination := range to {
+		if seen[destination] {
+			continue
+		}
+		seen[destination] = true
+		if _, ok := world[destination]; !ok {
+			plan.Destroyed = append(plan.Destroyed, destination)
+			continue
+		}
+		if _, ok := distance[destination]; !ok {
+			plan.Unreachable = append(plan.Unreachable, destination)
+			continue
+		}
+		path := []string{destination}
+		for city := destination; city != from; {
+			city = previous[city]
+			path = append(path, city)
+		}
+		for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+			path[i], path[j] = path[j], path[i]
+		}
+		plan.Routes = append(plan.Routes, Route{Destination: destination, Path: path, Length: distance[destination], Risk: risk[destination]})
+	}
+	sort.Slice(plan.Routes, func(i, j int) bool {
+		if plan.Routes[i].Risk != plan.Routes[j].Risk {
+			return plan.Routes[i].Risk < plan.Routes[j].Risk
+		}
+		if plan.Routes[i].Length != plan.Routes[j].Length {
+			return plan.Routes[i].Length < plan.Routes[j].Length
+		}
+		return plan.Routes[i].Destination < plan.Routes[j].Destination
+	})
+	sort.Strings(plan.Unreachable)
+	sort.Strings(plan.Destroyed)
+	return plan, nil
+}
+
+/*
+	alienDistances returns the least number of rounds any alien needs to get to every city it can reach.
+*/
+func alienDistances(world map[string][]*simulation.City, aliens map[int]string) map[string]int {
+	reach := make(map[string]int)
+	queue := make([]string, 0, len(aliens))
+	for _, city := range aliens {
+		if _, ok := reach[city]; !ok {
+			reach[city] = 0
+			queue = append(queue, city)
+		}
+	}
+	for idx := 0; idx < len(queue); idx++ {
+		city := queue[idx]
+		for _, link := range world[city] {
+			if _, ok := reach[link.Name]; ok {
+				continue
+			}
+			reach[link.Name] = reach[city] + 1
+			queue = append(queue, link.Name)
+		}
+	}
+	return reach
+}
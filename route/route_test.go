@@ -0,0 +1,138 @@
+package route
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/rvsingh011/alien-invasion/generator"
+	"github.com/rvsingh011/alien-invasion/internal/worldtest"
+	"github.com/rvsingh011/alien-invasion/simulation"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// a 3x3 grid, every road listed both ways
+const grid = "A east=B south=D\nB west=A east=C south=E\nC west=B south=F\n" +
+	"D north=A east=E south=G\nE north=B west=D east=F south=H\nF north=C west=E south=I\n" +
+	"G north=D east=H\nH north=E west=G east=I\nI north=F west=H"
+
+func TestFind(t *testing.T) {
+	tests := []struct {
+		name    string
+		world   string
+		aliens  map[int]string
+		from    string
+		to      []string
+		want    *Plan
+		wantErr string
+	}{
+		{
+			name:  "No aliens, shortest routes",
+			world: grid,
+			from:  "A",
+			to:    []string{"I", "C"},
+			want: &Plan{From: "A", Unreachable: []string{}, Destroyed: []string{}, Routes: []Route{
+				{Destination: "C", Path: []string{"A", "B", "C"}, Length: 2},
+				{Destination: "I", Path: []string{"A", "B", "C", "F", "I"}, Length: 4},
+			}},
+		},
+		{
+			// E and its neighbours are the danger zone, the only way left goes around it
+			name:   "Around an alien",
+			world:  grid,
+			aliens: map[int]string{1: "E"},
+			from:   "A",
+			to:     []string{"C", "G"},
+			want:   &Plan{From: "A", Unreachable: []string{"C", "G"}, Destroyed: []string{}, Routes: []Route{}},
+		},
+		{
+			// the alien in I reaches F and H in a round and C and G in two, the evacuees would arrive there in round 2
+			name:   "Ranked by risk",
+			world:  grid,
+			aliens: map[int]string{1: "I"},
+			from:   "E",
+			to:     []string{"A", "C"},
+			want: &Plan{From: "E", Unreachable: []string{}, Destroyed: []string{}, Routes: []Route{
+				{Destination: "A", Path: []string{"E", "B", "A"}, Length: 2, Risk: 0},
+				{Destination: "C", Path: []string{"E", "B", "C"}, Length: 2, Risk: 1},
+			}},
+		},
+		{
+			// Bar lists no road back to Foo, nobody can get out of Bar
+			name:   "One way roads",
+			world:  "Bar\nFoo east=Bar",
+			aliens: map[int]string{},
+			from:   "Bar",
+			to:     []string{"Foo", "Bar"},
+			want: &Plan{From: "Bar", Unreachable: []string{"Foo"}, Destroyed: []string{}, Routes: []Route{
+				{Destination: "Bar", Path: []string{"Bar"}},
+			}},
+		},
+		{name: "Unknown city", world: grid, from: "Foo", to: []string{"A"}, wantErr: "There is no city Foo left"},
+		{
+			name:  "Destroyed destination",
+			world: grid,
+			from:  "A",
+			to:    []string{"Foo", "B"},
+			want: &Plan{From: "A", Unreachable: []string{}, Destroyed: []string{"Foo"}, Routes: []Route{
+				{Destination: "B", Path: []string{"A", "B"}, Length: 1},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := Find(worldtest.Load(t, tt.world).World, tt.aliens, tt.from, tt.to)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, plan)
+		})
+	}
+}
+
+func TestFind_avoidsDanger(t *testing.T) {
+	worldMap := worldtest.Generated(t, generator.Options{Topology: generator.TopologyGrid, Width: 12, Height: 12, Density: 0.9, Seed: 5})
+	sim, err := simulation.NewSimulation(100, 10, "", "", nil, zap.NewNop())
+	assert.NoError(t, err)
+	sim.Output = io.Discard
+	sim.SetSeed(5)
+	assert.NoError(t, sim.LoadWorld(strings.NewReader(worldMap)))
+	assert.NoError(t, sim.CreateAliens())
+
+	destinations := make([]string, 0, len(sim.Cities))
+	for _, city := range sim.Cities {
+		destinations = append(destinations, city.Name)
+	}
+	for round := 0; round < 5 && !sim.Ended(); round++ {
+		sim.Step()
+		danger := make(map[string]bool)
+		for _, city := range sim.AlienCityMapping {
+			danger[city] = true
+			for _, link := range sim.World[city] {
+				danger[link.Name] = true
+			}
+		}
+		from := sim.Cities[0].Name
+		plan, err := Find(sim.World, sim.AlienCityMapping, from, destinations)
+		assert.NoError(t, err)
+		assert.Equal(t, len(destinations), len(plan.Routes)+len(plan.Unreachable)+len(plan.Destroyed))
+		assert.Equal(t, len(destinations)-len(sim.Cities), len(plan.Destroyed))
+		for _, route := range plan.Routes {
+			for idx, city := range route.Path[1:] {
+				assert.False(t, danger[city], city)
+				assert.Contains(t, linkNames(sim.World[route.Path[idx]]), city)
+			}
+		}
+	}
+}
+
+func linkNames(links []*simulation.City) []string {
+	names := make([]string, 0, len(links))
+	for _, link := range links {
+		names = append(names, link.Name)
+	}
+	return names
+}
@@ -0,0 +1,256 @@
+package scenario
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rvsingh011/alien-invasion/graphio"
+	"github.com/rvsingh011/alien-invasion/simulation"
+	"go.uber.org/zap"
+)
+
+const (
+	// MovementRandom moves every alien uniformly to one of its neighbour cities or keeps it in place
+	MovementRandom = "random"
+)
+
+/*
+	Scenario wires a simulation together from a single JSON file.
+	The world and the roster can either reference a file (relative to the scenario file) or be embedded.
+*/
+type Scenario struct {
+	Name       string `json:"name"`
+	Iterations int    `json:"iterations"`
+	Aliens     int    `json:"aliens"`
+	Seed       *int64 `json:"seed,omitempty"`
+
+	// World is a path to a world file, WorldMap embeds the lines of a world file
+	World    string   `json:"world,omitempty"`
+	WorldMap []string `json:"worldMap,omitempty"`
+
+	// Names is a path to an alien names file, Roster embeds the names, missing names are generated
+	Names  string   `json:"names,omitempty"`
+	Roster []string `json:"roster,omitempty"`
+	// SampleNames takes names at random from the file or the roster instead of the first ones
+	SampleNames bool `json:"sampleNames,omitempty"`
+
+	Rules  Rules                       `json:"rules"`
+	Events []simulation.ScheduledEvent `json:"events,omitempty"`
+	Expect Expectations                `json:"expect"`
+
+	// directory of the scenario file, used to resolve relative paths
+	dir string
+}
+
+/*
+	Rules selects the rules the aliens follow during the invasion.
+*/
+type Rules struct {
+	Movement string `json:"movement,omitempty"`
+}
+
+/*
+	Expectations are the outcomes the scenario is expected to produce, unset fields are not checked.
+*/
+type Expectations struct {
+	Destroyed      []string `json:"destroyed,omitempty"`
+	Survived       []string `json:"survived,omitempty"`
+	AliensAlive    *int     `json:"aliensAlive,omitempty"`
+	CitiesSurvived *int     `json:"citiesSurvived,omitempty"`
+}
+
+/*
+	Result holds the outcome of a scenario run.
+*/
+type Result struct {
+	Seed      int64
+	Destroyed []string
+	Failures  []string
+}
+
+/*
+	Passed reports whether all the expectations of the scenario were met.
+*/
+func (result *Result) Passed() bool {
+	return len(result.Failures) == 0
+}
+
+/*
+	Load reads and validates a scenario file, scenarios are written in JSON, YAML is not supported.
+*/
+func Load(path string) (*Scenario, error) {
+	if extension := strings.ToLower(filepath.Ext(path)); extension == ".yaml" || extension == ".yml" {
+		return nil, fmt.Errorf("YAML scenarios are not supported, write the scenario file %s in JSON", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error Reading the scenario file : %s, Error: %s", path, err.Error())
+	}
+	var scenario Scenario
+	if err := json.Unmarshal(data, &scenario); err != nil {
+		return nil, fmt.Errorf("Error Parsing the scenario file : %s, Error: %s", path, err.Error())
+	}
+	scenario.dir = filepath.Dir(path)
+	if err := scenario.Validate(); err != nil {
+		return nil, err
+	}
+	return &scenario, nil
+}
+
+/*
+	Validate checks that the scenario can be run.
+*/
+func (scenario *Scenario) Validate() error {
+	if scenario.Iterations < 0 {
+		return fmt.Errorf("Number of iterations cannot be negative")
+	}
+	if scenario.Aliens < 0 {
+		return fmt.Errorf("Number of aliens cannot be negative")
+	}
+	if (scenario.World == "") == (len(scenario.WorldMap) == 0) {
+		return fmt.Errorf("Exactly one of world or worldMap must be given")
+	}
+	if scenario.Names != "" && len(scenario.Roster) > 0 {
+		return fmt.Errorf("Only one of names or roster can be given")
+	}
+	switch scenario.Rules.Movement {
+	case "", MovementRandom:
+	default:
+		return fmt.Errorf("Unsupported movement rule %q, supported rules: %s", scenario.Rules.Movement, MovementRandom)
+	}
+	for _, event := range scenario.Events {
+		if err := event.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+	NewSimulation builds the simulation described by the scenario, with its world, aliens and events loaded.
+*/
+func (scenario *Scenario) NewSimulation(logger *zap.Logger) (*simulation.Simulation, int64, error) {
+	seed := time.Now().UnixNano()
+	if scenario.Seed != nil {
+		seed = *scenario.Seed
+	}
+	sim, err := simulation.NewSimulation(scenario.Iterations, scenario.Aliens, scenario.path(scenario.Names), scenario.path(scenario.World), nil, logger)
+	if err != nil {
+		return nil, seed, err
+	}
+	sim.SetSeed(seed)
+	sim.SampleNames = scenario.SampleNames
+
+	if scenario.World != "" {
+		err = graphio.LoadWorld(sim)
+	} else {
+		err = sim.LoadWorld(strings.NewReader(strings.Join(scenario.WorldMap, "\n")))
+	}
+	if err != nil {
+		return nil, seed, err
+	}
+
+	switch {
+	case scenario.Names != "":
+		err = sim.CreateAliens()
+	case len(scenario.Roster) > 0:
+		err = sim.LoadAliens(strings.NewReader(strings.Join(scenario.Roster, "\n")))
+	default:
+		// aliens without a name are displayed as "alien <ID>"
+		for alien := 0; alien < scenario.Aliens; alien++ {
+			sim.AddAlien("")
+		}
+	}
+	if err != nil {
+		return nil, seed, err
+	}
+
+	for _, event := range scenario.Events {
+		for _, city := range []string{event.City, event.To} {
+			if _, ok := sim.World[city]; city != "" && !ok {
+				return nil, seed, fmt.Errorf("Event %q refers to the unknown city %s", event, city)
+			}
+		}
+		if err := sim.Schedule(event); err != nil {
+			return nil, seed, err
+		}
+	}
+	return sim, seed, nil
+}
+
+/*
+	Run executes the scenario and checks its expectations.
+*/
+func (scenario *Scenario) Run(logger *zap.Logger) (*Result, error) {
+	sim, seed, err := scenario.NewSimulation(logger)
+	if err != nil {
+		return nil, err
+	}
+	return scenario.Play(sim, seed)
+}
+
+/*
+	Play runs a simulation created by NewSimulation with the seed it returned, and checks the expectations of the scenario.
+*/
+func (scenario *Scenario) Play(sim *simulation.Simulation, seed int64) (*Result, error) {
+	initialCities := make([]string, 0, len(sim.Cities))
+	for _, city := range sim.Cities {
+		initialCities = append(initialCities, city.Name)
+	}
+
+	sim.ViewWorld()
+	sim.ViewAliens()
+	if err := sim.Start(); err != nil {
+		return nil, err
+	}
+	sim.EndAndConclude()
+
+	result := &Result{Seed: seed}
+	for _, city := range initialCities {
+		if _, ok := sim.World[city]; !ok {
+			result.Destroyed = append(result.Destroyed, city)
+		}
+	}
+	sort.Strings(result.Destroyed)
+	result.Failures = scenario.Expect.check(sim)
+	return result, nil
+}
+
+/*
+	check compares the final state of the simulation with the expectations, and returns the unmet ones.
+*/
+func (expect Expectations) check(sim *simulation.Simulation) []string {
+	failures := make([]string, 0)
+	for _, city := range expect.Destroyed {
+		if _, ok := sim.World[city]; ok {
+			failures = append(failures, fmt.Sprintf("expected %s to be destroyed but it survived", city))
+		}
+	}
+	for _, city := range expect.Survived {
+		if _, ok := sim.World[city]; !ok {
+			failures = append(failures, fmt.Sprintf("expected %s to survive but it was destroyed", city))
+		}
+	}
+	if expect.AliensAlive != nil && *expect.AliensAlive != len(sim.Aliens) {
+		failures = append(failures, fmt.Sprintf("expected %d aliens alive but found %d", *expect.AliensAlive, len(sim.Aliens)))
+	}
+	if expect.CitiesSurvived != nil && *expect.CitiesSurvived != len(sim.World) {
+		failures = append(failures, fmt.Sprintf("expected %d cities to survive but found %d", *expect.CitiesSurvived, len(sim.World)))
+	}
+	return failures
+}
+
+/*
+	path resolves a file referenced by the scenario relative to the scenario file.
+*/
+func (scenario *Scenario) path(file string) string {
+	if file == "" || filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(scenario.dir, file)
+}
@@ -0,0 +1,111 @@
+package scenario
+
+import (
+	"testing"
+
+	"github.com/rvsingh011/alien-invasion/simulation"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func intPointer(value int) *int {
+	return &value
+}
+
+func TestScenario_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		scenario Scenario
+		wantErr  bool
+	}{
+		{
+			name:     "Embedded world",
+			scenario: Scenario{Iterations: 10, Aliens: 2, WorldMap: []string{"Foo north=Bar"}},
+		},
+		{
+			name:     "No world",
+			scenario: Scenario{Iterations: 10, Aliens: 2},
+			wantErr:  true,
+		},
+		{
+			name:     "Both world file and embedded world",
+			scenario: Scenario{Iterations: 10, Aliens: 2, World: "world.txt", WorldMap: []string{"Foo north=Bar"}},
+			wantErr:  true,
+		},
+		{
+			name:     "Unknown movement rule",
+			scenario: Scenario{Iterations: 10, Aliens: 2, World: "world.txt", Rules: Rules{Movement: "teleport"}},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.scenario.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Scenario.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	scenario, err := Load("../data/scenario-example-1.json")
+	assert.NoError(t, err)
+	assert.Equal(t, 4, scenario.Aliens)
+	assert.Equal(t, 2, len(scenario.Events))
+
+	sim, seed, err := scenario.NewSimulation(zap.NewNop())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(66), seed)
+	assert.Equal(t, 4, len(sim.Aliens))
+	assert.Equal(t, 2, len(sim.ScheduledEvents))
+
+	// the example meets its expectations
+	result, err := scenario.Run(zap.NewNop())
+	assert.NoError(t, err)
+	assert.Empty(t, result.Failures)
+}
+
+func TestLoad_YAML(t *testing.T) {
+	_, err := Load("invasion.yaml")
+	assert.EqualError(t, err, "YAML scenarios are not supported, write the scenario file invasion.yaml in JSON")
+}
+
+func TestScenario_Run(t *testing.T) {
+	seed := int64(3)
+	tests := []struct {
+		name       string
+		scenario   Scenario
+		wantPassed bool
+	}{
+		{
+			name: "Aliens landing together destroy the city",
+			scenario: Scenario{
+				Iterations: 1,
+				Aliens:     1,
+				Seed:       &seed,
+				WorldMap:   []string{"Foo north=Bar west=Baz"},
+				Events:     []simulation.ScheduledEvent{{Round: 1, Action: simulation.ActionLand, City: "Bar", Count: 2}},
+				Expect:     Expectations{Destroyed: []string{"Bar"}, CitiesSurvived: intPointer(2)},
+			},
+			wantPassed: true,
+		},
+		{
+			name: "Unmet expectations fail",
+			scenario: Scenario{
+				Iterations: 1,
+				Aliens:     1,
+				Seed:       &seed,
+				WorldMap:   []string{"Foo north=Bar west=Baz"},
+				Expect:     Expectations{Destroyed: []string{"Foo"}, CitiesSurvived: intPointer(1)},
+			},
+			wantPassed: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tt.scenario.Run(zap.NewNop())
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantPassed, result.Passed(), result.Failures)
+		})
+	}
+}
@@ -0,0 +1,706 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rvsingh011/alien-invasion/history"
+	"github.com/rvsingh011/alien-invasion/metrics"
+	"github.com/rvsingh011/alien-invasion/route"
+	"github.com/rvsingh011/alien-invasion/scenario"
+	"github.com/rvsingh011/alien-invasion/simulation"
+	"go.uber.org/zap"
+)
+
+const (
+	// StatusQueued the run waits for a free slot
+	StatusQueued = "queued"
+	// StatusRunning the run is being simulated
+	StatusRunning = "running"
+	// StatusFinished the attack is over
+	StatusFinished = "finished"
+	// StatusCancelled the run was cancelled before the attack was over
+	StatusCancelled = "cancelled"
+	// StatusFailed the run could not be simulated
+	StatusFailed = "failed"
+
+	// largest request body accepted when starting a run
+	maxBodySize = 10 << 20
+)
+
+/*
+	Limits bounds the memory held by the runs of a server.
+	Once over, at most Runs runs are kept, the oldest are forgotten first, runs kept in a history store can still be looked
+	up there. At most Events events are kept by run, the oldest are dropped first. Runs of more than Aliens aliens, landings
+	included, Iterations rounds or Cities cities are refused.
+*/
+type Limits struct {
+	Runs       int
+	Events     int
+	Aliens     int
+	Iterations int
+	Cities     int
+}
+
+// DefaultLimits are the limits of a new server
+var DefaultLimits = Limits{Runs: 1000, Events: 100000, Aliens: 100000, Iterations: 100000000, Cities: 100000}
+
+/*
+	Server runs simulations on demand over a REST API.
+
+	POST   /runs             start a run from a scenario with an embedded worldMap and roster, returns its id
+	GET    /runs             list the runs
+	GET    /runs/{id}        status of a run
+	GET    /runs/{id}/map    grid layout of the world of a run, to draw it
+	GET    /runs/{id}/world  what is left of the world once the run is over
+	GET    /runs/{id}/events event log of a run, from the index given by ?from=
+	GET    /runs/{id}/stream events and rounds of a run as they happen, as Server-Sent Events
+	GET    /runs/{id}/route  evacuation routes from the city ?from= to the cities ?to=, comma separated, after the last round
+	POST   /runs/{id}/pause  pause a run after the current round
+	POST   /runs/{id}/resume resume a paused run
+	POST   /runs/{id}/step   run the next ?n= rounds of a paused run, 1 by default
+	DELETE /runs/{id}        cancel a run
+
+	GET    /metrics          metrics of the runs in the Prometheus text exposition format
+	GET    /                 web front-end starting, watching and controlling runs
+
+	Every run has its own simulation, at most maxConcurrent runs are simulated at once and at most maxQueued wait for a slot.
+	The runs over and their events are kept within the Limits of the server.
+*/
+type Server struct {
+	logger     *zap.Logger
+	slots      chan struct{}
+	maxQueued  int
+	mu         sync.Mutex
+	runs       map[string]*run
+	pending    int
+	limits     Limits
+	ctx        context.Context
+	cancelAll  context.CancelFunc
+	inProgress sync.WaitGroup
+	metrics    *metrics.Metrics
+	// ids of the runs over still kept, the oldest first
+	over []string
+	// store the runs are kept in once over, not kept if nil
+	store *history.Store
+}
+
+/*
+	run is a simulation started through the API.
+*/
+type run struct {
+	id      string
+	created time.Time
+	cancel  context.CancelFunc
+	control *simulation.Controller
+	// the simulation is only read through control.Inspect
+	sim *simulation.Simulation
+
+	mu         sync.Mutex
+	status     string
+	seed       int64
+	iterations int
+	round      int
+	cities     int
+	aliens     int
+	err        string
+	world      string
+	layout     WorldLayout
+	events     []simulation.Event
+	// number of events dropped from the start of the event log
+	dropped int
+	// closed and replaced every time the run changes, to wake up its streams
+	changed chan struct{}
+}
+
+/*
+	WorldLayout places the cities of the world of a run on a grid.
+*/
+type WorldLayout struct {
+	Width     int         `json:"width"`
+	Height    int         `json:"height"`
+	Cities    []CityPoint `json:"cities"`
+	Roads     [][2]string `json:"roads"`
+	Conflicts []string    `json:"conflicts,omitempty"`
+}
+
+/*
+	CityPoint is a city at its place on the grid.
+*/
+type CityPoint struct {
+	Name string `json:"name"`
+	X    int    `json:"x"`
+	Y    int    `json:"y"`
+}
+
+/*
+	runRequest is the body of a request starting a run, a scenario with the controls of the run.
+*/
+type runRequest struct {
+	scenario.Scenario
+	// Paused starts the run paused, it waits to be resumed or stepped
+	Paused bool `json:"paused"`
+	// Delay throttles the run, in milliseconds between two rounds
+	Delay int `json:"delay"`
+}
+
+/*
+	RunStatus is the state of a run returned by the API.
+*/
+type RunStatus struct {
+	ID          string    `json:"id"`
+	Status      string    `json:"status"`
+	Created     time.Time `json:"created"`
+	Seed        int64     `json:"seed"`
+	Iterations  int       `json:"iterations"`
+	Round       int       `json:"round"`
+	CitiesLeft  int       `json:"citiesLeft"`
+	AliensAlive int       `json:"aliensAlive"`
+	Paused      bool      `json:"paused"`
+	Events      int       `json:"events"`
+	FirstEvent  int       `json:"firstEvent"`
+	Error       string    `json:"error,omitempty"`
+}
+
+/*
+	New returns a server simulating at most maxConcurrent runs at once, with at most maxQueued runs waiting.
+*/
+func New(maxConcurrent, maxQueued int, logger *zap.Logger) *Server {
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Server{
+		logger:    logger,
+		slots:     make(chan struct{}, maxConcurrent),
+		maxQueued: maxQueued,
+		runs:      make(map[string]*run),
+		limits:    DefaultLimits,
+		ctx:       ctx,
+		cancelAll: cancel,
+		metrics:   metrics.New(),
+	}
+}
+
+/*
+	KeepRunsIn keeps every run in the store once it is over.
+*/
+func (server *Server) KeepRunsIn(store *history.Store) {
+	server.store = store
+}
+
+/*
+	SetLimits changes the limits of the server, it must be called before the server runs.
+*/
+func (server *Server) SetLimits(limits Limits) {
+	server.limits = limits
+}
+
+/*
+	Close cancels every run and waits for them to stop.
+*/
+func (server *Server) Close() {
+	server.cancelAll()
+	server.inProgress.Wait()
+}
+
+/*
+	ServeHTTP routes the requests of the API.
+*/
+func (server *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/metrics" && r.Method == http.MethodGet {
+		server.metrics.ServeHTTP(w, r)
+		return
+	}
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if parts[0] != "runs" && serveUI(w, r) {
+		return
+	}
+	if parts[0] != "runs" || len(parts) > 3 {
+		writeError(w, http.StatusNotFound, fmt.Errorf("Unknown path %s", r.URL.Path))
+		return
+	}
+
+	if len(parts) == 1 {
+		switch r.Method {
+		case http.MethodPost:
+			server.start(w, r)
+		case http.MethodGet:
+			server.list(w)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("Method %s not allowed on %s", r.Method, r.URL.Path))
+		}
+		return
+	}
+
+	server.mu.Lock()
+	current, ok := server.runs[parts[1]]
+	server.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("There is no run %s", parts[1]))
+		return
+	}
+
+	resource := ""
+	if len(parts) == 3 {
+		resource = parts[2]
+	}
+	switch {
+	case resource == "" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, current.describe())
+	case resource == "" && r.Method == http.MethodDelete:
+		current.cancel()
+		writeJSON(w, http.StatusAccepted, current.describe())
+	case resource == "world" && r.Method == http.MethodGet:
+		server.world(w, current)
+	case resource == "map" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, current.layout)
+	case resource == "events" && r.Method == http.MethodGet:
+		server.events(w, r, current)
+	case resource == "stream" && r.Method == http.MethodGet:
+		server.stream(w, r, current)
+	case resource == "route" && r.Method == http.MethodGet:
+		server.route(w, r, current)
+	case (resource == "pause" || resource == "resume" || resource == "step") && r.Method == http.MethodPost:
+		server.controlRun(w, r, current, resource)
+	case !knownResources[resource]:
+		writeError(w, http.StatusNotFound, fmt.Errorf("Unknown path %s", r.URL.Path))
+	default:
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("Method %s not allowed on %s", r.Method, r.URL.Path))
+	}
+}
+
+// resources of a run
+var knownResources = map[string]bool{"": true, "map": true, "world": true, "events": true, "stream": true, "route": true, "pause": true, "resume": true, "step": true}
+
+/*
+	start validates the scenario of a new run and queues it.
+	A place in the queue is taken before the scenario is read, and given back if the run cannot start.
+*/
+func (server *Server) start(w http.ResponseWriter, r *http.Request) {
+	server.mu.Lock()
+	if server.pending >= server.maxQueued+cap(server.slots) {
+		server.mu.Unlock()
+		writeError(w, http.StatusServiceUnavailable, fmt.Errorf("Too many runs in progress, try again later"))
+		return
+	}
+	server.pending++
+	server.mu.Unlock()
+	queued := false
+	defer func() {
+		if !queued {
+			server.mu.Lock()
+			server.pending--
+			server.mu.Unlock()
+		}
+	}()
+
+	var body runRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err := decoder.Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("Error Parsing the run, Error: %s", err.Error()))
+		return
+	}
+	invasion := body.Scenario
+	// runs must not read the files of the server
+	if invasion.World != "" || invasion.Names != "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("The world and the roster must be embedded with worldMap and roster"))
+		return
+	}
+	if err := invasion.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := server.limits.check(invasion); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	sim, seed, err := invasion.NewSimulation(server.logger)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := server.limits.checkWorld(sim); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	sim.Output = io.Discard
+	if body.Delay < 0 {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("The delay between two rounds cannot be negative"))
+		return
+	}
+
+	ctx, cancel := context.WithCancel(server.ctx)
+	current := &run{
+		id:         newID(),
+		created:    time.Now(),
+		cancel:     cancel,
+		control:    simulation.NewController(body.Paused),
+		sim:        sim,
+		changed:    make(chan struct{}),
+		status:     StatusQueued,
+		seed:       seed,
+		iterations: sim.Iterations,
+		cities:     len(sim.World),
+		aliens:     len(sim.Aliens),
+		layout:     layoutOf(sim),
+	}
+	server.mu.Lock()
+	server.runs[current.id] = current
+	server.inProgress.Add(1)
+	server.mu.Unlock()
+	queued = true
+	server.metrics.RunQueued()
+
+	current.control.SetDelay(time.Duration(body.Delay) * time.Millisecond)
+	go server.simulate(ctx, current, sim)
+	writeJSON(w, http.StatusAccepted, current.describe())
+}
+
+/*
+	check refuses the scenarios beyond the limits, before anything is allocated for them.
+*/
+func (limits Limits) check(invasion scenario.Scenario) error {
+	aliens := invasion.Aliens
+	for _, event := range invasion.Events {
+		if event.Action == simulation.ActionLand {
+			aliens += event.Count
+		}
+	}
+	if limits.Aliens > 0 && aliens > limits.Aliens {
+		return fmt.Errorf("The run has %d aliens, at most %d are allowed", aliens, limits.Aliens)
+	}
+	if limits.Iterations > 0 && invasion.Iterations > limits.Iterations {
+		return fmt.Errorf("The run has %d iterations, at most %d are allowed", invasion.Iterations, limits.Iterations)
+	}
+	return nil
+}
+
+/*
+	checkWorld refuses the worlds with more cities than the limit, once parsed: a line of the world map can hold
+	several cities.
+*/
+func (limits Limits) checkWorld(sim *simulation.Simulation) error {
+	if limits.Cities > 0 && len(sim.World) > limits.Cities {
+		return fmt.Errorf("The world has %d cities, at most %d are allowed", len(sim.World), limits.Cities)
+	}
+	return nil
+}
+
+/*
+	simulate waits for a free slot and runs the attack until it is over or cancelled.
+*/
+func (server *Server) simulate(ctx context.Context, current *run, sim *simulation.Simulation) {
+	defer server.inProgress.Done()
+	defer func() {
+		server.mu.Lock()
+		server.pending--
+		server.forget(current.id)
+		server.mu.Unlock()
+	}()
+
+	select {
+	case server.slots <- struct{}{}:
+		defer func() { <-server.slots }()
+		server.metrics.RunDequeued()
+	case <-ctx.Done():
+		server.metrics.RunDequeued()
+		current.end(StatusCancelled, sim)
+		return
+	}
+
+	current.update(func() { current.status = StatusRunning })
+	server.metrics.RunStarted()
+	started := time.Now()
+	var entry *history.Entry
+	if server.store != nil {
+		entry = server.store.Track(sim, "server")
+	}
+	// a run must not bring the server down
+	defer func() {
+		if reason := recover(); reason != nil {
+			current.update(func() {
+				current.status = StatusFailed
+				current.err = fmt.Sprintf("%v", reason)
+			})
+			server.logger.Error("run failed", zap.String("id", current.id), zap.Any("reason", reason))
+		}
+		current.mu.Lock()
+		status := current.status
+		current.mu.Unlock()
+		server.metrics.RunFinished(status, time.Since(started))
+		if entry != nil {
+			if _, err := entry.Save(sim, status); err != nil {
+				server.logger.Error("unable to keep the run", zap.String("id", current.id), zap.Error(err))
+			}
+		}
+	}()
+	server.metrics.Track(sim)
+
+	sim.OnEvent(func(event simulation.Event) {
+		current.update(func() { current.record(event, server.limits.Events) })
+	})
+	sim.OnRound(func(sim *simulation.Simulation) {
+		current.update(func() {
+			current.round = sim.Round
+			current.cities = len(sim.World)
+			current.aliens = len(sim.Aliens)
+		})
+	})
+
+	if err := sim.RunControlled(ctx, current.control); err != nil {
+		current.end(StatusCancelled, sim)
+		return
+	}
+	current.end(StatusFinished, sim)
+	server.logger.Debug("run finished", zap.String("id", current.id), zap.Int("rounds", sim.Round))
+}
+
+/*
+	forget keeps the run over among the runs over, and forgets the oldest ones beyond the limit.
+	It must be called with the lock of the server held.
+*/
+func (server *Server) forget(id string) {
+	server.over = append(server.over, id)
+	for server.limits.Runs > 0 && len(server.over) > server.limits.Runs {
+		delete(server.runs, server.over[0])
+		server.over = server.over[1:]
+	}
+}
+
+/*
+	record adds an event to the event log of the run, and drops the oldest quarter of the events kept once there are more
+	than limit. It must be called with the lock of the run held.
+*/
+func (current *run) record(event simulation.Event, limit int) {
+	current.events = append(current.events, event)
+	if limit <= 0 || len(current.events) <= limit {
+		return
+	}
+	drop := len(current.events) - limit*3/4
+	current.events = append(make([]simulation.Event, 0, limit), current.events[drop:]...)
+	current.dropped += drop
+}
+
+/*
+	end records the final state of a run.
+*/
+func (current *run) end(status string, sim *simulation.Simulation) {
+	world := sim.WorldMap()
+	current.update(func() {
+		current.status = status
+		current.world = world
+		current.round = sim.Round
+		current.cities = len(sim.World)
+		current.aliens = len(sim.Aliens)
+	})
+}
+
+/*
+	layoutOf lays the world of a simulation out on a grid.
+*/
+func layoutOf(sim *simulation.Simulation) WorldLayout {
+	layout := sim.InferLayout()
+	world := WorldLayout{Width: layout.Width, Height: layout.Height, Cities: make([]CityPoint, 0, len(sim.Cities)), Roads: make([][2]string, 0)}
+	for _, city := range sim.Cities {
+		point := layout.Positions[city.Name]
+		world.Cities = append(world.Cities, CityPoint{Name: city.Name, X: point.X, Y: point.Y})
+	}
+	known := make(map[[2]string]bool)
+	for _, city := range sim.Cities {
+		for _, link := range sim.World[city.Name] {
+			road := [2]string{city.Name, link.Name}
+			if road[0] > road[1] {
+				road[0], road[1] = road[1], road[0]
+			}
+			if !known[road] {
+				known[road] = true
+				world.Roads = append(world.Roads, road)
+			}
+		}
+	}
+	for _, conflict := range layout.Conflicts {
+		world.Conflicts = append(world.Conflicts, conflict.Reason)
+	}
+	return world
+}
+
+/*
+	update changes the run and wakes up its streams.
+*/
+func (current *run) update(change func()) {
+	current.mu.Lock()
+	defer current.mu.Unlock()
+	change()
+	close(current.changed)
+	current.changed = make(chan struct{})
+}
+
+/*
+	over reports whether the run is over, it must be called with the lock held.
+*/
+func (current *run) over() bool {
+	return current.status != StatusQueued && current.status != StatusRunning
+}
+
+/*
+	describe returns the state of the run.
+*/
+func (current *run) describe() RunStatus {
+	current.mu.Lock()
+	defer current.mu.Unlock()
+	return RunStatus{
+		ID:          current.id,
+		Status:      current.status,
+		Created:     current.created,
+		Seed:        current.seed,
+		Iterations:  current.iterations,
+		Round:       current.round,
+		CitiesLeft:  current.cities,
+		AliensAlive: current.aliens,
+		Paused:      current.control.Paused(),
+		Events:      current.dropped + len(current.events),
+		FirstEvent:  current.dropped,
+		Error:       current.err,
+	}
+}
+
+/*
+	list writes the status of every run, oldest first.
+*/
+func (server *Server) list(w http.ResponseWriter) {
+	server.mu.Lock()
+	statuses := make([]RunStatus, 0, len(server.runs))
+	for _, current := range server.runs {
+		statuses = append(statuses, current.describe())
+	}
+	server.mu.Unlock()
+	sort.Slice(statuses, func(i, j int) bool {
+		if !statuses[i].Created.Equal(statuses[j].Created) {
+			return statuses[i].Created.Before(statuses[j].Created)
+		}
+		return statuses[i].ID < statuses[j].ID
+	})
+	writeJSON(w, http.StatusOK, statuses)
+}
+
+/*
+	world writes what is left of the world in the world file format, once the run is over.
+*/
+func (server *Server) world(w http.ResponseWriter, current *run) {
+	current.mu.Lock()
+	status, world, round, over := current.status, current.world, current.round, current.over()
+	current.mu.Unlock()
+	if !over {
+		writeError(w, http.StatusConflict, fmt.Errorf("The run %s is %s, the world is only available once it is over", current.id, status))
+		return
+	}
+	writeJSON(w, http.StatusOK, struct {
+		ID     string `json:"id"`
+		Status string `json:"status"`
+		Round  int    `json:"round"`
+		World  string `json:"world"`
+	}{current.id, status, round, world})
+}
+
+/*
+	events writes the event log of a run, starting at the index given by the from query parameter, or at the first
+	event kept if the events before were dropped.
+*/
+func (server *Server) events(w http.ResponseWriter, r *http.Request, current *run) {
+	from := 0
+	if value := r.URL.Query().Get("from"); value != "" {
+		var err error
+		if from, err = strconv.Atoi(value); err != nil || from < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("Invalid event index %s", value))
+			return
+		}
+	}
+	current.mu.Lock()
+	events := make([]simulation.Event, 0)
+	if from -= current.dropped; from < 0 {
+		from = 0
+	}
+	if from < len(current.events) {
+		events = append(events, current.events[from:]...)
+	}
+	current.mu.Unlock()
+	writeJSON(w, http.StatusOK, events)
+}
+
+/*
+	route plans the evacuation of a city on the state of a run after its last round, clients ask again every round.
+	The plan is made between two rounds of the run.
+*/
+func (server *Server) route(w http.ResponseWriter, r *http.Request, current *run) {
+	from := r.URL.Query().Get("from")
+	to := r.URL.Query().Get("to")
+	if from == "" || to == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("The city evacuated and the destinations are required, see from and to"))
+		return
+	}
+	known := make(map[string]bool, len(current.layout.Cities))
+	for _, city := range current.layout.Cities {
+		known[city.Name] = true
+	}
+	destinations := strings.Split(to, ",")
+	for _, city := range append([]string{from}, destinations...) {
+		if !known[city] {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("There is no city %s in the world", city))
+			return
+		}
+	}
+
+	var plan *route.Plan
+	var round int
+	var err error
+	current.control.Inspect(func() {
+		round = current.sim.Round
+		plan, err = route.Find(current.sim.World, current.sim.AlienCityMapping, from, destinations)
+	})
+	if err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, struct {
+		ID    string `json:"id"`
+		Round int    `json:"round"`
+		*route.Plan
+	}{current.id, round, plan})
+}
+
+/*
+	newID returns a random run id.
+*/
+func newID() string {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(id)
+}
+
+func writeJSON(w http.ResponseWriter, code int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, struct {
+		Error string `json:"error"`
+	}{err.Error()})
+}
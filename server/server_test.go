@@ -0,0 +1,310 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rvsingh011/alien-invasion/simulation"
+	"github.com/stretchr/testify/assert"
+)
+
+// a run which fights on arrival and ends after the first round
+const shortRun = `{"iterations": 100, "aliens": 2, "seed": 3, "worldMap": ["Foo"], "roster": ["A", "B"]}`
+
+// a run which never ends by itself, the only alien wanders on and on waiting for the landing of the last round
+const endlessRun = `{"iterations": 100000000, "aliens": 1, "seed": 3, "worldMap": ["Foo east=Bar"], "roster": ["A"],
+	"events": [{"round": 100000000, "action": "land", "city": "Foo", "count": 1}]}`
+
+func request(t *testing.T, method, url, body string, want int, into interface{}) {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	assert.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, want, resp.StatusCode)
+	if into != nil {
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(into))
+	}
+}
+
+func waitFor(t *testing.T, url, status string) RunStatus {
+	var current RunStatus
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		request(t, http.MethodGet, url, "", http.StatusOK, &current)
+		if current.Status == status {
+			return current
+		}
+	}
+	t.Fatalf("run %s is %s, expected %s", current.ID, current.Status, status)
+	return current
+}
+
+func TestServer_Run(t *testing.T) {
+	api := New(2, 2, nil)
+	defer api.Close()
+	ts := httptest.NewServer(api)
+	defer ts.Close()
+
+	var started RunStatus
+	request(t, http.MethodPost, ts.URL+"/runs", shortRun, http.StatusAccepted, &started)
+	assert.NotEmpty(t, started.ID)
+	assert.Equal(t, int64(3), started.Seed)
+
+	finished := waitFor(t, ts.URL+"/runs/"+started.ID, StatusFinished)
+	assert.Equal(t, 1, finished.Round)
+	assert.Equal(t, 0, finished.CitiesLeft)
+	assert.Equal(t, 0, finished.AliensAlive)
+
+	var world struct {
+		Round int    `json:"round"`
+		World string `json:"world"`
+	}
+	request(t, http.MethodGet, ts.URL+"/runs/"+started.ID+"/world", "", http.StatusOK, &world)
+	assert.Equal(t, 1, world.Round)
+	assert.Equal(t, "", world.World)
+
+	var events []simulation.Event
+	request(t, http.MethodGet, ts.URL+"/runs/"+started.ID+"/events", "", http.StatusOK, &events)
+	assert.Len(t, events, 3)
+	assert.Equal(t, simulation.EventFight, events[2].Kind)
+	assert.Equal(t, []string{"A", "B"}, events[2].Aliens)
+	request(t, http.MethodGet, ts.URL+"/runs/"+started.ID+"/events?from=2", "", http.StatusOK, &events)
+	assert.Len(t, events, 1)
+
+	var runs []RunStatus
+	request(t, http.MethodGet, ts.URL+"/runs", "", http.StatusOK, &runs)
+	assert.Len(t, runs, 1)
+
+	resp, err := http.Get(ts.URL + "/metrics")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	metrics, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/plain")
+	assert.Contains(t, string(metrics), "alien_invasion_runs_started_total 1\n")
+	assert.Contains(t, string(metrics), "alien_invasion_runs_finished_total{status=\"finished\"} 1\n")
+	assert.Contains(t, string(metrics), "alien_invasion_cities_destroyed_total 1\n")
+	assert.Contains(t, string(metrics), "alien_invasion_aliens_killed_total 2\n")
+	assert.Contains(t, string(metrics), "alien_invasion_active_runs 0\n")
+}
+
+func TestServer_Cancel(t *testing.T) {
+	api := New(1, 0, nil)
+	defer api.Close()
+	ts := httptest.NewServer(api)
+	defer ts.Close()
+
+	var endless RunStatus
+	request(t, http.MethodPost, ts.URL+"/runs", endlessRun, http.StatusAccepted, &endless)
+	waitFor(t, ts.URL+"/runs/"+endless.ID, StatusRunning)
+
+	// the only slot is taken and no run may wait for it
+	request(t, http.MethodPost, ts.URL+"/runs", shortRun, http.StatusServiceUnavailable, nil)
+	request(t, http.MethodGet, ts.URL+"/runs/"+endless.ID+"/world", "", http.StatusConflict, nil)
+
+	request(t, http.MethodDelete, ts.URL+"/runs/"+endless.ID, "", http.StatusAccepted, nil)
+	cancelled := waitFor(t, ts.URL+"/runs/"+endless.ID, StatusCancelled)
+	assert.Less(t, cancelled.Round, 100000000)
+	request(t, http.MethodGet, ts.URL+"/runs/"+endless.ID+"/world", "", http.StatusOK, nil)
+
+	// the slot is free again
+	var started RunStatus
+	request(t, http.MethodPost, ts.URL+"/runs", shortRun, http.StatusAccepted, &started)
+	waitFor(t, ts.URL+"/runs/"+started.ID, StatusFinished)
+}
+
+func TestServer_Limits(t *testing.T) {
+	api := New(1, 1, nil)
+	api.SetLimits(Limits{Runs: 1, Events: 4})
+	defer api.Close()
+	ts := httptest.NewServer(api)
+	defer ts.Close()
+
+	var endless RunStatus
+	request(t, http.MethodPost, ts.URL+"/runs", strings.Replace(endlessRun, "{", `{"paused": true, `, 1), http.StatusAccepted, &endless)
+	waitFor(t, ts.URL+"/runs/"+endless.ID, StatusRunning)
+	request(t, http.MethodPost, ts.URL+"/runs/"+endless.ID+"/step?n=10", "", http.StatusOK, nil)
+	var stepped RunStatus
+	for deadline := time.Now().Add(5 * time.Second); stepped.Round < 10 && time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		request(t, http.MethodGet, ts.URL+"/runs/"+endless.ID, "", http.StatusOK, &stepped)
+	}
+	assert.Equal(t, 10, stepped.Round)
+
+	// the alien arrived, then moved every round, only the last events are kept
+	assert.Equal(t, 10, stepped.Events)
+	var events []simulation.Event
+	request(t, http.MethodGet, ts.URL+"/runs/"+endless.ID+"/events", "", http.StatusOK, &events)
+	assert.Len(t, events, stepped.Events-stepped.FirstEvent)
+	assert.LessOrEqual(t, len(events), 4)
+	assert.Equal(t, 10, events[len(events)-1].Round)
+	request(t, http.MethodGet, ts.URL+"/runs/"+endless.ID+"/events?from=9", "", http.StatusOK, &events)
+	assert.Len(t, events, 1)
+
+	// only the last run over is kept
+	request(t, http.MethodDelete, ts.URL+"/runs/"+endless.ID, "", http.StatusAccepted, nil)
+	waitFor(t, ts.URL+"/runs/"+endless.ID, StatusCancelled)
+	var started RunStatus
+	request(t, http.MethodPost, ts.URL+"/runs", shortRun, http.StatusAccepted, &started)
+	waitFor(t, ts.URL+"/runs/"+started.ID, StatusFinished)
+	request(t, http.MethodGet, ts.URL+"/runs/"+endless.ID, "", http.StatusNotFound, nil)
+	var runs []RunStatus
+	request(t, http.MethodGet, ts.URL+"/runs", "", http.StatusOK, &runs)
+	assert.Len(t, runs, 1)
+}
+
+func TestServer_Errors(t *testing.T) {
+	api := New(1, 0, nil)
+	limits := DefaultLimits
+	limits.Aliens, limits.Iterations, limits.Cities = 10, 1000, 2
+	api.SetLimits(limits)
+	defer api.Close()
+	ts := httptest.NewServer(api)
+	defer ts.Close()
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{name: "Invalid json", method: http.MethodPost, path: "/runs", body: "{", want: http.StatusBadRequest},
+		{name: "World file of the server", method: http.MethodPost, path: "/runs", body: `{"aliens": 1, "world": "/etc/passwd"}`, want: http.StatusBadRequest},
+		{name: "Negative aliens", method: http.MethodPost, path: "/runs", body: `{"aliens": -3, "worldMap": ["Foo"], "roster": ["A"]}`, want: http.StatusBadRequest},
+		{name: "Event on an unknown city", method: http.MethodPost, path: "/runs",
+			body: `{"aliens": 1, "worldMap": ["Foo"], "events": [{"round": 1, "action": "destroy-city", "city": "Bar"}]}`, want: http.StatusBadRequest},
+		{name: "Too many aliens", method: http.MethodPost, path: "/runs", body: `{"aliens": 11, "iterations": 10, "worldMap": ["Foo"]}`, want: http.StatusBadRequest},
+		{name: "Too many landings", method: http.MethodPost, path: "/runs",
+			body: `{"aliens": 5, "iterations": 10, "worldMap": ["Foo"], "events": [{"round": 2, "action": "land", "city": "Foo", "count": 6}]}`, want: http.StatusBadRequest},
+		{name: "Too many iterations", method: http.MethodPost, path: "/runs", body: `{"aliens": 1, "iterations": 1001, "worldMap": ["Foo"]}`, want: http.StatusBadRequest},
+		{name: "Too many cities", method: http.MethodPost, path: "/runs", body: `{"aliens": 1, "iterations": 10, "worldMap": ["Foo", "Bar", "Baz"]}`, want: http.StatusBadRequest},
+		{name: "Too many cities in one line", method: http.MethodPost, path: "/runs", body: `{"aliens": 1, "iterations": 10, "worldMap": ["Foo\nBar\nBaz"]}`, want: http.StatusBadRequest},
+		{name: "Unknown run", method: http.MethodGet, path: "/runs/nope", want: http.StatusNotFound},
+		{name: "Unknown path", method: http.MethodGet, path: "/worlds", want: http.StatusNotFound},
+		{name: "Method not allowed", method: http.MethodPut, path: "/runs", want: http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var failure struct {
+				Error string `json:"error"`
+			}
+			request(t, tt.method, ts.URL+tt.path, tt.body, tt.want, &failure)
+			assert.NotEmpty(t, failure.Error)
+		})
+	}
+
+	// the runs refused gave their place in the queue back
+	var started RunStatus
+	request(t, http.MethodPost, ts.URL+"/runs", shortRun, http.StatusAccepted, &started)
+	waitFor(t, ts.URL+"/runs/"+started.ID, StatusFinished)
+}
+
+func TestServer_UI(t *testing.T) {
+	api := New(1, 1, nil)
+	defer api.Close()
+	ts := httptest.NewServer(api)
+	defer ts.Close()
+
+	tests := []struct {
+		name            string
+		path            string
+		wantCode        int
+		wantContentType string
+		wantContains    string
+	}{
+		{name: "Index", path: "/", wantCode: http.StatusOK, wantContentType: "text/html", wantContains: `<script src="app.js">`},
+		{name: "Script", path: "/app.js", wantCode: http.StatusOK, wantContentType: "javascript", wantContains: "EventSource"},
+		{name: "Style", path: "/style.css", wantCode: http.StatusOK, wantContentType: "text/css", wantContains: ".city.destroyed"},
+		{name: "Missing file", path: "/nope.js", wantCode: http.StatusNotFound, wantContentType: "application/json", wantContains: "Unknown path"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Get(ts.URL + tt.path)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantCode, resp.StatusCode)
+			assert.Contains(t, resp.Header.Get("Content-Type"), tt.wantContentType)
+			assert.Contains(t, string(body), tt.wantContains)
+		})
+	}
+}
+
+func TestServer_Map(t *testing.T) {
+	api := New(1, 1, nil)
+	defer api.Close()
+	ts := httptest.NewServer(api)
+	defer ts.Close()
+
+	var started RunStatus
+	request(t, http.MethodPost, ts.URL+"/runs", `{"aliens": 1, "iterations": 1, "worldMap": ["Foo north=Bar west=Baz", "Bar west=Bee"]}`, http.StatusAccepted, &started)
+	var layout WorldLayout
+	request(t, http.MethodGet, ts.URL+"/runs/"+started.ID+"/map", "", http.StatusOK, &layout)
+	assert.Equal(t, WorldLayout{
+		Width:  2,
+		Height: 2,
+		Cities: []CityPoint{{Name: "Foo", X: 1, Y: 1}, {Name: "Bar", X: 1, Y: 0}, {Name: "Baz", X: 0, Y: 1}, {Name: "Bee", X: 0, Y: 0}},
+		Roads:  [][2]string{{"Bar", "Foo"}, {"Baz", "Foo"}, {"Bar", "Bee"}},
+	}, layout)
+}
+
+func TestServer_Route(t *testing.T) {
+	api := New(1, 1, nil)
+	defer api.Close()
+	ts := httptest.NewServer(api)
+	defer ts.Close()
+
+	grid := `["A east=B south=D", "B east=C south=E", "C south=F", "D east=E south=G", "E east=F south=H", "F south=I", "G east=H", "H east=I", "I"]`
+	var started RunStatus
+	request(t, http.MethodPost, ts.URL+"/runs", `{"aliens": 1, "iterations": 10, "seed": 4, "paused": true, "worldMap": `+grid+`}`, http.StatusAccepted, &started)
+	url := ts.URL + "/runs/" + started.ID + "/route"
+
+	type plan struct {
+		Round  int `json:"round"`
+		Routes []struct {
+			Destination string   `json:"destination"`
+			Path        []string `json:"path"`
+		} `json:"routes"`
+		Unreachable []string `json:"unreachable"`
+	}
+	// the aliens did not arrive yet
+	var before plan
+	request(t, http.MethodGet, url+"?from=A&to=I,B", "", http.StatusOK, &before)
+	assert.Equal(t, 0, before.Round)
+	assert.Len(t, before.Routes, 2)
+	assert.Equal(t, "B", before.Routes[0].Destination)
+	assert.Equal(t, []string{"A", "B"}, before.Routes[0].Path)
+
+	// a lone alien ends the run after it arrived
+	request(t, http.MethodPost, ts.URL+"/runs/"+started.ID+"/step", "", http.StatusOK, nil)
+	waitFor(t, ts.URL+"/runs/"+started.ID, StatusFinished)
+	var events []simulation.Event
+	request(t, http.MethodGet, ts.URL+"/runs/"+started.ID+"/events", "", http.StatusOK, &events)
+	occupied := events[0].City
+
+	var after plan
+	request(t, http.MethodGet, url+"?from=A&to=A,B,C,D,E,F,G,H,I", "", http.StatusOK, &after)
+	assert.Equal(t, 1, after.Round)
+	assert.Contains(t, after.Unreachable, occupied)
+	for _, route := range after.Routes {
+		assert.NotContains(t, route.Path[1:], occupied)
+	}
+
+	request(t, http.MethodGet, url+"?from=A", "", http.StatusBadRequest, nil)
+	request(t, http.MethodGet, url+"?from=Foo&to=A", "", http.StatusBadRequest, nil)
+
+	// the routes of a running run are planned between two of its rounds
+	var endless RunStatus
+	request(t, http.MethodPost, ts.URL+"/runs", endlessRun, http.StatusAccepted, &endless)
+	waitFor(t, ts.URL+"/runs/"+endless.ID, StatusRunning)
+	var running plan
+	request(t, http.MethodGet, ts.URL+"/runs/"+endless.ID+"/route?from=Foo&to=Bar", "", http.StatusOK, &running)
+	assert.Equal(t, 1, len(running.Routes)+len(running.Unreachable))
+	request(t, http.MethodDelete, ts.URL+"/runs/"+endless.ID, "", http.StatusAccepted, nil)
+}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/rvsingh011/alien-invasion/simulation"
+)
+
+/*
+	stream sends the events of a run as Server-Sent Events as they happen, starting at the index given by ?from= or Last-Event-ID,
+	or at the first event kept if the events before were dropped.
+	Every event is sent with its index as id and its kind as name, a round message follows every round
+	and an end message with the final status closes the stream.
+*/
+func (server *Server) stream(w http.ResponseWriter, r *http.Request, current *run) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("Streaming is not supported"))
+		return
+	}
+	from := 0
+	if value := r.Header.Get("Last-Event-ID"); value != "" {
+		last, err := strconv.Atoi(value)
+		if err != nil || last < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("Invalid event id %s", value))
+			return
+		}
+		from = last + 1
+	}
+	if value := r.URL.Query().Get("from"); value != "" {
+		var err error
+		if from, err = strconv.Atoi(value); err != nil || from < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("Invalid event index %s", value))
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	lastRound := -1
+	for {
+		current.mu.Lock()
+		var events []simulation.Event
+		// the events dropped are skipped
+		if from < current.dropped {
+			from = current.dropped
+		}
+		if from-current.dropped < len(current.events) {
+			events = append(events, current.events[from-current.dropped:]...)
+		}
+		round, over, changed := current.round, current.over(), current.changed
+		current.mu.Unlock()
+
+		for _, event := range events {
+			writeEvent(w, strconv.Itoa(from), event.Kind, event)
+			from++
+		}
+		if over {
+			writeEvent(w, "", "end", current.describe())
+			flusher.Flush()
+			return
+		}
+		if round != lastRound {
+			writeEvent(w, "", "round", current.describe())
+			lastRound = round
+		}
+		flusher.Flush()
+
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		case <-server.ctx.Done():
+			return
+		}
+	}
+}
+
+/*
+	writeEvent writes a single Server-Sent Event with a json payload.
+*/
+func writeEvent(w http.ResponseWriter, id, name string, payload interface{}) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+	if id != "" {
+		fmt.Fprintf(w, "id: %s\n", id)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, data)
+}
+
+/*
+	controlRun pauses, resumes or steps a run.
+*/
+func (server *Server) controlRun(w http.ResponseWriter, r *http.Request, current *run, action string) {
+	current.mu.Lock()
+	over := current.over()
+	current.mu.Unlock()
+	if over {
+		writeError(w, http.StatusConflict, fmt.Errorf("The run %s is over", current.id))
+		return
+	}
+
+	switch action {
+	case "pause":
+		current.control.Pause()
+	case "resume":
+		current.control.Resume()
+	case "step":
+		rounds := 1
+		if value := r.URL.Query().Get("n"); value != "" {
+			var err error
+			if rounds, err = strconv.Atoi(value); err != nil || rounds < 1 {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("Invalid number of rounds %s", value))
+				return
+			}
+		}
+		current.control.Step(rounds)
+	}
+	writeJSON(w, http.StatusOK, current.describe())
+}
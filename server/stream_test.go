@@ -0,0 +1,128 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+	sseReader reads the messages of a Server-Sent Events stream.
+*/
+type sseReader struct {
+	scanner *bufio.Scanner
+}
+
+func (reader *sseReader) next(t *testing.T) (string, string, string) {
+	var id, name, data string
+	for reader.scanner.Scan() {
+		line := reader.scanner.Text()
+		switch {
+		case line == "":
+			return id, name, data
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+	t.Fatalf("the stream ended")
+	return "", "", ""
+}
+
+/*
+	until skips messages until a message with the name, and returns its data.
+*/
+func (reader *sseReader) until(t *testing.T, name string) string {
+	for {
+		if _, got, data := reader.next(t); got == name {
+			return data
+		}
+	}
+}
+
+func openStream(t *testing.T, url string) (*sseReader, func()) {
+	resp, err := http.Get(url)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	return &sseReader{scanner: bufio.NewScanner(resp.Body)}, func() { resp.Body.Close() }
+}
+
+func TestServer_Stream(t *testing.T) {
+	api := New(1, 1, nil)
+	defer api.Close()
+	ts := httptest.NewServer(api)
+	defer ts.Close()
+
+	var started RunStatus
+	request(t, http.MethodPost, ts.URL+"/runs", strings.Replace(endlessRun, "{", `{"paused": true, `, 1), http.StatusAccepted, &started)
+	assert.True(t, started.Paused)
+	stream, closeStream := openStream(t, ts.URL+"/runs/"+started.ID+"/stream")
+	defer closeStream()
+
+	var status RunStatus
+	assert.NoError(t, json.Unmarshal([]byte(stream.until(t, "round")), &status))
+	assert.Equal(t, 0, status.Round)
+
+	request(t, http.MethodPost, ts.URL+"/runs/"+started.ID+"/step?n=2", "", http.StatusOK, nil)
+	id, name, data := stream.next(t)
+	assert.Equal(t, "0", id)
+	assert.Equal(t, "arrive", name)
+	assert.Contains(t, data, `"alien":"A"`)
+	for status.Round < 2 {
+		assert.NoError(t, json.Unmarshal([]byte(stream.until(t, "round")), &status))
+	}
+	assert.Equal(t, 2, status.Round)
+	assert.True(t, status.Paused)
+
+	request(t, http.MethodPost, ts.URL+"/runs/"+started.ID+"/resume", "", http.StatusOK, &status)
+	assert.False(t, status.Paused)
+	request(t, http.MethodPost, ts.URL+"/runs/"+started.ID+"/pause", "", http.StatusOK, &status)
+	assert.True(t, status.Paused)
+	request(t, http.MethodPost, ts.URL+"/runs/"+started.ID+"/step?n=0", "", http.StatusBadRequest, nil)
+
+	request(t, http.MethodDelete, ts.URL+"/runs/"+started.ID, "", http.StatusAccepted, nil)
+	assert.NoError(t, json.Unmarshal([]byte(stream.until(t, "end")), &status))
+	assert.Equal(t, StatusCancelled, status.Status)
+	assert.False(t, stream.scanner.Scan())
+	request(t, http.MethodPost, ts.URL+"/runs/"+started.ID+"/resume", "", http.StatusConflict, nil)
+}
+
+func TestServer_StreamFinishedRun(t *testing.T) {
+	api := New(1, 1, nil)
+	defer api.Close()
+	ts := httptest.NewServer(api)
+	defer ts.Close()
+
+	var started RunStatus
+	request(t, http.MethodPost, ts.URL+"/runs", shortRun, http.StatusAccepted, &started)
+	waitFor(t, ts.URL+"/runs/"+started.ID, StatusFinished)
+
+	tests := []struct {
+		name      string
+		query     string
+		wantKinds []string
+	}{
+		{name: "Whole run", query: "", wantKinds: []string{"arrive", "arrive", "fight", "end"}},
+		{name: "From an event", query: "?from=2", wantKinds: []string{"fight", "end"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream, closeStream := openStream(t, ts.URL+"/runs/"+started.ID+"/stream"+tt.query)
+			defer closeStream()
+			for _, kind := range tt.wantKinds {
+				_, name, _ := stream.next(t)
+				assert.Equal(t, kind, name)
+			}
+			assert.False(t, stream.scanner.Scan())
+		})
+	}
+}
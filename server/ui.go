@@ -0,0 +1,35 @@
+package server
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+	"strings"
+)
+
+// web front-end watching and controlling runs, served at the root of the server
+//
+//go:embed ui
+var uiFiles embed.FS
+
+/*
+	serveUI serves the files of the web front-end, it reports false when the path is not one of them.
+*/
+func serveUI(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	assets, err := fs.Sub(uiFiles, "ui")
+	if err != nil {
+		return false
+	}
+	name := strings.TrimPrefix(r.URL.Path, "/")
+	if name == "" {
+		name = "index.html"
+	}
+	if info, err := fs.Stat(assets, name); err != nil || info.IsDir() {
+		return false
+	}
+	http.FileServer(http.FS(assets)).ServeHTTP(w, r)
+	return true
+}
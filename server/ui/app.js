@@ -0,0 +1,250 @@
+// Alien invasion web UI: starts a run through the API and animates its stream of events.
+(function () {
+  "use strict";
+
+  const cell = 90, margin = 50, cityRadius = 14, alienRadius = 5;
+  const svgNS = "http://www.w3.org/2000/svg";
+  const $ = (id) => document.getElementById(id);
+
+  let run = null, stream = null;
+  // city of every alien alive, and the element drawing it
+  let aliens = new Map();
+  let positions = new Map();
+
+  $("world-file").addEventListener("change", (event) => {
+    const file = event.target.files[0];
+    if (file) {
+      file.text().then((text) => { $("world").value = text; });
+    }
+  });
+  $("delay").addEventListener("input", () => {
+    $("delay-value").textContent = $("delay").value + " ms";
+  });
+
+  $("setup").addEventListener("submit", (event) => {
+    event.preventDefault();
+    $("error").hidden = true;
+    const lines = (text) => text.split("\n").map((line) => line.trim()).filter((line) => line !== "");
+    const body = {
+      worldMap: lines($("world").value),
+      aliens: parseInt($("aliens").value, 10),
+      iterations: parseInt($("iterations").value, 10),
+      rules: { movement: $("movement").value },
+      delay: parseInt($("delay").value, 10),
+    };
+    if ($("seed").value !== "") {
+      body.seed = parseInt($("seed").value, 10);
+    }
+    const roster = lines($("roster").value);
+    if (roster.length > 0) {
+      body.roster = roster;
+    }
+    api("POST", "/runs", body).then(start).catch(showError);
+  });
+
+  $("pause").addEventListener("click", () => control("POST", "/pause"));
+  $("resume").addEventListener("click", () => control("POST", "/resume"));
+  $("step").addEventListener("click", () => control("POST", "/step"));
+  $("cancel").addEventListener("click", () => control("DELETE", ""));
+
+  function api(method, path, body) {
+    return fetch(path, {
+      method: method,
+      headers: body ? { "Content-Type": "application/json" } : {},
+      body: body ? JSON.stringify(body) : undefined,
+    }).then((response) => response.json().then((data) => {
+      if (!response.ok) {
+        throw new Error(data.error || response.statusText);
+      }
+      return data;
+    }));
+  }
+
+  function control(method, action) {
+    if (run) {
+      api(method, "/runs/" + run.id + action).then(showStatus).catch(showError);
+    }
+  }
+
+  function showError(err) {
+    $("error").textContent = err.message;
+    $("error").hidden = false;
+  }
+
+  function showStatus(status) {
+    let text = "Round " + status.round + ": " + status.citiesLeft + " cities, " + status.aliensAlive + " aliens";
+    if (status.status !== "running") {
+      text += " (" + status.status + ")";
+    } else if (status.paused) {
+      text += " (paused)";
+    }
+    $("status").textContent = text;
+  }
+
+  function log(text) {
+    const item = document.createElement("li");
+    item.textContent = text;
+    $("log").prepend(item);
+  }
+
+  function start(status) {
+    if (stream) {
+      stream.close();
+    }
+    run = status;
+    aliens = new Map();
+    $("log").replaceChildren();
+    $("run").hidden = false;
+    showStatus(status);
+    api("GET", "/runs/" + run.id + "/map").then((layout) => {
+      drawMap(layout);
+      listen();
+    }).catch(showError);
+  }
+
+  function point(name) {
+    return positions.get(name) || { x: margin, y: margin };
+  }
+
+  function drawMap(layout) {
+    const map = $("map");
+    map.replaceChildren();
+    const width = 2 * margin + Math.max(layout.width - 1, 0) * cell;
+    const height = 2 * margin + Math.max(layout.height - 1, 0) * cell;
+    map.setAttribute("viewBox", "0 0 " + width + " " + height);
+    map.setAttribute("width", width);
+    map.setAttribute("height", height);
+
+    positions = new Map();
+    layout.cities.forEach((city) => {
+      positions.set(city.name, { x: margin + city.x * cell, y: margin + city.y * cell });
+    });
+    layout.roads.forEach((road) => {
+      const from = point(road[0]), to = point(road[1]);
+      const line = element("line", { class: "road", x1: from.x, y1: from.y, x2: to.x, y2: to.y });
+      line.dataset.road = roadKey(road[0], road[1]);
+      map.appendChild(line);
+    });
+    layout.cities.forEach((city) => {
+      const at = point(city.name);
+      const group = element("g", { class: "city" });
+      group.dataset.city = city.name;
+      const circle = element("circle", { cx: at.x, cy: at.y, r: cityRadius });
+      const title = element("title", {});
+      title.textContent = city.name;
+      circle.appendChild(title);
+      const label = element("text", { x: at.x, y: at.y + cityRadius + 14 });
+      label.textContent = city.name;
+      group.append(circle, label);
+      map.appendChild(group);
+    });
+    (layout.conflicts || []).forEach((conflict) => log("Layout conflict: " + conflict));
+  }
+
+  function element(name, attributes) {
+    const node = document.createElementNS(svgNS, name);
+    Object.keys(attributes).forEach((key) => node.setAttribute(key, attributes[key]));
+    return node;
+  }
+
+  function roadKey(a, b) {
+    return a < b ? a + "\u0000" + b : b + "\u0000" + a;
+  }
+
+  function cityNode(name) {
+    return Array.from(document.querySelectorAll("#map .city")).find((node) => node.dataset.city === name);
+  }
+
+  function destroyCity(name) {
+    const node = cityNode(name);
+    if (node) {
+      node.classList.add("destroyed");
+    }
+    document.querySelectorAll("#map .road").forEach((road) => {
+      if (road.dataset.road.split("\u0000").includes(name)) {
+        road.classList.add("destroyed");
+      }
+    });
+    aliens.forEach((alien, alienName) => {
+      if (alien.city === name) {
+        killAlien(alienName);
+      }
+    });
+  }
+
+  function killAlien(name) {
+    const alien = aliens.get(name);
+    if (!alien) {
+      return;
+    }
+    aliens.delete(name);
+    alien.node.classList.add("dead");
+    setTimeout(() => alien.node.remove(), 700);
+  }
+
+  function placeAlien(name, city) {
+    let alien = aliens.get(name);
+    if (!alien) {
+      const node = element("circle", { class: "alien", r: alienRadius, cx: 0, cy: 0 });
+      const title = element("title", {});
+      title.textContent = name;
+      node.appendChild(title);
+      $("map").appendChild(node);
+      alien = { node: node, city: city };
+      aliens.set(name, alien);
+    }
+    alien.city = city;
+  }
+
+  // aliens sharing a city are lined up above its right side
+  function layoutAliens() {
+    const seen = new Map();
+    aliens.forEach((alien) => {
+      const index = seen.get(alien.city) || 0;
+      seen.set(alien.city, index + 1);
+      const at = point(alien.city);
+      const x = at.x + cityRadius + (index % 4) * (2 * alienRadius + 1);
+      const y = at.y - cityRadius - Math.floor(index / 4) * (2 * alienRadius + 1);
+      alien.node.style.transform = "translate(" + x + "px," + y + "px)";
+    });
+  }
+
+  function listen() {
+    stream = new EventSource("/runs/" + run.id + "/stream");
+    const on = (kind, handler) => stream.addEventListener(kind, (message) => handler(JSON.parse(message.data)));
+
+    on("arrive", (event) => placeAlien(event.alien, event.city));
+    on("land", (event) => {
+      placeAlien(event.alien, event.city);
+      log("Round " + event.round + ": " + event.alien + " landed in " + event.city);
+    });
+    on("move", (event) => placeAlien(event.alien, event.to));
+    on("fight", (event) => {
+      log("Round " + event.round + ": " + event.city + " was destroyed by " + event.aliens.join(", "));
+      destroyCity(event.city);
+    });
+    on("scheduled", (event) => {
+      const scheduled = event.scheduled;
+      log("Round " + event.round + ": scheduled " + scheduled.action + " " + scheduled.city + (scheduled.to ? "-" + scheduled.to : ""));
+      if (scheduled.action === "destroy-city") {
+        destroyCity(scheduled.city);
+      } else if (scheduled.action === "destroy-road") {
+        document.querySelectorAll("#map .road").forEach((road) => {
+          if (road.dataset.road === roadKey(scheduled.city, scheduled.to)) {
+            road.classList.add("destroyed");
+          }
+        });
+      }
+    });
+    on("round", (status) => {
+      layoutAliens();
+      showStatus(status);
+    });
+    on("end", (status) => {
+      layoutAliens();
+      showStatus(status);
+      log("The invasion is " + status.status + " after round " + status.round);
+      stream.close();
+    });
+  }
+})();
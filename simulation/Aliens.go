@@ -1,15 +1,89 @@
 package simulation
 
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
 /*
 	Alien store information and characterstics of alien. Going forward more infomation about the alien can be stored.
+	The ID identifies the alien in the simulation, the name is only used to display it and can be shared by several aliens.
 */
 type Alien struct {
+	ID   int
 	Name string
 }
 
 /*
 	NewAlien simulates the creation/arrival of a new alien.
 */
-func NewAlien(name string) *Alien {
-	return &Alien{Name: name}
+func NewAlien(id int, name string) *Alien {
+	return &Alien{ID: id, Name: name}
+}
+
+/*
+	String returns the name of the alien, aliens without a name are displayed by their ID.
+*/
+func (alien *Alien) String() string {
+	if alien.Name == "" {
+		return fmt.Sprintf("alien %d", alien.ID)
+	}
+	return alien.Name
+}
+
+/*
+	AddAlien adds an alien to the invasion, it gets the next free ID.
+*/
+func (sim *Simulation) AddAlien(name string) *Alien {
+	sim.worldChanged()
+	sim.LastAlienID++
+	alien := NewAlien(sim.LastAlienID, name)
+	sim.Aliens = append(sim.Aliens, alien)
+	return alien
+}
+
+/*
+	FindAlien returns the living alien displayed with the given name, or with the given ID prefixed by #.
+	The first alien in the invasion order is returned when several aliens share the name.
+*/
+func (sim *Simulation) FindAlien(name string) (*Alien, bool) {
+	for _, alien := range sim.Aliens {
+		if alien.String() == name || fmt.Sprintf("#%d", alien.ID) == name {
+			return alien, true
+		}
+	}
+	return nil, false
+}
+
+/*
+	alienNames returns the display name of every alien by ID.
+*/
+func (sim *Simulation) alienNames() map[int]string {
+	names := make(map[int]string, len(sim.Aliens))
+	for _, alien := range sim.Aliens {
+		names[alien.ID] = alien.String()
+	}
+	return names
+}
+
+/*
+	joinNames lists names the way people do, "a, b and c".
+*/
+func joinNames(names []string) string {
+	if len(names) < 2 {
+		return strings.Join(names, "")
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " and " + names[len(names)-1]
+}
+
+/*
+	namesRandom returns the source names are sampled and generated from, seeded like the attack vector.
+*/
+func (sim *Simulation) namesRandom() *rand.Rand {
+	var seed int64
+	if sim.RandSource != nil {
+		seed, _ = sim.RandSource.State()
+	}
+	return rand.New(rand.NewSource(seed))
 }
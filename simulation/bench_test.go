@@ -0,0 +1,105 @@
+package simulation
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/rvsingh011/alien-invasion/generator"
+	"go.uber.org/zap"
+)
+
+// scales of the benchmarks: a side x side grid with every road, aliens and rounds
+var benchScales = []struct {
+	side, aliens, rounds int
+}{
+	{side: 10, aliens: 10, rounds: 1000},
+	{side: 100, aliens: 1000, rounds: 1000},
+	{side: 300, aliens: 10000, rounds: 100},
+}
+
+func benchWorld(b *testing.B, side int) []byte {
+	world, err := generator.Generate(generator.Options{Topology: generator.TopologyGrid, Width: side, Height: side, Density: 1, Seed: 1})
+	if err != nil {
+		b.Fatal(err)
+	}
+	var text bytes.Buffer
+	if _, err := world.WriteTo(&text); err != nil {
+		b.Fatal(err)
+	}
+	return text.Bytes()
+}
+
+func benchSimulation(b *testing.B, worldMap []byte, aliens, rounds int) *Simulation {
+	sim, err := NewSimulation(rounds, aliens, "", "", nil, zap.NewNop())
+	if err != nil {
+		b.Fatal(err)
+	}
+	sim.Output = io.Discard
+	sim.SetSeed(1)
+	if err := sim.LoadWorld(bytes.NewReader(worldMap)); err != nil {
+		b.Fatal(err)
+	}
+	if err := sim.CreateAliens(); err != nil {
+		b.Fatal(err)
+	}
+	return sim
+}
+
+func BenchmarkSimulation_LoadWorld(b *testing.B) {
+	for _, scale := range benchScales {
+		worldMap := benchWorld(b, scale.side)
+		b.Run(fmt.Sprintf("cities=%d", scale.side*scale.side), func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(worldMap)))
+			for n := 0; n < b.N; n++ {
+				sim, _ := NewSimulation(1, 1, "", "", nil, zap.NewNop())
+				if err := sim.LoadWorld(bytes.NewReader(worldMap)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkSimulation_Start(b *testing.B) {
+	for _, scale := range benchScales {
+		worldMap := benchWorld(b, scale.side)
+		b.Run(fmt.Sprintf("cities=%d/aliens=%d/rounds=%d", scale.side*scale.side, scale.aliens, scale.rounds), func(b *testing.B) {
+			b.ReportAllocs()
+			rounds := 0
+			var running time.Duration
+			for n := 0; n < b.N; n++ {
+				b.StopTimer()
+				sim := benchSimulation(b, worldMap, scale.aliens, scale.rounds)
+				b.StartTimer()
+				started := time.Now()
+				if err := sim.Start(); err != nil {
+					b.Fatal(err)
+				}
+				running += time.Since(started)
+				rounds += sim.Round
+			}
+			b.ReportMetric(float64(rounds)/running.Seconds(), "rounds/s")
+		})
+	}
+}
+
+func BenchmarkSimulation_fight(b *testing.B) {
+	for _, scale := range benchScales {
+		worldMap := benchWorld(b, scale.side)
+		b.Run(fmt.Sprintf("cities=%d/aliens=%d", scale.side*scale.side, scale.aliens), func(b *testing.B) {
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				b.StopTimer()
+				sim := benchSimulation(b, worldMap, scale.aliens, scale.rounds)
+				sim.Round = 1
+				sim.prepareAttack()
+				b.StartTimer()
+				sim.fight()
+			}
+		})
+	}
+}
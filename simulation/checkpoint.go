@@ -0,0 +1,199 @@
+package simulation
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+
+	"github.com/rvsingh011/alien-invasion/utils"
+	"go.uber.org/zap"
+)
+
+// CheckpointVersion is the version of the checkpoint file format
+const CheckpointVersion = 3
+
+/*
+	Checkpoint is a snapshot of a simulation between two rounds of attack.
+	The attack vector is stored as the state of its generator, so a resumed simulation makes the same choices.
+*/
+type Checkpoint struct {
+	Version          int                `json:"version"`
+	Iterations       int                `json:"iterations"`
+	WorldFile        string             `json:"worldFile"`
+	NumberOfAliens   int                `json:"numberOfAliens"`
+	AlienNames       string             `json:"alienNames"`
+	Round            int                `json:"round"`
+	World            map[string][]*City `json:"world"`
+	Cities           []*City            `json:"cities"`
+	Aliens           []*Alien           `json:"aliens"`
+	LastAlienID      int                `json:"lastAlienId"`
+	AlienCityMapping map[int]string     `json:"alienCityMapping"`
+	CityAlienMapping map[string][]int   `json:"cityAlienMapping"`
+	ScheduledEvents  []ScheduledEvent   `json:"scheduledEvents,omitempty"`
+	Landed           int                `json:"landed"`
+	Seed             int64              `json:"seed"`
+	Draws            uint64             `json:"draws"`
+	Generator        uint64             `json:"generator"`
+}
+
+/*
+	WriteCheckpoint writes a snapshot of the simulation, only simulations seeded with SetSeed can be checkpointed.
+*/
+func (sim *Simulation) WriteCheckpoint(w io.Writer) error {
+	if sim.RandSource == nil {
+		return fmt.Errorf("The attack vector of the simulation cannot be checkpointed, seed the simulation with SetSeed")
+	}
+	return json.NewEncoder(w).Encode(sim.snapshot())
+}
+
+/*
+	snapshot captures the state of the simulation, the attack vector is left empty if it cannot be checkpointed.
+*/
+func (sim *Simulation) snapshot() Checkpoint {
+	checkpoint := Checkpoint{
+		Version:          CheckpointVersion,
+		Iterations:       sim.Iterations,
+		WorldFile:        sim.WorldFile,
+		NumberOfAliens:   sim.NumberOfAliens,
+		AlienNames:       sim.AlienNames,
+		Round:            sim.Round,
+		World:            sim.World,
+		Cities:           sim.Cities,
+		Aliens:           sim.Aliens,
+		LastAlienID:      sim.LastAlienID,
+		AlienCityMapping: sim.AlienCityMapping,
+		CityAlienMapping: sim.CityAlienMapping,
+		ScheduledEvents:  sim.ScheduledEvents,
+		Landed:           sim.landed,
+	}
+	if sim.RandSource != nil {
+		checkpoint.Seed, checkpoint.Draws = sim.RandSource.State()
+		checkpoint.Generator = sim.RandSource.Generator()
+	}
+	return checkpoint
+}
+
+/*
+	SaveCheckpoint writes a snapshot of the simulation to a file, the file is replaced atomically.
+*/
+func (sim *Simulation) SaveCheckpoint(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("Error Creating the checkpoint file : %s, Error: %s", path, err.Error())
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := sim.WriteCheckpoint(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("Error Writing the checkpoint file : %s, Error: %s", path, err.Error())
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+/*
+	ReadCheckpoint rebuilds a simulation from a snapshot written by WriteCheckpoint.
+*/
+func ReadCheckpoint(r io.Reader, logger *zap.Logger) (*Simulation, error) {
+	var checkpoint Checkpoint
+	if err := json.NewDecoder(r).Decode(&checkpoint); err != nil {
+		return nil, fmt.Errorf("Error Parsing the checkpoint, Error: %s", err.Error())
+	}
+	if checkpoint.Version != CheckpointVersion {
+		return nil, fmt.Errorf("Unsupported checkpoint version %d, expected %d", checkpoint.Version, CheckpointVersion)
+	}
+
+	return checkpoint.restore(logger)
+}
+
+/*
+	restore rebuilds the simulation captured by the checkpoint with its attack vector.
+*/
+func (checkpoint *Checkpoint) restore(logger *zap.Logger) (*Simulation, error) {
+	sim, err := checkpoint.rebuild(logger)
+	if err != nil {
+		return nil, err
+	}
+	sim.RandSource = utils.RestoreRandSource(checkpoint.Seed, checkpoint.Draws, checkpoint.Generator)
+	sim.RandSeed = rand.New(sim.RandSource)
+	return sim, nil
+}
+
+/*
+	rebuild rebuilds the simulation captured by the checkpoint without its attack vector, the simulation cannot draw.
+*/
+func (checkpoint *Checkpoint) rebuild(logger *zap.Logger) (*Simulation, error) {
+	sim, err := NewSimulation(checkpoint.Iterations, checkpoint.NumberOfAliens, checkpoint.AlienNames, checkpoint.WorldFile, nil, logger)
+	if err != nil {
+		return nil, err
+	}
+	sim.Round = checkpoint.Round
+	sim.World = checkpoint.World
+	sim.Cities = checkpoint.Cities
+	sim.Aliens = checkpoint.Aliens
+	sim.LastAlienID = checkpoint.LastAlienID
+	sim.ScheduledEvents = checkpoint.ScheduledEvents
+	sim.landed = checkpoint.Landed
+	if checkpoint.AlienCityMapping != nil {
+		sim.AlienCityMapping = checkpoint.AlienCityMapping
+	}
+	if checkpoint.CityAlienMapping != nil {
+		sim.CityAlienMapping = checkpoint.CityAlienMapping
+	}
+	return sim, nil
+}
+
+/*
+	LoadCheckpoint rebuilds a simulation from a snapshot file written by SaveCheckpoint.
+*/
+func LoadCheckpoint(path string, logger *zap.Logger) (*Simulation, error) {
+	checkpointFile, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error Reading the checkpoint file : %s, Error: %s", path, err.Error())
+	}
+	defer checkpointFile.Close()
+	return ReadCheckpoint(checkpointFile, logger)
+}
+
+/*
+	CheckpointEvery saves a snapshot of the simulation to path after every n rounds of attack.
+*/
+func (sim *Simulation) CheckpointEvery(n int, path string) {
+	if n < 1 {
+		return
+	}
+	sim.OnRound(func(sim *Simulation) {
+		if sim.Round%n == 0 {
+			sim.saveCheckpointAfterRound(path)
+		}
+	})
+}
+
+/*
+	CheckpointOn saves a snapshot of the simulation to path after the round during which a signal was received.
+*/
+func (sim *Simulation) CheckpointOn(signals <-chan os.Signal, path string) {
+	sim.OnRound(func(sim *Simulation) {
+		select {
+		case <-signals:
+			sim.saveCheckpointAfterRound(path)
+		default:
+		}
+	})
+}
+
+/*
+	saveCheckpointAfterRound saves a snapshot from a round hook, where errors can only be reported.
+*/
+func (sim *Simulation) saveCheckpointAfterRound(path string) {
+	if err := sim.SaveCheckpoint(path); err != nil {
+		fmt.Fprintf(sim.out(), "Unable to checkpoint the simulation, Reason: %s\n", err.Error())
+		return
+	}
+	fmt.Fprintf(sim.out(), "Checkpoint of round %d saved to %s\n", sim.Round, path)
+}
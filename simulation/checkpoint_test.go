@@ -0,0 +1,68 @@
+package simulation
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func newSeededSimulation(t *testing.T, iterations int, seed int64) *Simulation {
+	sim, err := NewSimulation(iterations, 6, "", "", nil, zap.NewNop())
+	assert.NoError(t, err)
+	sim.SetSeed(seed)
+	assert.NoError(t, sim.LoadWorld(strings.NewReader(strings.Join([]string{
+		"Foo north=Bar west=Baz south=Qu-ux east=Moo",
+		"Bar west=Bee north=Lee east=Zee",
+		"Lee north=Yee west=Loo east=Doo",
+		"Moo north=Zee south=Poo",
+		"Poo west=Qu-ux",
+	}, "\n"))))
+	assert.NoError(t, sim.LoadAliens(strings.NewReader("A\nB\nC\nD\nE\nF")))
+	return sim
+}
+
+func TestSimulation_Checkpoint(t *testing.T) {
+	tests := []struct {
+		name        string
+		seed        int64
+		checkpoint  int
+		totalRounds int
+	}{
+		{name: "Checkpoint after the arrival", seed: 3, checkpoint: 1, totalRounds: 30},
+		{name: "Checkpoint in the middle of the attack", seed: 7, checkpoint: 4, totalRounds: 30},
+		{name: "Checkpoint before the arrival", seed: 11, checkpoint: 0, totalRounds: 30},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the reference run is not interrupted
+			reference := newSeededSimulation(t, tt.totalRounds, tt.seed)
+			assert.NoError(t, reference.Start())
+
+			interrupted := newSeededSimulation(t, tt.checkpoint, tt.seed)
+			assert.NoError(t, interrupted.Start())
+			var snapshot bytes.Buffer
+			assert.NoError(t, interrupted.WriteCheckpoint(&snapshot))
+
+			resumed, err := ReadCheckpoint(&snapshot, zap.NewNop())
+			assert.NoError(t, err)
+			assert.Equal(t, tt.checkpoint, resumed.Round)
+			resumed.Iterations = tt.totalRounds
+			assert.NoError(t, resumed.Start())
+
+			assert.Equal(t, reference.Round, resumed.Round)
+			assert.Equal(t, reference.World, resumed.World)
+			assert.Equal(t, reference.Cities, resumed.Cities)
+			assert.Equal(t, reference.Aliens, resumed.Aliens)
+			assert.Equal(t, reference.AlienCityMapping, resumed.AlienCityMapping)
+		})
+	}
+}
+
+func TestSimulation_WriteCheckpointWithoutSource(t *testing.T) {
+	sim := &Simulation{}
+	var snapshot bytes.Buffer
+	assert.Error(t, sim.WriteCheckpoint(&snapshot))
+}
@@ -2,10 +2,14 @@ package simulation
 
 /*
 	City simulates a city in world.
+	X and Y are the grid coordinates of the city once the layout of the world was inferred, see InferLayout.
 */
 type City struct {
-	Name      string
-	Direction string
+	Name       string
+	Direction  string
+	X          int  `json:",omitempty"`
+	Y          int  `json:",omitempty"`
+	Positioned bool `json:",omitempty"`
 }
 
 func NewCity(cityName string) *City {
@@ -0,0 +1,196 @@
+package simulation
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+/*
+	Controller pauses, resumes, steps and throttles a simulation run with RunControlled from another goroutine.
+*/
+type Controller struct {
+	mu     sync.Mutex
+	paused bool
+	// rounds allowed to run while paused
+	steps int
+	// time to wait between two rounds
+	delay time.Duration
+	// closed and replaced every time the controller changes, to wake up the run
+	changed chan struct{}
+	// the run is in RunControlled, inspections wait for it to be between two rounds
+	running bool
+	// inspections waiting for the run
+	inspections []func()
+}
+
+/*
+	NewController returns a controller, a paused controller does not let the run start before it is resumed or stepped.
+*/
+func NewController(paused bool) *Controller {
+	return &Controller{paused: paused, changed: make(chan struct{})}
+}
+
+/*
+	Pause stops the run after the current round.
+*/
+func (control *Controller) Pause() {
+	control.update(func() { control.paused = true })
+}
+
+/*
+	Resume lets a paused run go on.
+*/
+func (control *Controller) Resume() {
+	control.update(func() {
+		control.paused = false
+		control.steps = 0
+	})
+}
+
+/*
+	Step lets a paused run go on for n more rounds.
+*/
+func (control *Controller) Step(n int) {
+	control.update(func() { control.steps += n })
+}
+
+/*
+	SetDelay throttles the run, it waits delay between two rounds.
+*/
+func (control *Controller) SetDelay(delay time.Duration) {
+	control.update(func() { control.delay = delay })
+}
+
+/*
+	Paused reports whether the run is paused.
+*/
+func (control *Controller) Paused() bool {
+	control.mu.Lock()
+	defer control.mu.Unlock()
+	return control.paused
+}
+
+/*
+	Inspect runs inspect while the simulation can be read safely from another goroutine and waits for it: between two
+	rounds of a run, or straight away before the run starts and once it is over.
+*/
+func (control *Controller) Inspect(inspect func()) {
+	control.mu.Lock()
+	if !control.running {
+		defer control.mu.Unlock()
+		inspect()
+		return
+	}
+	done := make(chan struct{})
+	control.inspections = append(control.inspections, func() {
+		defer close(done)
+		inspect()
+	})
+	close(control.changed)
+	control.changed = make(chan struct{})
+	control.mu.Unlock()
+	<-done
+}
+
+/*
+	inspectLocked runs the inspections waiting, if any, it must be called with the lock held and returns with it released.
+	It reports whether there were inspections.
+*/
+func (control *Controller) inspectLocked() bool {
+	inspections := control.inspections
+	control.inspections = nil
+	control.mu.Unlock()
+	for _, inspect := range inspections {
+		inspect()
+	}
+	return len(inspections) > 0
+}
+
+/*
+	update changes the controller and wakes up the run waiting on it.
+*/
+func (control *Controller) update(change func()) {
+	control.mu.Lock()
+	defer control.mu.Unlock()
+	change()
+	close(control.changed)
+	control.changed = make(chan struct{})
+}
+
+/*
+	wait blocks until the next round may run, or the context is done. The inspections are run while waiting.
+*/
+func (control *Controller) wait(ctx context.Context, first bool) error {
+	control.mu.Lock()
+	delay := control.delay
+	control.mu.Unlock()
+	if delay > 0 && !first {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		for waiting := true; waiting; {
+			control.mu.Lock()
+			changed := control.changed
+			if control.inspectLocked() {
+				continue
+			}
+			select {
+			case <-timer.C:
+				waiting = false
+			case <-changed:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+
+	for {
+		control.mu.Lock()
+		if len(control.inspections) > 0 {
+			control.inspectLocked()
+			continue
+		}
+		if !control.paused {
+			control.mu.Unlock()
+			return ctx.Err()
+		}
+		if control.steps > 0 {
+			control.steps--
+			control.mu.Unlock()
+			return ctx.Err()
+		}
+		changed := control.changed
+		control.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+/*
+	RunControlled runs the attack like Start, but waits for the controller before every round.
+	It stops early with the error of the context once the context is done.
+	Other goroutines read the simulation while it runs with Controller.Inspect.
+*/
+func (sim *Simulation) RunControlled(ctx context.Context, control *Controller) error {
+	control.mu.Lock()
+	control.running = true
+	control.mu.Unlock()
+	defer func() {
+		// the inspections still waiting are run once the run is over
+		control.mu.Lock()
+		control.running = false
+		control.inspectLocked()
+	}()
+
+	for first := true; !sim.Ended(); first = false {
+		if err := control.wait(ctx, first); err != nil {
+			return err
+		}
+		sim.Step()
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package simulation
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestSimulation_RunControlled(t *testing.T) {
+	// a single alien never meets anybody, the event of the last round keeps the run out of a stalemate until it is cancelled
+	sim, err := NewSimulation(1000000, 1, "", "", nil, zap.NewNop())
+	assert.NoError(t, err)
+	sim.Output = io.Discard
+	sim.SetSeed(5)
+	assert.NoError(t, sim.LoadWorld(strings.NewReader("Foo east=Bar")))
+	assert.NoError(t, sim.LoadAliens(strings.NewReader("A")))
+	assert.NoError(t, sim.Schedule(ScheduledEvent{Round: 1000000, Action: ActionLand, City: "Foo", Count: 1}))
+
+	rounds := make(chan int, 100)
+	sim.OnRound(func(sim *Simulation) { rounds <- sim.Round })
+	ctx, cancel := context.WithCancel(context.Background())
+	control := NewController(true)
+	done := make(chan error)
+	go func() { done <- sim.RunControlled(ctx, control) }()
+
+	noRound := func() {
+		select {
+		case round := <-rounds:
+			t.Fatalf("round %d ran while paused", round)
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+
+	noRound()
+	control.Step(2)
+	assert.Equal(t, 1, <-rounds)
+	assert.Equal(t, 2, <-rounds)
+	noRound()
+
+	control.Resume()
+	assert.False(t, control.Paused())
+	assert.Equal(t, 3, <-rounds)
+	control.Pause()
+	// the rounds which were already running finish
+	for drained := false; !drained; {
+		select {
+		case <-rounds:
+		case <-time.After(20 * time.Millisecond):
+			drained = true
+		}
+	}
+	noRound()
+
+	cancel()
+	assert.Equal(t, context.Canceled, <-done)
+}
+
+func TestController_Inspect(t *testing.T) {
+	sim, err := NewSimulation(1000000, 1, "", "", nil, zap.NewNop())
+	assert.NoError(t, err)
+	sim.Output = io.Discard
+	sim.SetSeed(5)
+	assert.NoError(t, sim.LoadWorld(strings.NewReader("Foo east=Bar")))
+	assert.NoError(t, sim.LoadAliens(strings.NewReader("A")))
+	assert.NoError(t, sim.Schedule(ScheduledEvent{Round: 1000000, Action: ActionLand, City: "Foo", Count: 1}))
+
+	rounds := make(chan int, 100)
+	sim.OnRound(func(sim *Simulation) { rounds <- sim.Round })
+	ctx, cancel := context.WithCancel(context.Background())
+	control := NewController(true)
+	round := func() int {
+		seen := -1
+		control.Inspect(func() { seen = sim.Round })
+		return seen
+	}
+	// the run has not started yet
+	assert.Equal(t, 0, round())
+
+	done := make(chan error)
+	go func() { done <- sim.RunControlled(ctx, control) }()
+	control.Step(1)
+	assert.Equal(t, 1, <-rounds)
+	// the paused run is woken up to inspect it
+	assert.Equal(t, 1, round())
+
+	// the run waiting between two rounds is woken up as well
+	control.SetDelay(time.Hour)
+	control.Resume()
+	assert.Equal(t, 2, <-rounds)
+	assert.Equal(t, 2, round())
+
+	cancel()
+	assert.Equal(t, context.Canceled, <-done)
+	assert.Equal(t, 2, round())
+}
@@ -0,0 +1,205 @@
+package simulation
+
+import (
+	"fmt"
+)
+
+const (
+	// ActionDestroyRoad removes the road between City and To in both directions
+	ActionDestroyRoad = "destroy-road"
+	// ActionDestroyCity destroys City together with every alien in it
+	ActionDestroyCity = "destroy-city"
+	// ActionLand lands Count new aliens in City
+	ActionLand = "land"
+)
+
+const (
+	// EventArrive an alien chose the city it attacks first
+	EventArrive = "arrive"
+	// EventMove an alien moved from City to To
+	EventMove = "move"
+	// EventStay an alien decided to stay in City
+	EventStay = "stay"
+	// EventTrapped an alien is trapped in City, all its roads are destroyed
+	EventTrapped = "trapped"
+	// EventFight the Aliens fought and destroyed City
+	EventFight = "fight"
+	// EventLand an alien landed in City through a scheduled event
+	EventLand = "land"
+	// EventScheduled a scheduled event was injected
+	EventScheduled = "scheduled"
+)
+
+/*
+	Event is something which happened during a round of attack.
+	Alien and Aliens hold the names the aliens are displayed with, AlienID and AlienIDs identify them.
+*/
+type Event struct {
+	Round     int             `json:"round"`
+	Kind      string          `json:"kind"`
+	Alien     string          `json:"alien,omitempty"`
+	AlienID   int             `json:"alienId,omitempty"`
+	City      string          `json:"city,omitempty"`
+	To        string          `json:"to,omitempty"`
+	Aliens    []string        `json:"aliens,omitempty"`
+	AlienIDs  []int           `json:"alienIds,omitempty"`
+	Scheduled *ScheduledEvent `json:"scheduled,omitempty"`
+}
+
+/*
+	String describes the event in human readable format.
+*/
+func (event Event) String() string {
+	switch event.Kind {
+	case EventArrive:
+		return fmt.Sprintf("%s arrived in %s", event.Alien, event.City)
+	case EventMove:
+		return fmt.Sprintf("%s moved from %s to %s", event.Alien, event.City, event.To)
+	case EventStay:
+		return fmt.Sprintf("%s stayed in %s", event.Alien, event.City)
+	case EventTrapped:
+		return fmt.Sprintf("%s is trapped in %s", event.Alien, event.City)
+	case EventFight:
+		return fmt.Sprintf("%s was destroyed by %s", event.City, joinNames(event.Aliens))
+	case EventLand:
+		return fmt.Sprintf("%s landed in %s", event.Alien, event.City)
+	case EventScheduled:
+		if event.Scheduled != nil {
+			return fmt.Sprintf("scheduled event %s", event.Scheduled)
+		}
+	}
+	return event.Kind
+}
+
+/*
+	ScheduledEvent is an action injected into the simulation at a given round.
+	Events run after the aliens moved and before they fight, so aliens landing in an occupied city fight straight away.
+*/
+type ScheduledEvent struct {
+	Round  int    `json:"round"`
+	Action string `json:"action"`
+	City   string `json:"city"`
+	To     string `json:"to,omitempty"`
+	Count  int    `json:"count,omitempty"`
+}
+
+/*
+	String describes the event in human readable format.
+*/
+func (event ScheduledEvent) String() string {
+	switch event.Action {
+	case ActionDestroyRoad:
+		return fmt.Sprintf("round %d: destroy road %s-%s", event.Round, event.City, event.To)
+	case ActionDestroyCity:
+		return fmt.Sprintf("round %d: destroy city %s", event.Round, event.City)
+	case ActionLand:
+		return fmt.Sprintf("round %d: %d aliens land at %s", event.Round, event.Count, event.City)
+	}
+	return fmt.Sprintf("round %d: %s", event.Round, event.Action)
+}
+
+/*
+	Validate checks the event is well formed, it does not check the cities exist in the world.
+*/
+func (event ScheduledEvent) Validate() error {
+	if event.Round < 1 {
+		return fmt.Errorf("Event %q must be scheduled for round 1 or later", event)
+	}
+	if event.City == "" {
+		return fmt.Errorf("Event %q has no city", event)
+	}
+	switch event.Action {
+	case ActionDestroyRoad:
+		if event.To == "" {
+			return fmt.Errorf("Event %q has no road end", event)
+		}
+	case ActionDestroyCity:
+	case ActionLand:
+		if event.Count < 1 {
+			return fmt.Errorf("Event %q must land at least one alien", event)
+		}
+	default:
+		return fmt.Errorf("Unknown event action %q", event.Action)
+	}
+	return nil
+}
+
+/*
+	Schedule records an event to be injected at its round.
+*/
+func (sim *Simulation) Schedule(event ScheduledEvent) error {
+	if err := event.Validate(); err != nil {
+		return err
+	}
+	sim.ScheduledEvents = append(sim.ScheduledEvents, event)
+	return nil
+}
+
+/*
+	applyScheduledEvents injects all the events scheduled for the current round, in the order they were scheduled.
+*/
+func (sim *Simulation) applyScheduledEvents() {
+	for _, event := range sim.ScheduledEvents {
+		if event.Round != sim.Round {
+			continue
+		}
+		if err := sim.applyEvent(event); err != nil {
+			fmt.Fprintf(sim.out(), "Scheduled event skipped, Reason: %s\n", err.Error())
+			continue
+		}
+		fmt.Fprintf(sim.out(), "Scheduled event %s\n", event)
+		applied := event
+		sim.emit(Event{Kind: EventScheduled, City: event.City, To: event.To, Scheduled: &applied})
+	}
+}
+
+/*
+	applyEvent injects a single event into the current world.
+*/
+func (sim *Simulation) applyEvent(event ScheduledEvent) error {
+	if _, ok := sim.World[event.City]; !ok {
+		return fmt.Errorf("the city %s does not exist anymore", event.City)
+	}
+	switch event.Action {
+	case ActionDestroyRoad:
+		if _, ok := sim.World[event.To]; !ok {
+			return fmt.Errorf("the city %s does not exist anymore", event.To)
+		}
+		if !sim.removeRoad(event.City, event.To) {
+			return fmt.Errorf("there is no road between %s and %s", event.City, event.To)
+		}
+	case ActionDestroyCity:
+		sim.burryDeadAliens(sim.CityAlienMapping[event.City])
+		sim.removeDestroyedCities([]string{event.City})
+	case ActionLand:
+		for count := 0; count < event.Count; count++ {
+			sim.landed++
+			alien := sim.AddAlien(fmt.Sprintf("Lander%d", sim.landed))
+			sim.AlienCityMapping[alien.ID] = event.City
+			sim.CityAlienMapping[event.City] = append(sim.CityAlienMapping[event.City], alien.ID)
+			sim.emit(Event{Kind: EventLand, Alien: alien.String(), AlienID: alien.ID, City: event.City})
+		}
+	default:
+		return fmt.Errorf("unknown event action %q", event.Action)
+	}
+	return nil
+}
+
+/*
+	removeRoad removes the road between two cities in both directions, reports if any road was removed.
+*/
+func (sim *Simulation) removeRoad(from, to string) bool {
+	sim.worldChanged()
+	removed := false
+	for _, pair := range [][2]string{{from, to}, {to, from}} {
+		links := sim.World[pair[0]]
+		for idx := len(links) - 1; idx >= 0; idx-- {
+			if links[idx].Name == pair[1] {
+				links = append(links[:idx], links[idx+1:]...)
+				removed = true
+			}
+		}
+		sim.World[pair[0]] = links
+	}
+	return removed
+}
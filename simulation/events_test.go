@@ -0,0 +1,125 @@
+package simulation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimulation_applyEvent(t *testing.T) {
+	newWorld := func() map[string][]*City {
+		return map[string][]*City{
+			"Foo": {
+				NewCityWithDirections("Lee", "north"),
+				NewCityWithDirections("Bar", "south"),
+			},
+			"Lee": {
+				NewCityWithDirections("Foo", "south"),
+			},
+			"Bar": {
+				NewCityWithDirections("Foo", "north"),
+			},
+		}
+	}
+	tests := []struct {
+		name                 string
+		event                ScheduledEvent
+		wantErr              bool
+		wantWorld            map[string]int
+		wantAlienCityMapping map[int]string
+	}{
+		{
+			name:                 "Destroy road",
+			event:                ScheduledEvent{Round: 1, Action: ActionDestroyRoad, City: "Foo", To: "Bar"},
+			wantWorld:            map[string]int{"Foo": 1, "Lee": 1, "Bar": 0},
+			wantAlienCityMapping: map[int]string{1: "Foo"},
+		},
+		{
+			name:                 "Destroy missing road",
+			event:                ScheduledEvent{Round: 1, Action: ActionDestroyRoad, City: "Lee", To: "Bar"},
+			wantErr:              true,
+			wantWorld:            map[string]int{"Foo": 2, "Lee": 1, "Bar": 1},
+			wantAlienCityMapping: map[int]string{1: "Foo"},
+		},
+		{
+			name:                 "Destroy city with alien",
+			event:                ScheduledEvent{Round: 1, Action: ActionDestroyCity, City: "Foo"},
+			wantWorld:            map[string]int{"Lee": 0, "Bar": 0},
+			wantAlienCityMapping: map[int]string{},
+		},
+		{
+			name:                 "Land aliens",
+			event:                ScheduledEvent{Round: 1, Action: ActionLand, City: "Lee", Count: 2},
+			wantWorld:            map[string]int{"Foo": 2, "Lee": 1, "Bar": 1},
+			wantAlienCityMapping: map[int]string{1: "Foo", 2: "Lee", 3: "Lee"},
+		},
+		{
+			name:                 "Land aliens in unknown city",
+			event:                ScheduledEvent{Round: 1, Action: ActionLand, City: "Mee", Count: 2},
+			wantErr:              true,
+			wantWorld:            map[string]int{"Foo": 2, "Lee": 1, "Bar": 1},
+			wantAlienCityMapping: map[int]string{1: "Foo"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sim := &Simulation{
+				World:            newWorld(),
+				Aliens:           []*Alien{NewAlien(1, "Alien0")},
+				LastAlienID:      1,
+				Cities:           []*City{NewCity("Foo"), NewCity("Lee"), NewCity("Bar")},
+				AlienCityMapping: map[int]string{1: "Foo"},
+				CityAlienMapping: map[string][]int{"Foo": {1}, "Lee": {}, "Bar": {}},
+			}
+			if err := sim.applyEvent(tt.event); (err != nil) != tt.wantErr {
+				t.Errorf("Simulation.applyEvent() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			assert.Equal(t, len(tt.wantWorld), len(sim.World))
+			for city, links := range tt.wantWorld {
+				assert.Equal(t, links, len(sim.World[city]))
+			}
+			assert.Equal(t, tt.wantAlienCityMapping, sim.AlienCityMapping)
+			assert.Equal(t, len(tt.wantAlienCityMapping), len(sim.Aliens))
+		})
+	}
+}
+
+func TestScheduledEvent_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		event   ScheduledEvent
+		wantErr bool
+	}{
+		{
+			name:  "Valid road destruction",
+			event: ScheduledEvent{Round: 5, Action: ActionDestroyRoad, City: "Foo", To: "Bar"},
+		},
+		{
+			name:    "Road without end",
+			event:   ScheduledEvent{Round: 5, Action: ActionDestroyRoad, City: "Foo"},
+			wantErr: true,
+		},
+		{
+			name:    "Round zero",
+			event:   ScheduledEvent{Round: 0, Action: ActionDestroyCity, City: "Foo"},
+			wantErr: true,
+		},
+		{
+			name:    "Landing nobody",
+			event:   ScheduledEvent{Round: 10, Action: ActionLand, City: "Bee"},
+			wantErr: true,
+		},
+		{
+			name:    "Unknown action",
+			event:   ScheduledEvent{Round: 10, Action: "nuke", City: "Bee"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.event.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("ScheduledEvent.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package simulation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// gap left between two connected components of the world on the grid
+const componentGap = 2
+
+/*
+	Point is a position on the grid, X grows to the east and Y grows to the south like screen coordinates.
+*/
+type Point struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+/*
+	LayoutConflict is a contradiction between the directions of the roads.
+*/
+type LayoutConflict struct {
+	City   string
+	Other  string
+	Reason string
+}
+
+func (conflict LayoutConflict) Error() string {
+	return conflict.Reason
+}
+
+/*
+	Layout places the cities of a world on a grid from the compass directions of their roads.
+*/
+type Layout struct {
+	Positions  map[string]Point
+	Components [][]string
+	Conflicts  []LayoutConflict
+	Width      int
+	Height     int
+}
+
+// offsets of a step in each compass direction
+var directionOffsets = map[string]Point{
+	"north": {X: 0, Y: -1},
+	"south": {X: 0, Y: 1},
+	"east":  {X: 1, Y: 0},
+	"west":  {X: -1, Y: 0},
+}
+
+/*
+	InferLayout gives every city of the world grid coordinates, one connected component at a time.
+	Cities are visited in the order of cities, cities of the world missing from it are visited in name order.
+	Contradictions do not stop the layout, the city keeps the first position it was given and the conflict is reported.
+*/
+func InferLayout(world map[string][]*City, cities []*City) *Layout {
+	// roads are followed in both directions, even where the world only holds one of them
+	type road struct {
+		to     string
+		offset Point
+		label  string
+	}
+	order := make([]string, 0, len(world))
+	seen := make(map[string]bool, len(world))
+	for _, city := range cities {
+		if _, ok := world[city.Name]; ok && !seen[city.Name] {
+			order = append(order, city.Name)
+			seen[city.Name] = true
+		}
+	}
+	remaining := make([]string, 0)
+	for city := range world {
+		if !seen[city] {
+			remaining = append(remaining, city)
+		}
+	}
+	sort.Strings(remaining)
+	order = append(order, remaining...)
+
+	roads := make(map[string][]road, len(world))
+	known := make(map[[2]string]Point)
+	layout := &Layout{Positions: make(map[string]Point, len(world))}
+	for _, from := range order {
+		for _, link := range world[from] {
+			offset, ok := directionOffsets[strings.ToLower(link.Direction)]
+			if !ok {
+				layout.Conflicts = append(layout.Conflicts, LayoutConflict{
+					City:   from,
+					Other:  link.Name,
+					Reason: fmt.Sprintf("The road from %s to %s has the unknown direction %q", from, link.Name, link.Direction),
+				})
+				continue
+			}
+			label := fmt.Sprintf("%s is %s of %s", link.Name, link.Direction, from)
+			if previous, ok := known[[2]string{from, link.Name}]; ok {
+				if previous != offset {
+					layout.Conflicts = append(layout.Conflicts, LayoutConflict{
+						City:   from,
+						Other:  link.Name,
+						Reason: fmt.Sprintf("The roads between %s and %s disagree, %s", from, link.Name, label),
+					})
+				}
+				continue
+			}
+			known[[2]string{from, link.Name}] = offset
+			known[[2]string{link.Name, from}] = Point{X: -offset.X, Y: -offset.Y}
+			roads[from] = append(roads[from], road{to: link.Name, offset: offset, label: label})
+			roads[link.Name] = append(roads[link.Name], road{to: from, offset: Point{X: -offset.X, Y: -offset.Y}, label: label})
+		}
+	}
+
+	placed := make(map[string]bool, len(world))
+	offsetX := 0
+	for _, start := range order {
+		if placed[start] {
+			continue
+		}
+		// breadth first walk of the component, positions are relative to its first city
+		positions := map[string]Point{start: {}}
+		component := []string{start}
+		placed[start] = true
+		for next := 0; next < len(component); next++ {
+			from := component[next]
+			for _, road := range roads[from] {
+				expected := Point{X: positions[from].X + road.offset.X, Y: positions[from].Y + road.offset.Y}
+				if !placed[road.to] {
+					placed[road.to] = true
+					positions[road.to] = expected
+					component = append(component, road.to)
+					continue
+				}
+				if position := positions[road.to]; position != expected && from < road.to {
+					layout.Conflicts = append(layout.Conflicts, LayoutConflict{
+						City:   road.to,
+						Other:  from,
+						Reason: fmt.Sprintf("The roads disagree on the position of %s: %s, but it is already placed at (%d,%d) instead of (%d,%d)", road.to, road.label, position.X, position.Y, expected.X, expected.Y),
+					})
+				}
+			}
+		}
+
+		// shift the component next to the previous one
+		minX, minY, maxX, maxY := 0, 0, 0, 0
+		for _, position := range positions {
+			minX, minY = minInt(minX, position.X), minInt(minY, position.Y)
+			maxX, maxY = maxInt(maxX, position.X), maxInt(maxY, position.Y)
+		}
+		occupied := make(map[Point]string, len(component))
+		for _, city := range component {
+			position := Point{X: positions[city].X - minX + offsetX, Y: positions[city].Y - minY}
+			layout.Positions[city] = position
+			if other, ok := occupied[position]; ok {
+				layout.Conflicts = append(layout.Conflicts, LayoutConflict{
+					City:   city,
+					Other:  other,
+					Reason: fmt.Sprintf("The cities %s and %s are both placed at (%d,%d)", other, city, position.X, position.Y),
+				})
+				continue
+			}
+			occupied[position] = city
+		}
+		layout.Components = append(layout.Components, component)
+		layout.Width = offsetX + maxX - minX + 1
+		layout.Height = maxInt(layout.Height, maxY-minY+1)
+		offsetX = layout.Width + componentGap
+	}
+	return layout
+}
+
+/*
+	InferLayout infers the layout of the current world and stores the coordinates on its cities.
+*/
+func (sim *Simulation) InferLayout() *Layout {
+	layout := InferLayout(sim.World, sim.Cities)
+	for _, city := range sim.Cities {
+		if position, ok := layout.Positions[city.Name]; ok {
+			city.X, city.Y, city.Positioned = position.X, position.Y, true
+		}
+	}
+	for _, links := range sim.World {
+		for _, link := range links {
+			if position, ok := layout.Positions[link.Name]; ok {
+				link.X, link.Y, link.Positioned = position.X, position.Y, true
+			}
+		}
+	}
+	return layout
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,90 @@
+package simulation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInferLayout(t *testing.T) {
+	tests := []struct {
+		name           string
+		worldMap       string
+		world          map[string][]*City
+		wantPositions  map[string]Point
+		wantComponents int
+		wantConflicts  int
+	}{
+		{
+			name:     "Cross around a city",
+			worldMap: "Foo north=Bar west=Baz south=Qu-ux east=Lee",
+			wantPositions: map[string]Point{
+				"Foo":   {X: 1, Y: 1},
+				"Bar":   {X: 1, Y: 0},
+				"Baz":   {X: 0, Y: 1},
+				"Qu-ux": {X: 1, Y: 2},
+				"Lee":   {X: 2, Y: 1},
+			},
+			wantComponents: 1,
+		},
+		{
+			name:     "Disconnected components are placed side by side",
+			worldMap: "Foo north=Bar\nLee east=Mee\nalone",
+			wantPositions: map[string]Point{
+				"Foo":   {X: 0, Y: 1},
+				"Bar":   {X: 0, Y: 0},
+				"Lee":   {X: 3, Y: 0},
+				"Mee":   {X: 4, Y: 0},
+				"alone": {X: 7, Y: 0},
+			},
+			wantComponents: 3,
+		},
+		{
+			name:           "Chain of north roads looping back",
+			worldMap:       "A north=B\nB north=C\nC north=A",
+			wantComponents: 1,
+			wantConflicts:  1,
+		},
+		{
+			name:           "Two cities forced into the same position",
+			worldMap:       "A north=B east=C\nB east=D\nC north=E",
+			wantComponents: 1,
+			wantConflicts:  1,
+		},
+		{
+			name: "Roads disagreeing on their direction",
+			world: map[string][]*City{
+				"A": {NewCityWithDirections("B", "north")},
+				"B": {NewCityWithDirections("A", "north")},
+			},
+			wantComponents: 1,
+			wantConflicts:  1,
+		},
+		{
+			name:           "Square is consistent",
+			worldMap:       "A north=B east=C\nB east=D\nC north=D",
+			wantComponents: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sim := &Simulation{World: make(map[string][]*City)}
+			if tt.world != nil {
+				sim.World = tt.world
+			}
+			assert.NoError(t, sim.LoadWorld(strings.NewReader(tt.worldMap)))
+			layout := sim.InferLayout()
+			for city, position := range tt.wantPositions {
+				assert.Equal(t, position, layout.Positions[city], city)
+			}
+			assert.Equal(t, len(sim.World), len(layout.Positions))
+			assert.Equal(t, tt.wantComponents, len(layout.Components))
+			assert.Equal(t, tt.wantConflicts, len(layout.Conflicts), layout.Conflicts)
+			for _, city := range sim.Cities {
+				assert.True(t, city.Positioned)
+				assert.Equal(t, layout.Positions[city.Name], Point{X: city.X, Y: city.Y})
+			}
+		})
+	}
+}
@@ -0,0 +1,272 @@
+package simulation
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"runtime"
+
+	"github.com/rvsingh011/alien-invasion/utils"
+)
+
+// LoadProgressLines is the number of lines of a world file read between two progress reports
+const LoadProgressLines = 1000000
+
+// number of city objects allocated at once while loading a world
+const citySlab = 4096
+
+// bytes of world file per city assumed to size the tables before loading a world file
+const bytesPerCity = 64
+
+/*
+	LoadProgress tells how far the loading of a world file went.
+	HeapAlloc is the memory allocated on the heap by the whole program when the progress was reported.
+*/
+type LoadProgress struct {
+	Lines     int
+	Bytes     int64
+	Cities    int
+	Roads     int
+	HeapAlloc uint64
+	Done      bool
+}
+
+/*
+	OnLoadProgress registers a hook called every LoadProgressLines lines while a world is loaded, and once the world is loaded.
+*/
+func (sim *Simulation) OnLoadProgress(hook func(progress LoadProgress)) {
+	sim.loadHooks = append(sim.loadHooks, hook)
+}
+
+/*
+	worldLoader streams a world file into a simulation.
+	City names are interned, every road to a city shares the name of the city, and lines can be of any length.
+	A road listed twice by a city is found in constant time with the marks in seen: seen[c] == mark when the city being read lists c.
+*/
+type worldLoader struct {
+	sim *Simulation
+
+	index map[string]int32
+	names []string
+	roads [][]*City
+
+	seen   []uint32
+	mark   uint32
+	marked int32
+
+	// interned directions and their opposite directions
+	directions map[string]string
+	opposites  map[string]string
+
+	slab     []City
+	progress LoadProgress
+}
+
+/*
+	newWorldLoader prepares a loader adding to the world the simulation already has, cities is the number of cities expected.
+*/
+func newWorldLoader(sim *Simulation, cities int) *worldLoader {
+	loader := &worldLoader{
+		sim:        sim,
+		index:      make(map[string]int32, len(sim.World)+cities),
+		marked:     -1,
+		directions: make(map[string]string),
+		opposites:  make(map[string]string),
+	}
+	for _, city := range sim.Cities {
+		loader.intern(city.Name, sim.World[city.Name])
+	}
+	for name, roads := range sim.World {
+		if _, ok := loader.index[name]; !ok {
+			loader.intern(name, roads)
+		}
+	}
+	loader.progress.Cities = len(sim.Cities)
+	return loader
+}
+
+/*
+	intern gives the next ID to a city.
+*/
+func (loader *worldLoader) intern(name string, roads []*City) int32 {
+	id := int32(len(loader.names))
+	loader.index[name] = id
+	loader.names = append(loader.names, name)
+	loader.roads = append(loader.roads, roads)
+	loader.seen = append(loader.seen, 0)
+	loader.progress.Roads += len(roads)
+	return id
+}
+
+/*
+	city returns the ID of a city, the city is added to the world if it is not known yet.
+*/
+func (loader *worldLoader) city(name []byte) (int32, bool) {
+	if id, ok := loader.index[string(name)]; ok {
+		return id, false
+	}
+	id := loader.intern(string(name), make([]*City, 0))
+	loader.sim.Cities = append(loader.sim.Cities, loader.newCity(loader.names[id], ""))
+	loader.progress.Cities++
+	return id, true
+}
+
+/*
+	newCity takes a city object from the current slab, so that millions of roads do not mean millions of allocations.
+*/
+func (loader *worldLoader) newCity(name, direction string) *City {
+	if len(loader.slab) == 0 {
+		loader.slab = make([]City, citySlab)
+	}
+	city := &loader.slab[0]
+	loader.slab = loader.slab[1:]
+	city.Name = name
+	city.Direction = direction
+	return city
+}
+
+/*
+	direction returns the interned direction and its opposite.
+*/
+func (loader *worldLoader) direction(text []byte) (string, string) {
+	direction, ok := loader.directions[string(text)]
+	if !ok {
+		direction = string(text)
+		loader.directions[direction] = direction
+		loader.opposites[direction] = utils.GetOppositeDirection(direction)
+	}
+	return direction, loader.opposites[direction]
+}
+
+/*
+	markRoads marks the cities a city has roads to, the marks stay valid as long as lines of the same city follow each other.
+*/
+func (loader *worldLoader) markRoads(city int32) {
+	if loader.marked == city {
+		return
+	}
+	loader.mark++
+	if loader.mark == 0 {
+		// the marks wrapped around, older marks could be taken for new ones
+		for idx := range loader.seen {
+			loader.seen[idx] = 0
+		}
+		loader.mark = 1
+	}
+	loader.marked = city
+	for _, road := range loader.roads[city] {
+		// a road of a world already played can lead to a destroyed city
+		if target, ok := loader.index[road.Name]; ok {
+			loader.seen[target] = loader.mark
+		}
+	}
+}
+
+/*
+	addLine adds the city and the roads of a line of the world file.
+	Like the first loader of the world, a road to a city not known yet adds the road back from that city, and a city only
+	keeps the first road to another city.
+*/
+func (loader *worldLoader) addLine(line []byte) error {
+	line = bytes.TrimSpace(line)
+	end := bytes.IndexByte(line, ' ')
+	if end < 0 {
+		end = len(line)
+	}
+	source, _ := loader.city(line[:end])
+	loader.markRoads(source)
+
+	for end < len(line) {
+		line = line[end+1:]
+		if end = bytes.IndexByte(line, ' '); end < 0 {
+			end = len(line)
+		}
+		road := bytes.TrimSpace(line[:end])
+		if len(road) == 0 {
+			continue
+		}
+		split := bytes.IndexByte(road, '=')
+		if split < 0 {
+			return fmt.Errorf("Invalid road %q on line %d of the world map, expected direction=city", road, loader.progress.Lines)
+		}
+		name := road[split+1:]
+		if next := bytes.IndexByte(name, '='); next >= 0 {
+			name = name[:next]
+		}
+
+		target, created := loader.city(name)
+		if loader.seen[target] == loader.mark {
+			continue
+		}
+		loader.seen[target] = loader.mark
+		direction, opposite := loader.direction(road[:split])
+		loader.roads[source] = append(loader.roads[source], loader.newCity(loader.names[target], direction))
+		loader.progress.Roads++
+		if created {
+			loader.roads[target] = append(loader.roads[target], loader.newCity(loader.names[source], opposite))
+			loader.progress.Roads++
+		}
+	}
+	return nil
+}
+
+/*
+	load reads the world file line by line, a line is never cut however long it is.
+*/
+func (loader *worldLoader) load(worldMap io.Reader) error {
+	reader := bufio.NewReaderSize(worldMap, 1<<16)
+	var long []byte
+	for {
+		line, err := reader.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			long = append(long[:0], line...)
+			for err == bufio.ErrBufferFull {
+				line, err = reader.ReadSlice('\n')
+				long = append(long, line...)
+			}
+			line = long
+		}
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if len(line) > 0 {
+			loader.progress.Lines++
+			loader.progress.Bytes += int64(len(line))
+			if err := loader.addLine(line); err != nil {
+				return err
+			}
+			if loader.progress.Lines%LoadProgressLines == 0 {
+				loader.report(false)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+
+	if len(loader.sim.World) == 0 {
+		loader.sim.World = make(map[string][]*City, len(loader.names))
+	}
+	for id, name := range loader.names {
+		loader.sim.World[name] = loader.roads[id]
+	}
+	loader.report(true)
+	return nil
+}
+
+/*
+	report calls the progress hooks of the simulation.
+*/
+func (loader *worldLoader) report(done bool) {
+	if len(loader.sim.loadHooks) == 0 {
+		return
+	}
+	var memory runtime.MemStats
+	runtime.ReadMemStats(&memory)
+	loader.progress.HeapAlloc = memory.HeapAlloc
+	loader.progress.Done = done
+	for _, hook := range loader.sim.loadHooks {
+		hook(loader.progress)
+	}
+}
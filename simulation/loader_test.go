@@ -0,0 +1,143 @@
+package simulation
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// roads lists the roads of every city of the world as direction=city
+func roads(sim *Simulation) map[string][]string {
+	world := make(map[string][]string, len(sim.World))
+	for city, links := range sim.World {
+		world[city] = make([]string, 0, len(links))
+		for _, link := range links {
+			world[city] = append(world[city], link.Direction+"="+link.Name)
+		}
+	}
+	return world
+}
+
+func TestSimulation_LoadWorld(t *testing.T) {
+	// a line of about 300KB
+	star := make([]string, 0, 20000)
+	for idx := 0; idx < 20000; idx++ {
+		star = append(star, fmt.Sprintf("road%d=City%d", idx, idx))
+	}
+
+	tests := []struct {
+		name       string
+		worldMap   string
+		wantCities []string
+		wantRoads  map[string][]string
+		wantErr    bool
+	}{
+		{
+			name:       "Roads back from new cities",
+			worldMap:   "Foo north=Bar west=Baz\nBar west=Bee\n",
+			wantCities: []string{"Foo", "Bar", "Baz", "Bee"},
+			wantRoads: map[string][]string{
+				"Foo": {"north=Bar", "west=Baz"},
+				"Bar": {"south=Foo", "west=Bee"},
+				"Baz": {"east=Foo"},
+				"Bee": {"east=Bar"},
+			},
+		},
+		{
+			name:       "Only the first road to a city is kept",
+			worldMap:   "Foo north=Bar east=Bar\nBar south=Foo north=Foo\nFoo west=Baz\nBar east=Baz\nFoo south=Bar west=Baz",
+			wantCities: []string{"Foo", "Bar", "Baz"},
+			wantRoads: map[string][]string{
+				"Foo": {"north=Bar", "west=Baz"},
+				"Bar": {"south=Foo", "east=Baz"},
+				"Baz": {"east=Foo"},
+			},
+		},
+		{
+			name:       "Windows line endings and extra spaces",
+			worldMap:   "Foo  north=Bar \r\n  Bar west=Bee\r\n",
+			wantCities: []string{"Foo", "Bar", "Bee"},
+			wantRoads: map[string][]string{
+				"Foo": {"north=Bar"},
+				"Bar": {"south=Foo", "west=Bee"},
+				"Bee": {"east=Bar"},
+			},
+		},
+		{
+			name:       "A blank line is a city without a name",
+			worldMap:   "Foo\n\nBar",
+			wantCities: []string{"Foo", "", "Bar"},
+			wantRoads:  map[string][]string{"Foo": {}, "": {}, "Bar": {}},
+		},
+		{
+			name:       "Line longer than a buffer",
+			worldMap:   "Star " + strings.Join(star, " "),
+			wantCities: append([]string{"Star"}, cityNames(20000)...),
+			wantRoads:  starWorld(star),
+		},
+		{
+			name:     "Road without a direction",
+			worldMap: "Foo north=Bar\nBar Bee",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sim, err := NewSimulation(10, 1, "", "", nil, zap.NewNop())
+			assert.NoError(t, err)
+			err = sim.LoadWorld(strings.NewReader(tt.worldMap))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			cities := make([]string, 0, len(sim.Cities))
+			for _, city := range sim.Cities {
+				cities = append(cities, city.Name)
+			}
+			assert.Equal(t, tt.wantCities, cities)
+			assert.Equal(t, tt.wantRoads, roads(sim))
+		})
+	}
+}
+
+func cityNames(count int) []string {
+	names := make([]string, 0, count)
+	for idx := 0; idx < count; idx++ {
+		names = append(names, fmt.Sprintf("City%d", idx))
+	}
+	return names
+}
+
+// starWorld is the world of a city with a road to every other city, the directions have no opposite
+func starWorld(starRoads []string) map[string][]string {
+	world := map[string][]string{"Star": starRoads}
+	for idx := range starRoads {
+		world[fmt.Sprintf("City%d", idx)] = []string{"=Star"}
+	}
+	return world
+}
+
+func TestSimulation_OnLoadProgress(t *testing.T) {
+	sim, err := NewSimulation(10, 1, "", "", nil, zap.NewNop())
+	assert.NoError(t, err)
+	sim.World["Zork"] = []*City{NewCityWithDirections("Foo", "east")}
+	sim.Cities = append(sim.Cities, NewCity("Zork"))
+	progress := make([]LoadProgress, 0)
+	sim.OnLoadProgress(func(current LoadProgress) {
+		assert.NotZero(t, current.HeapAlloc)
+		current.HeapAlloc = 0
+		progress = append(progress, current)
+	})
+
+	worldMap := "Foo north=Bar west=Baz\n" + strings.Repeat("Bar south=Foo\n", LoadProgressLines)
+	assert.NoError(t, sim.LoadWorld(strings.NewReader(worldMap)))
+	assert.Equal(t, []LoadProgress{
+		{Lines: LoadProgressLines, Bytes: int64(len(worldMap) - len("Bar south=Foo\n")), Cities: 4, Roads: 5},
+		{Lines: LoadProgressLines + 1, Bytes: int64(len(worldMap)), Cities: 4, Roads: 5, Done: true},
+	}, progress)
+	assert.Len(t, sim.World["Zork"], 1)
+}
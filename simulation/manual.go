@@ -0,0 +1,52 @@
+package simulation
+
+import (
+	"fmt"
+)
+
+/*
+	Ended reports whether the attack is over, either all rounds were run or there is nothing left to fight for.
+*/
+func (sim *Simulation) Ended() bool {
+	return sim.Round >= sim.Iterations || !sim.isNextIterationRequired()
+}
+
+/*
+	MoveAlien overrides the decision of an alien and moves it to any city of the world, roads are not required.
+	The alien is found by its name or by its ID prefixed by #, see FindAlien. Aliens meeting in the city fight straight away.
+*/
+func (sim *Simulation) MoveAlien(name, city string) error {
+	if sim.Round == 0 {
+		return fmt.Errorf("The aliens have not arrived yet")
+	}
+	alien, ok := sim.FindAlien(name)
+	if !ok {
+		return fmt.Errorf("There is no alien %s alive", name)
+	}
+	currentCity, ok := sim.AlienCityMapping[alien.ID]
+	if !ok {
+		return fmt.Errorf("The alien %s has not arrived yet", name)
+	}
+	if _, ok := sim.World[city]; !ok {
+		return fmt.Errorf("There is no city %s left", city)
+	}
+	if currentCity == city {
+		return nil
+	}
+	fmt.Fprintf(sim.out(), "The alien %s was moved to %s\n", alien, city)
+	sim.worldChanged()
+	sim.moveAlien(alien, city)
+	sim.fight()
+	return nil
+}
+
+/*
+	DestroyCity destroys a city together with all the aliens in it.
+*/
+func (sim *Simulation) DestroyCity(city string) error {
+	if _, ok := sim.World[city]; !ok {
+		return fmt.Errorf("There is no city %s left", city)
+	}
+	fmt.Fprintf(sim.out(), "The %s was destroyed\n", city)
+	return sim.applyEvent(ScheduledEvent{Round: sim.Round, Action: ActionDestroyCity, City: city})
+}
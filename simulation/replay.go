@@ -0,0 +1,260 @@
+package simulation
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"go.uber.org/zap"
+)
+
+/*
+	replayRound holds the decisions taken during a round of attack.
+	Fights are not recorded, they follow from the positions of the aliens.
+*/
+type replayRound struct {
+	Round     int              `json:"r"`
+	Moves     []replayMove     `json:"m,omitempty"`
+	Scheduled []ScheduledEvent `json:"s,omitempty"`
+}
+
+/*
+	replayMove is an alien, by ID, entering a city.
+*/
+type replayMove struct {
+	Alien int    `json:"a"`
+	City  string `json:"c"`
+}
+
+/*
+	Recorder records a running simulation into a replay file.
+	The file is a gzip compressed stream of json lines, a checkpoint of the initial state followed by one line per round.
+*/
+type Recorder struct {
+	writer  *gzip.Writer
+	encoder *json.Encoder
+	round   replayRound
+	err     error
+}
+
+/*
+	Record starts recording the simulation to w, the recording must be closed once the simulation ended.
+*/
+func (sim *Simulation) Record(w io.Writer) (*Recorder, error) {
+	writer := gzip.NewWriter(w)
+	recorder := &Recorder{writer: writer, encoder: json.NewEncoder(writer)}
+	if err := recorder.encoder.Encode(sim.snapshot()); err != nil {
+		return nil, fmt.Errorf("Error Recording the simulation, Error: %s", err.Error())
+	}
+
+	sim.OnEvent(recorder.record)
+	sim.OnRound(func(sim *Simulation) {
+		recorder.round.Round = sim.Round
+		if err := recorder.encoder.Encode(recorder.round); err != nil && recorder.err == nil {
+			recorder.err = err
+		}
+		recorder.round = replayRound{}
+	})
+	return recorder, nil
+}
+
+/*
+	record keeps the decisions of an event, everything else can be rebuilt from them.
+*/
+func (recorder *Recorder) record(event Event) {
+	switch event.Kind {
+	case EventArrive:
+		recorder.round.Moves = append(recorder.round.Moves, replayMove{Alien: event.AlienID, City: event.City})
+	case EventMove:
+		recorder.round.Moves = append(recorder.round.Moves, replayMove{Alien: event.AlienID, City: event.To})
+	case EventScheduled:
+		recorder.round.Scheduled = append(recorder.round.Scheduled, *event.Scheduled)
+	}
+}
+
+/*
+	Close flushes the recording, it does not close the underlying writer.
+*/
+func (recorder *Recorder) Close() error {
+	if err := recorder.writer.Close(); err != nil && recorder.err == nil {
+		recorder.err = err
+	}
+	if recorder.err != nil {
+		return fmt.Errorf("Error Recording the simulation, Error: %s", recorder.err.Error())
+	}
+	return nil
+}
+
+// replayKeyframeEvery is the number of rounds between two keyframes of a replay
+const replayKeyframeEvery = 100
+
+/*
+	Replay rebuilds the state of a recorded simulation at any round, without drawing from the attack vector.
+*/
+type Replay struct {
+	first  int
+	rounds []replayRound
+	logger *zap.Logger
+
+	// the simulation at the last round sought, stepping forward continues from it
+	current *Simulation
+	// checkpoints of the rounds sought past, the recorded header first, seeking starts from the closest one
+	keyframes []replayKeyframe
+}
+
+/*
+	replayKeyframe is a checkpoint of a replayed simulation at the end of a round.
+*/
+type replayKeyframe struct {
+	round      int
+	checkpoint []byte
+}
+
+/*
+	ReadReplay reads a recording written by a Recorder.
+*/
+func ReadReplay(r io.Reader, logger *zap.Logger) (*Replay, error) {
+	reader, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("Error Reading the replay, Error: %s", err.Error())
+	}
+	defer reader.Close()
+
+	decoder := json.NewDecoder(bufio.NewReader(reader))
+	var header json.RawMessage
+	if err := decoder.Decode(&header); err != nil {
+		return nil, fmt.Errorf("Error Parsing the replay header, Error: %s", err.Error())
+	}
+	var start struct {
+		Round int `json:"round"`
+	}
+	if err := json.Unmarshal(header, &start); err != nil {
+		return nil, fmt.Errorf("Error Parsing the replay header, Error: %s", err.Error())
+	}
+	replay := &Replay{first: start.Round, logger: logger, keyframes: []replayKeyframe{{round: start.Round, checkpoint: header}}}
+	for {
+		var round replayRound
+		if err := decoder.Decode(&round); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("Error Parsing the replay after round %d, Error: %s", replay.LastRound(), err.Error())
+		}
+		replay.rounds = append(replay.rounds, round)
+	}
+	return replay, nil
+}
+
+/*
+	LoadReplay reads a replay file.
+*/
+func LoadReplay(path string, logger *zap.Logger) (*Replay, error) {
+	replayFile, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error Reading the replay file : %s, Error: %s", path, err.Error())
+	}
+	defer replayFile.Close()
+	return ReadReplay(replayFile, logger)
+}
+
+/*
+	FirstRound returns the round the recording started at, before any recorded round was run.
+*/
+func (replay *Replay) FirstRound() int {
+	return replay.first
+}
+
+/*
+	LastRound returns the last recorded round.
+*/
+func (replay *Replay) LastRound() int {
+	if len(replay.rounds) == 0 {
+		return replay.FirstRound()
+	}
+	return replay.rounds[len(replay.rounds)-1].Round
+}
+
+/*
+	Seek rebuilds the simulation as it was at the end of the round.
+	Seeking forward continues from the last round sought, seeking backward rebuilds from the closest keyframe before the
+	round, a keyframe is kept every replayKeyframeEvery rounds replayed.
+	The returned simulation must not be modified, it is reused by the following seeks.
+*/
+func (replay *Replay) Seek(round int) (*Simulation, error) {
+	if round < replay.FirstRound() || round > replay.LastRound() {
+		return nil, fmt.Errorf("Round %d is not recorded, the recording covers rounds %d to %d", round, replay.FirstRound(), replay.LastRound())
+	}
+	closest := replay.keyframes[sort.Search(len(replay.keyframes), func(idx int) bool {
+		return replay.keyframes[idx].round > round
+	})-1]
+	if replay.current == nil || replay.current.Round > round || replay.current.Round < closest.round {
+		var checkpoint Checkpoint
+		if err := json.Unmarshal(closest.checkpoint, &checkpoint); err != nil {
+			return nil, fmt.Errorf("Error Parsing the replay keyframe of round %d, Error: %s", closest.round, err.Error())
+		}
+		// the recorded decisions are replayed, the attack vector is not needed
+		sim, err := checkpoint.rebuild(replay.logger)
+		if err != nil {
+			return nil, err
+		}
+		sim.Output = io.Discard
+		replay.current = sim
+	}
+	next := sort.Search(len(replay.rounds), func(idx int) bool {
+		return replay.rounds[idx].Round > replay.current.Round
+	})
+	for _, recorded := range replay.rounds[next:] {
+		if recorded.Round > round {
+			break
+		}
+		replay.current.replayRound(recorded)
+		if recorded.Round%replayKeyframeEvery == 0 && recorded.Round > replay.keyframes[len(replay.keyframes)-1].round {
+			checkpoint, err := json.Marshal(replay.current.snapshot())
+			if err != nil {
+				return nil, fmt.Errorf("Error Keeping the replay keyframe of round %d, Error: %s", recorded.Round, err.Error())
+			}
+			replay.keyframes = append(replay.keyframes, replayKeyframe{round: recorded.Round, checkpoint: checkpoint})
+		}
+	}
+	return replay.current, nil
+}
+
+/*
+	replayRound runs a recorded round of attack, the decisions are taken from the recording instead of the attack vector.
+*/
+func (sim *Simulation) replayRound(recorded replayRound) {
+	sim.Round = recorded.Round
+	if sim.Round == 1 {
+		for idx := range sim.Cities {
+			sim.CityAlienMapping[sim.Cities[idx].Name] = make([]int, 0)
+		}
+	}
+	aliens := make(map[int]*Alien, len(sim.Aliens))
+	for _, alien := range sim.Aliens {
+		aliens[alien.ID] = alien
+	}
+	for _, move := range recorded.Moves {
+		alien, ok := aliens[move.Alien]
+		if !ok {
+			continue
+		}
+		if _, ok := sim.AlienCityMapping[alien.ID]; ok {
+			sim.moveAlien(alien, move.City)
+		} else {
+			sim.placeAlien(alien, move.City)
+		}
+	}
+	for _, event := range recorded.Scheduled {
+		if err := sim.applyEvent(event); err != nil {
+			fmt.Fprintf(sim.out(), "Scheduled event skipped, Reason: %s\n", err.Error())
+		}
+	}
+	sim.fight()
+
+	for _, hook := range sim.roundHooks {
+		hook(sim)
+	}
+}
@@ -0,0 +1,102 @@
+package simulation
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestReplay_Seek(t *testing.T) {
+	recorded := newSeededSimulation(t, 40, 5)
+	recorded.Output = &bytes.Buffer{}
+	assert.NoError(t, recorded.Schedule(ScheduledEvent{Round: 3, Action: ActionLand, City: "Lee", Count: 2}))
+	var recording bytes.Buffer
+	recorder, err := recorded.Record(&recording)
+	assert.NoError(t, err)
+	assert.NoError(t, recorded.Start())
+	assert.NoError(t, recorder.Close())
+
+	replay, err := ReadReplay(&recording, zap.NewNop())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, replay.FirstRound())
+	assert.Equal(t, recorded.Round, replay.LastRound())
+	assert.Less(t, 3, replay.LastRound())
+
+	tests := []struct {
+		name  string
+		round int
+	}{
+		{name: "Seek the arrival", round: 1},
+		{name: "Seek the landing", round: 3},
+		{name: "Seek the end", round: replay.LastRound()},
+		{name: "Seek backward", round: 2},
+		{name: "Seek forward", round: replay.LastRound() - 1},
+		{name: "Seek the start", round: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reference := newSeededSimulation(t, tt.round, 5)
+			reference.Output = &bytes.Buffer{}
+			assert.NoError(t, reference.Schedule(ScheduledEvent{Round: 3, Action: ActionLand, City: "Lee", Count: 2}))
+			assert.NoError(t, reference.Start())
+
+			sim, err := replay.Seek(tt.round)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tt.round, sim.Round)
+			assert.Equal(t, reference.World, sim.World)
+			assert.Equal(t, reference.Aliens, sim.Aliens)
+			assert.Equal(t, reference.AlienCityMapping, sim.AlienCityMapping)
+		})
+	}
+
+	_, err = replay.Seek(replay.LastRound() + 1)
+	assert.Error(t, err)
+}
+
+func TestReplay_SeekKeyframes(t *testing.T) {
+	// a single alien never fights, a landing after the last round keeps it wandering until then
+	newWanderer := func(iterations int) *Simulation {
+		sim, err := NewSimulation(iterations, 1, "", "", nil, zap.NewNop())
+		assert.NoError(t, err)
+		sim.Output = &bytes.Buffer{}
+		sim.SetSeed(1)
+		assert.NoError(t, sim.LoadWorld(strings.NewReader("Foo north=Bar\nBar east=Baz\nBaz south=Qux\nQux west=Foo")))
+		assert.NoError(t, sim.LoadAliens(strings.NewReader("A")))
+		assert.NoError(t, sim.Schedule(ScheduledEvent{Round: 1000, Action: ActionLand, City: "Foo", Count: 1}))
+		return sim
+	}
+	recorded := newWanderer(350)
+	var recording bytes.Buffer
+	recorder, err := recorded.Record(&recording)
+	assert.NoError(t, err)
+	assert.NoError(t, recorded.Start())
+	assert.NoError(t, recorder.Close())
+
+	replay, err := ReadReplay(&recording, zap.NewNop())
+	assert.NoError(t, err)
+	assert.Equal(t, 350, replay.LastRound())
+
+	for _, round := range []int{350, 120, 99, 301, 200, 0, 250} {
+		reference := newWanderer(round)
+		assert.NoError(t, reference.Start())
+
+		sim, err := replay.Seek(round)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, round, sim.Round)
+		assert.Equal(t, reference.AlienCityMapping, sim.AlienCityMapping)
+		assert.Equal(t, reference.CityAlienMapping, sim.CityAlienMapping)
+	}
+	// the first seek replayed the whole recording and kept its keyframes
+	rounds := make([]int, 0, len(replay.keyframes))
+	for _, keyframe := range replay.keyframes {
+		rounds = append(rounds, keyframe.round)
+	}
+	assert.Equal(t, []int{0, 100, 200, 300}, rounds)
+}
@@ -0,0 +1,76 @@
+package simulation
+
+import (
+	"sort"
+)
+
+/*
+	WorldSnapshot is a copy of the world and of the position of the aliens at the end of a round.
+*/
+type WorldSnapshot struct {
+	Round            int
+	World            map[string][]*City
+	Cities           []*City
+	AlienCityMapping map[int]string
+	// display name of every alien by ID
+	AlienNames map[int]string
+}
+
+/*
+	SnapshotWorld copies the current world and the position of the aliens, the copy is not changed by the following rounds.
+*/
+func (sim *Simulation) SnapshotWorld() *WorldSnapshot {
+	snapshot := &WorldSnapshot{
+		Round:            sim.Round,
+		World:            make(map[string][]*City, len(sim.World)),
+		Cities:           make([]*City, 0, len(sim.Cities)),
+		AlienCityMapping: make(map[int]string, len(sim.AlienCityMapping)),
+		AlienNames:       sim.alienNames(),
+	}
+	for city, links := range sim.World {
+		copied := make([]*City, 0, len(links))
+		for _, link := range links {
+			linkCopy := *link
+			copied = append(copied, &linkCopy)
+		}
+		snapshot.World[city] = copied
+	}
+	for _, city := range sim.Cities {
+		cityCopy := *city
+		snapshot.Cities = append(snapshot.Cities, &cityCopy)
+	}
+	for alien, city := range sim.AlienCityMapping {
+		snapshot.AlienCityMapping[alien] = city
+	}
+	return snapshot
+}
+
+/*
+	HasRoad reports whether the snapshot holds a road from one city to another.
+*/
+func (snapshot *WorldSnapshot) HasRoad(from, to string) bool {
+	for _, link := range snapshot.World[from] {
+		if link.Name == to {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+	AliensIn returns the names of the aliens in each city of the snapshot, sorted.
+*/
+func (snapshot *WorldSnapshot) AliensIn() map[string][]string {
+	aliens := make(map[string][]string)
+	for alien, city := range snapshot.AlienCityMapping {
+		name, ok := snapshot.AlienNames[alien]
+		if !ok {
+			name = (&Alien{ID: alien}).String()
+		}
+		aliens[city] = append(aliens[city], name)
+	}
+	for city := range aliens {
+		sort.Strings(aliens[city])
+	}
+	return aliens
+}
@@ -0,0 +1,87 @@
+package simulation
+
+import (
+	"fmt"
+)
+
+/*
+	Stalemate reports whether no fight can ever happen again: no two aliens left can reach a common city by the roads of
+	the remaining world. An alien which is not trapped may stay where it is, so two aliens which can reach the same city may
+	meet there, and aliens in different parts of the world never will. A run with scheduled events still to come is never
+	in a stalemate, landing aliens could fight.
+	The answer is only computed again after the world changed: a city or a road was destroyed or an alien was added or moved by hand.
+*/
+func (sim *Simulation) Stalemate() bool {
+	if sim.Round == 0 {
+		return false
+	}
+	for _, event := range sim.ScheduledEvents {
+		if event.Round > sim.Round {
+			return false
+		}
+	}
+	if !sim.stalemateChecked {
+		sim.stalemate = !sim.fightPossible()
+		sim.stalemateChecked = true
+	}
+	return sim.stalemate
+}
+
+/*
+	fightPossible searches the world from every alien at once, each city belongs to the first alien reaching it.
+	Two aliens can meet as soon as one of them reaches a city of the other, so the search stops there.
+*/
+func (sim *Simulation) fightPossible() bool {
+	owner := make(map[string]int, len(sim.Aliens))
+	queue := make([]string, 0, len(sim.Aliens))
+	for _, alien := range sim.Aliens {
+		city, ok := sim.AlienCityMapping[alien.ID]
+		if !ok {
+			continue
+		}
+		if _, taken := owner[city]; taken {
+			return true
+		}
+		owner[city] = alien.ID
+		queue = append(queue, city)
+	}
+	for idx := 0; idx < len(queue); idx++ {
+		city := queue[idx]
+		for _, link := range sim.World[city] {
+			reached, ok := owner[link.Name]
+			if !ok {
+				owner[link.Name] = owner[city]
+				queue = append(queue, link.Name)
+				continue
+			}
+			if reached != owner[city] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+/*
+	worldChanged forgets the last stalemate check.
+*/
+func (sim *Simulation) worldChanged() {
+	sim.stalemateChecked = false
+}
+
+/*
+	EndReason tells why the attack is over, it is empty while the attack goes on.
+*/
+func (sim *Simulation) EndReason() string {
+	switch {
+	case len(sim.Aliens) < 1:
+		return "all the aliens are dead"
+	case len(sim.Cities) < 1:
+		return "all the cities are destroyed"
+	case sim.Stalemate():
+		return "stalemate, no two aliens left can ever meet"
+	case sim.Round >= sim.Iterations:
+		return fmt.Sprintf("all the %d rounds were run", sim.Iterations)
+	}
+	return ""
+}
@@ -0,0 +1,110 @@
+package simulation
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// placedSimulation returns a simulation after the given round, with an alien in each of the given cities
+func placedSimulation(t *testing.T, worldMap string, round int, cities ...string) *Simulation {
+	sim, err := NewSimulation(10, len(cities), "", "", nil, zap.NewNop())
+	assert.NoError(t, err)
+	sim.Output = io.Discard
+	assert.NoError(t, sim.LoadWorld(strings.NewReader(worldMap)))
+	for _, city := range cities {
+		alien := sim.AddAlien("")
+		sim.AlienCityMapping[alien.ID] = city
+		sim.CityAlienMapping[city] = append(sim.CityAlienMapping[city], alien.ID)
+	}
+	sim.Round = round
+	return sim
+}
+
+func TestSimulation_Stalemate(t *testing.T) {
+	tests := []struct {
+		name     string
+		worldMap string
+		// city of every alien, by ID
+		aliens []string
+		round  int
+		events []ScheduledEvent
+		want   bool
+	}{
+		{name: "Aliens on the same island", worldMap: "A east=B\nB east=C\nC east=D", aliens: []string{"A", "D"}, round: 4, want: false},
+		{name: "Aliens on different islands", worldMap: "A east=B\nC east=D\nE", aliens: []string{"A", "D", "E"}, round: 4, want: true},
+		{name: "Lone alien", worldMap: "A east=B", aliens: []string{"B"}, round: 4, want: true},
+		{name: "Aliens have not arrived yet", worldMap: "A\nB", aliens: []string{}, round: 0, want: false},
+		// the road from X to Y is one way
+		{name: "Alien following a one way road", worldMap: "Y\nX east=Y", aliens: []string{"Y", "X"}, round: 4, want: false},
+		// X leads to Y and Z but neither leads anywhere
+		{name: "Aliens beyond two one way roads", worldMap: "Y\nZ\nX east=Y west=Z", aliens: []string{"Y", "Z"}, round: 4, want: true},
+		{name: "Event still to come", worldMap: "A\nB", aliens: []string{"A", "B"}, round: 4,
+			events: []ScheduledEvent{{Round: 5, Action: ActionLand, City: "A", Count: 1}}, want: false},
+		{name: "Event already applied", worldMap: "A\nB", aliens: []string{"A", "B"}, round: 4,
+			events: []ScheduledEvent{{Round: 3, Action: ActionLand, City: "A", Count: 1}}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sim := placedSimulation(t, tt.worldMap, tt.round, tt.aliens...)
+			for _, event := range tt.events {
+				assert.NoError(t, sim.Schedule(event))
+			}
+			assert.Equal(t, tt.want, sim.Stalemate())
+		})
+	}
+}
+
+func TestSimulation_StalemateAfterChanges(t *testing.T) {
+	// Baz is reachable from Bar, Lee from nowhere
+	sim := placedSimulation(t, "Foo east=Bar\nBar south=Baz\nLee", 1, "Bar", "Baz")
+	assert.False(t, sim.Stalemate())
+	assert.False(t, sim.Ended())
+	assert.Equal(t, "", sim.EndReason())
+
+	// moved by hand out of reach
+	assert.NoError(t, sim.MoveAlien("#2", "Lee"))
+	assert.True(t, sim.Stalemate())
+	assert.True(t, sim.Ended())
+	assert.Equal(t, "stalemate, no two aliens left can ever meet", sim.EndReason())
+	assert.NoError(t, sim.Start())
+	assert.Equal(t, 1, sim.Round)
+
+	// moved back within reach
+	assert.NoError(t, sim.MoveAlien("#2", "Foo"))
+	assert.False(t, sim.Stalemate())
+
+	// destroying the city kills the alien in it, the other one is left alone
+	assert.NoError(t, sim.DestroyCity("Foo"))
+	assert.Equal(t, "stalemate, no two aliens left can ever meet", sim.EndReason())
+	assert.NoError(t, sim.DestroyCity("Bar"))
+	assert.Equal(t, "all the aliens are dead", sim.EndReason())
+	assert.NoError(t, sim.DestroyCity("Baz"))
+	assert.NoError(t, sim.DestroyCity("Lee"))
+	assert.Equal(t, "all the aliens are dead", sim.EndReason())
+}
+
+func TestSimulation_EndReason(t *testing.T) {
+	tests := []struct {
+		name     string
+		worldMap string
+		round    int
+		aliens   []string
+		want     string
+	}{
+		{name: "Attack goes on", worldMap: "Foo east=Bar\nBar east=Baz", round: 4, aliens: []string{"Foo", "Baz"}, want: ""},
+		{name: "All rounds run", worldMap: "Foo east=Bar\nBar east=Baz", round: 10, aliens: []string{"Foo", "Baz"}, want: "all the 10 rounds were run"},
+		{name: "Stalemate", worldMap: "Foo\nBar", round: 10, aliens: []string{"Foo", "Bar"}, want: "stalemate, no two aliens left can ever meet"},
+		{name: "No aliens", worldMap: "Foo", round: 4, want: "all the aliens are dead"},
+		{name: "No cities", worldMap: "", round: 4, aliens: []string{"Foo"}, want: "all the cities are destroyed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sim := placedSimulation(t, tt.worldMap, tt.round, tt.aliens...)
+			assert.Equal(t, tt.want, sim.EndReason())
+		})
+	}
+}
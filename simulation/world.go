@@ -3,8 +3,10 @@ package simulation
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"math/rand"
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/rvsingh011/alien-invasion/utils"
@@ -29,15 +31,49 @@ type Simulation struct {
 	// List of all cities on the target planet, this does not include the roads
 	Cities []*City
 
-	// Alien Commandar record for deployed aliens, represent which alien is currently in which city
-	AlienCityMapping map[string]string
+	// ID given to the last alien which joined the invasion, the IDs of the aliens are never reused
+	LastAlienID int
 
-	// united nations defense record, tracks and records which city is under attack by which alien
-	CityAlienMapping map[string][]string
+	// Alien Commandar record for deployed aliens, represent which alien (by ID) is currently in which city
+	AlienCityMapping map[int]string
+
+	// united nations defense record, tracks and records which city is under attack by which aliens (by ID)
+	CityAlienMapping map[string][]int
 
 	// Record the attack vector for future generation or run simulations
 	RandSeed *rand.Rand
 
+	// Source behind RandSeed when the simulation was seeded with SetSeed, it allows the attack vector to be checkpointed
+	RandSource *utils.RandSource
+
+	// Round is the last round of attack that was run, 0 means the aliens have not arrived yet
+	Round int
+
+	// SampleNames makes the aliens take names at random from the names file instead of the first ones
+	SampleNames bool
+
+	// Events scheduled to be injected into the simulation at a given round
+	ScheduledEvents []ScheduledEvent
+
+	// number of aliens which landed through scheduled events, used to name them
+	landed int
+
+	// result of the last stalemate check, valid until the world changes
+	stalemate        bool
+	stalemateChecked bool
+
+	// Output receives the messages of the simulation, os.Stdout is used if nil
+	Output io.Writer
+
+	// hooks called while the world is loaded
+	loadHooks []func(progress LoadProgress)
+
+	// hooks called after every round of attack
+	roundHooks []func(sim *Simulation)
+
+	// listeners called for every event of the attack
+	eventListeners []func(event Event)
+
 	// communication messages for future generation to read and learn (not in use), but can be used for only listning a particular type of messages
 	logger *zap.Logger
 }
@@ -52,14 +88,56 @@ func NewSimulation(iterations, alienNumbers int, alienNames, worldFile string, r
 		NumberOfAliens:   alienNumbers,
 		AlienNames:       alienNames,
 		World:            make(map[string][]*City),
-		AlienCityMapping: make(map[string]string),
-		CityAlienMapping: make(map[string][]string),
+		AlienCityMapping: make(map[int]string),
+		CityAlienMapping: make(map[string][]int),
 		RandSeed:         randomSeed,
 		logger:           logger,
 	}
 	return &simulation, nil
 }
 
+/*
+	SetSeed seeds the attack vector of the simulation with a checkpointable source.
+*/
+func (sim *Simulation) SetSeed(seed int64) {
+	sim.RandSource = utils.NewRandSource(seed)
+	sim.RandSeed = rand.New(sim.RandSource)
+}
+
+/*
+	out returns the writer receiving the messages of the simulation.
+*/
+func (sim *Simulation) out() io.Writer {
+	if sim.Output == nil {
+		return os.Stdout
+	}
+	return sim.Output
+}
+
+/*
+	OnEvent registers a listener called for every event of the attack, listeners are called in the order they were registered.
+*/
+func (sim *Simulation) OnEvent(listener func(event Event)) {
+	sim.eventListeners = append(sim.eventListeners, listener)
+}
+
+/*
+	emit stamps the event with the current round and passes it to the listeners.
+*/
+func (sim *Simulation) emit(event Event) {
+	event.Round = sim.Round
+	for _, listener := range sim.eventListeners {
+		listener(event)
+	}
+}
+
+/*
+	OnRound registers a hook called after every round of attack, hooks are called in the order they were registered.
+*/
+func (sim *Simulation) OnRound(hook func(sim *Simulation)) {
+	sim.roundHooks = append(sim.roundHooks, hook)
+}
+
 /*
 	CreateWorld simulates the world from the provided world file.
 */
@@ -69,49 +147,19 @@ func (sim *Simulation) CreateWorld() error {
 		return fmt.Errorf("Error Reading the world file : %s, Error: %s", sim.WorldFile, err.Error())
 	}
 	defer worldFile.Close()
-
-	scanner := bufio.NewScanner(worldFile)
-	scanner.Split(bufio.ScanLines)
-
-	for scanner.Scan() {
-		lineSplitOnSpace := strings.Split(strings.TrimSpace(scanner.Text()), " ")
-		newCity := lineSplitOnSpace[0]
-
-		if _, ok := sim.World[newCity]; !ok {
-			sim.World[newCity] = make([]*City, 0)
-			sim.Cities = append(sim.Cities, NewCity(newCity))
-		}
-
-		lineSplitOnSpace = lineSplitOnSpace[1:]
-		for _, cityDir := range lineSplitOnSpace {
-			cityWithDirections := strings.Split(strings.TrimSpace(cityDir), "=")
-			// if city already exists
-			isCityExist := false
-			for _, city := range sim.World[newCity] {
-				if city.Name == cityWithDirections[1] {
-					isCityExist = true
-				}
-			}
-
-			if !isCityExist {
-				sim.World[newCity] = append(
-					sim.World[newCity],
-					NewCityWithDirections(cityWithDirections[1], cityWithDirections[0]),
-				)
-				// if the income city is not there, Add it to the city.
-				if _, ok := sim.World[cityWithDirections[1]]; !ok {
-					sim.World[cityWithDirections[1]] = make([]*City, 0)
-					sim.World[cityWithDirections[1]] = append(
-						sim.World[cityWithDirections[1]],
-						NewCityWithDirections(newCity, utils.GetOppositeDirection(cityWithDirections[0])),
-					)
-					sim.Cities = append(sim.Cities, NewCity(cityWithDirections[1]))
-				}
-			}
-
-		}
+	cities := 0
+	if info, err := worldFile.Stat(); err == nil {
+		cities = int(info.Size() / bytesPerCity)
 	}
-	return nil
+	return newWorldLoader(sim, cities).load(worldFile)
+}
+
+/*
+	LoadWorld simulates the world from a reader holding the world map in the world file format.
+	The world map is streamed, see OnLoadProgress to follow the loading of large worlds.
+*/
+func (sim *Simulation) LoadWorld(worldMap io.Reader) error {
+	return newWorldLoader(sim, 0).load(worldMap)
 }
 
 /*
@@ -119,14 +167,14 @@ func (sim *Simulation) CreateWorld() error {
 */
 func (sim *Simulation) ViewWorld() string {
 
-	println("=========================================")
-	println("The World before attack is")
-	println("=========================================")
+	fmt.Fprintln(sim.out(), "=========================================")
+	fmt.Fprintln(sim.out(), "The World before attack is")
+	fmt.Fprintln(sim.out(), "=========================================")
 	var world strings.Builder
 	for key, value := range sim.World {
-		fmt.Printf("The City %s is connected to below cities\n", key)
+		fmt.Fprintf(sim.out(), "The City %s is connected to below cities\n", key)
 		for _, city := range value {
-			fmt.Printf("\tThe City %s is %s to the %s\n", city.Name, city.Direction, key)
+			fmt.Fprintf(sim.out(), "\tThe City %s is %s to the %s\n", city.Name, city.Direction, key)
 			world.WriteString(fmt.Sprintf("\tThe City %s is %s to the %s\n", city.Name, city.Direction, key))
 		}
 	}
@@ -134,19 +182,64 @@ func (sim *Simulation) ViewWorld() string {
 }
 
 /*
-	CreateAliens simulated aliens which will be attacking the cities.
+	CreateAliens simulated aliens which will be attacking the cities, named after the lines of the alien names file.
 */
 func (sim *Simulation) CreateAliens() error {
+	// without a names file the aliens are only known by their ID
+	if sim.AlienNames == "" {
+		for aliens := 0; aliens < sim.NumberOfAliens; aliens++ {
+			sim.AddAlien("")
+		}
+		return nil
+	}
 	alienNames, err := os.Open(sim.AlienNames)
 	if err != nil {
 		return fmt.Errorf("Error Reading the alien name file : %s, Error: %s", sim.AlienNames, err.Error())
 	}
 	defer alienNames.Close()
+	return sim.LoadAliens(alienNames)
+}
+
+/*
+	LoadAliens simulated aliens from a reader holding one alien name per line.
+	The aliens take the first names, or names sampled at random with SampleNames, names are generated once the reader runs short,
+	a generated name is followed by a number when it is taken already.
+	Sampling and generating names draw from their own source seeded like the attack vector, so the attack is not changed by the names.
+*/
+func (sim *Simulation) LoadAliens(alienNames io.Reader) error {
 	scanner := bufio.NewScanner(alienNames)
 	scanner.Split(bufio.ScanLines)
 
-	for aliens := 0; aliens < sim.NumberOfAliens && scanner.Scan(); aliens++ {
-		sim.Aliens = append(sim.Aliens, NewAlien(scanner.Text()))
+	names := make([]string, 0)
+	for (sim.SampleNames || len(names) < sim.NumberOfAliens) && scanner.Scan() {
+		names = append(names, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	random := sim.namesRandom()
+	if sim.SampleNames {
+		// partial Fisher-Yates shuffle, only the names taken are drawn
+		for idx := 0; idx < sim.NumberOfAliens && idx < len(names); idx++ {
+			pick := idx + random.Intn(len(names)-idx)
+			names[idx], names[pick] = names[pick], names[idx]
+		}
+	}
+	// generated names never repeat a name of the invasion, the names read are kept as they are
+	taken := make(map[string]bool, len(sim.Aliens)+sim.NumberOfAliens)
+	for _, alien := range sim.Aliens {
+		taken[alien.Name] = true
+	}
+	for idx := 0; idx < sim.NumberOfAliens && idx < len(names); idx++ {
+		taken[names[idx]] = true
+	}
+	for aliens := 0; aliens < sim.NumberOfAliens; aliens++ {
+		if aliens < len(names) {
+			sim.AddAlien(names[aliens])
+			continue
+		}
+		sim.AddAlien(utils.UniqueName(utils.GenerateName(random), taken))
 	}
 	return nil
 }
@@ -156,12 +249,16 @@ func (sim *Simulation) CreateAliens() error {
 */
 func (sim *Simulation) ViewAliens() error {
 
-	println("=========================================")
-	println("Alien Profiles")
-	println("=========================================")
+	fmt.Fprintln(sim.out(), "=========================================")
+	fmt.Fprintln(sim.out(), "Alien Profiles")
+	fmt.Fprintln(sim.out(), "=========================================")
 
-	for idx, alien := range sim.Aliens {
-		fmt.Printf("The alien %d has a name %s\n", idx, alien.Name)
+	for _, alien := range sim.Aliens {
+		if alien.Name == "" {
+			fmt.Fprintf(sim.out(), "The alien %d has no name\n", alien.ID)
+			continue
+		}
+		fmt.Fprintf(sim.out(), "The alien %d has a name %s\n", alien.ID, alien.Name)
 	}
 	return nil
 }
@@ -174,45 +271,71 @@ func (sim *Simulation) ViewAliens() error {
 	4. An alien can also decide not to move and stay in the same city.
 */
 func (sim *Simulation) Start() error {
-	for iteration := 1; iteration <= sim.Iterations; iteration++ {
+	for sim.Round < sim.Iterations {
 		// should the next iteration run ?
 		if sim.isNextIterationRequired() == false {
 			break
 		}
-		println("=========================================")
-		fmt.Printf("Running %d iteration of Attack\n", iteration)
-		println("=========================================")
-
-		// if aliens just arrrived they need to prepare weapons and initiate the attack
-		if iteration == 1 {
-			sim.prepareAttack()
-		} else {
-			sim.runNextRoundOfAttack()
-		}
-		sim.fight()
+		sim.Step()
 	}
 	return nil
 }
 
+/*
+	Step runs the next round of the attack.
+	1. Aliens arrive in the first round and move in the following rounds.
+	2. Events scheduled for the round are injected after the aliens moved.
+	3. Aliens in the same city fight.
+*/
+func (sim *Simulation) Step() {
+	sim.Round++
+	fmt.Fprintln(sim.out(), "=========================================")
+	fmt.Fprintf(sim.out(), "Running %d iteration of Attack\n", sim.Round)
+	fmt.Fprintln(sim.out(), "=========================================")
+
+	// if aliens just arrrived they need to prepare weapons and initiate the attack
+	if sim.Round == 1 {
+		sim.prepareAttack()
+	} else {
+		sim.runNextRoundOfAttack()
+	}
+	sim.applyScheduledEvents()
+	sim.fight()
+
+	for _, hook := range sim.roundHooks {
+		hook(sim)
+	}
+}
+
 /*
 	fight simualtes the fight between aliens which arrived in the same city.
 	1. If more than one alien comes to same city, all aliens are destoyed with the city and its link.
 */
 func (sim *Simulation) fight() {
-	deadAliens := make([]string, 0)
+	deadAliens := make([]int, 0)
 	destoyedCities := make([]string, 0)
 	for city, aliensInCity := range sim.CityAlienMapping {
 		if len(aliensInCity) > 1 {
-			var report strings.Builder
-			report.WriteString(fmt.Sprintf("The %s was destroyed by ", city))
 			destoyedCities = append(destoyedCities, city)
-			for idx := range aliensInCity {
-				report.WriteString(fmt.Sprintf("%s, ", aliensInCity[idx]))
-				deadAliens = append(deadAliens, aliensInCity[idx])
-			}
-			fmt.Println(report.String())
 		}
 	}
+	if len(destoyedCities) == 0 {
+		return
+	}
+	// report the fights in a stable order
+	sort.Strings(destoyedCities)
+
+	names := sim.alienNames()
+	for _, city := range destoyedCities {
+		aliensInCity := sim.CityAlienMapping[city]
+		fighters := make([]string, 0, len(aliensInCity))
+		for _, alien := range aliensInCity {
+			fighters = append(fighters, names[alien])
+			deadAliens = append(deadAliens, alien)
+		}
+		fmt.Fprintf(sim.out(), "The %s was destroyed by %s\n", city, joinNames(fighters))
+		sim.emit(Event{Kind: EventFight, City: city, Aliens: fighters, AlienIDs: append([]int(nil), aliensInCity...)})
+	}
 
 	sim.burryDeadAliens(deadAliens)
 	sim.removeDestroyedCities(destoyedCities)
@@ -221,22 +344,30 @@ func (sim *Simulation) fight() {
 /*
 	burryDeadAliens simualtes the death of a alien.
 */
-func (sim *Simulation) burryDeadAliens(deadAliens []string) {
+func (sim *Simulation) burryDeadAliens(deadAliens []int) {
+	if len(deadAliens) == 0 {
+		return
+	}
+	dead := make(map[int]bool, len(deadAliens))
 	for _, deadAlien := range deadAliens {
 		delete(sim.AlienCityMapping, deadAlien)
+		dead[deadAlien] = true
+	}
 
-		for i := len(sim.Aliens) - 1; i >= 0; i-- {
-			if sim.Aliens[i].Name == deadAlien {
-				sim.Aliens = append(sim.Aliens[:i], sim.Aliens[i+1:]...)
-			}
+	alive := sim.Aliens[:0]
+	for _, alien := range sim.Aliens {
+		if !dead[alien.ID] {
+			alive = append(alive, alien)
 		}
 	}
+	sim.Aliens = alive
 }
 
 /*
 	removeDestroyedCities simualtes a destroyed city.
 */
 func (sim *Simulation) removeDestroyedCities(destoyedCities []string) {
+	sim.worldChanged()
 	for _, destroyedCity := range destoyedCities {
 		delete(sim.CityAlienMapping, destroyedCity)
 		for i := len(sim.Cities) - 1; i >= 0; i-- {
@@ -267,9 +398,10 @@ func (sim *Simulation) deleteCityFromWorldMap(city string) {
 	isNextIterationRequired checks if next iteraton of simulations is required.
 	1. if all cities are destoyed, stop the simulation.
 	2. if all aliens are dead, stop the simulations.
+	3. if no fight can happen anymore, stop the simulation, see Stalemate.
 */
 func (sim *Simulation) isNextIterationRequired() bool {
-	if len(sim.Aliens) < 1 || len(sim.Cities) < 1 {
+	if len(sim.Aliens) < 1 || len(sim.Cities) < 1 || sim.Stalemate() {
 		return false
 	}
 	return true
@@ -280,35 +412,45 @@ func (sim *Simulation) isNextIterationRequired() bool {
 	1. In this step all aliens choose a city to attack.
 */
 func (sim *Simulation) prepareAttack() {
+	sim.worldChanged()
 
 	// intialize the city Command Center record
 	for idx := range sim.Cities {
-		sim.CityAlienMapping[sim.Cities[idx].Name] = make([]string, 0)
+		sim.CityAlienMapping[sim.Cities[idx].Name] = make([]int, 0)
 	}
 
 	// all aliens will first choose a city of there choice to attack
 	for _, alien := range sim.Aliens {
 		cityIndex := utils.GetRandomNumber(0, len(sim.Cities)-1, sim.RandSeed)
-		fmt.Printf("Alien %s choose %s city\n", alien.Name, sim.Cities[cityIndex].Name)
-		sim.AlienCityMapping[alien.Name] = sim.Cities[cityIndex].Name
-
-		// city command center intercepted target cities and who will be visiting
-		sim.CityAlienMapping[sim.Cities[cityIndex].Name] = append(sim.CityAlienMapping[sim.Cities[cityIndex].Name], alien.Name)
+		fmt.Fprintf(sim.out(), "Alien %s choose %s city\n", alien, sim.Cities[cityIndex].Name)
+		sim.placeAlien(alien, sim.Cities[cityIndex].Name)
 	}
 }
 
+/*
+	placeAlien records the arrival of an alien in its first city.
+*/
+func (sim *Simulation) placeAlien(alien *Alien, city string) {
+	sim.AlienCityMapping[alien.ID] = city
+
+	// city command center intercepted target cities and who will be visiting
+	sim.CityAlienMapping[city] = append(sim.CityAlienMapping[city], alien.ID)
+	sim.emit(Event{Kind: EventArrive, Alien: alien.String(), AlienID: alien.ID, City: city})
+}
+
 /*
 	runNextRoundOfAttack simulates the attack of alien after the intial round.
 	1. In this step all aliens either moves to a new city or stay in the same city, or get trapped in the city.
 */
 func (sim *Simulation) runNextRoundOfAttack() {
 	// Aliens will choose a city conneted to the exsiting city
-	for idx := range sim.Aliens {
-		alienCurrentCity := sim.AlienCityMapping[sim.Aliens[idx].Name]
+	for _, alien := range sim.Aliens {
+		alienCurrentCity := sim.AlienCityMapping[alien.ID]
 
 		maxIndex := len(sim.World[alienCurrentCity])
 		if maxIndex == 0 {
-			fmt.Printf("The alien %s is trapped in the %s city\n", sim.Aliens[idx].Name, alienCurrentCity)
+			fmt.Fprintf(sim.out(), "The alien %s is trapped in the %s city\n", alien, alienCurrentCity)
+			sim.emit(Event{Kind: EventTrapped, Alien: alien.String(), AlienID: alien.ID, City: alienCurrentCity})
 			continue
 		}
 
@@ -316,30 +458,60 @@ func (sim *Simulation) runNextRoundOfAttack() {
 
 		// maxIndex == len(sim.World[alienCurrentCity]) denoted no move by the alien.
 		if newCityIndex == maxIndex {
-			fmt.Printf("The alien %s decided to stay in %s city\n", sim.Aliens[idx].Name, alienCurrentCity)
+			fmt.Fprintf(sim.out(), "The alien %s decided to stay in %s city\n", alien, alienCurrentCity)
+			sim.emit(Event{Kind: EventStay, Alien: alien.String(), AlienID: alien.ID, City: alienCurrentCity})
 			continue
 		}
 
-		// remove the alien from current city
-		for index, alien := range sim.CityAlienMapping[alienCurrentCity] {
-			if alien == sim.Aliens[idx].Name {
-				sim.CityAlienMapping[alienCurrentCity] = append(sim.CityAlienMapping[alienCurrentCity][:index], sim.CityAlienMapping[alienCurrentCity][index+1:]...)
-			}
+		fmt.Fprintf(sim.out(), "The alien %s will now move to %s\n", alien, sim.World[alienCurrentCity][newCityIndex].Name)
+		sim.moveAlien(alien, sim.World[alienCurrentCity][newCityIndex].Name)
+	}
+}
+
+/*
+	moveAlien records the move of an alien from its current city to a new city.
+*/
+func (sim *Simulation) moveAlien(alien *Alien, city string) {
+	alienCurrentCity := sim.AlienCityMapping[alien.ID]
+
+	// remove the alien from current city
+	for index, alienInCity := range sim.CityAlienMapping[alienCurrentCity] {
+		if alienInCity == alien.ID {
+			sim.CityAlienMapping[alienCurrentCity] = append(sim.CityAlienMapping[alienCurrentCity][:index], sim.CityAlienMapping[alienCurrentCity][index+1:]...)
+			break
 		}
+	}
 
-		fmt.Printf("The alien %s will now move to %s\n", sim.Aliens[idx].Name, sim.World[alienCurrentCity][newCityIndex].Name)
-		sim.AlienCityMapping[sim.Aliens[idx].Name] = sim.World[alienCurrentCity][newCityIndex].Name
-		sim.CityAlienMapping[sim.World[alienCurrentCity][newCityIndex].Name] = append(sim.CityAlienMapping[sim.World[alienCurrentCity][newCityIndex].Name], sim.Aliens[idx].Name)
+	sim.AlienCityMapping[alien.ID] = city
+	sim.CityAlienMapping[city] = append(sim.CityAlienMapping[city], alien.ID)
+	sim.emit(Event{Kind: EventMove, Alien: alien.String(), AlienID: alien.ID, City: alienCurrentCity, To: city})
+}
+
+/*
+	WorldMap returns what is left of the world in the world file format, cities are listed in the order they were discovered.
+*/
+func (sim *Simulation) WorldMap() string {
+	var worldMap strings.Builder
+	for _, city := range sim.Cities {
+		worldMap.WriteString(city.Name)
+		for _, eachCity := range sim.World[city.Name] {
+			worldMap.WriteString(fmt.Sprintf(" %s=%s", eachCity.Direction, eachCity.Name))
+		}
+		worldMap.WriteString("\n")
 	}
+	return worldMap.String()
 }
 
 /*
 	EndAndConclude ends the simulations and print what is left of the world.
 */
 func (sim *Simulation) EndAndConclude() string {
-	println("=========================================")
-	println("The Bloody war ended, these are the remins of the world")
-	println("=========================================")
+	fmt.Fprintln(sim.out(), "=========================================")
+	fmt.Fprintln(sim.out(), "The Bloody war ended, these are the remins of the world")
+	fmt.Fprintln(sim.out(), "=========================================")
+	if reason := sim.EndReason(); reason != "" {
+		fmt.Fprintf(sim.out(), "The war ended after round %d: %s\n", sim.Round, reason)
+	}
 
 	var leftWorld strings.Builder
 	for city, linkedCities := range sim.World {
@@ -351,7 +523,7 @@ func (sim *Simulation) EndAndConclude() string {
 		for _, eachCity := range linkedCities {
 			cityInfo.WriteString(fmt.Sprintf(" %s=%s", eachCity.Direction, eachCity.Name))
 		}
-		fmt.Println(cityInfo.String())
+		fmt.Fprintln(sim.out(), cityInfo.String())
 		leftWorld.WriteString(cityInfo.String())
 		leftWorld.WriteString("\n")
 	}
@@ -2,6 +2,7 @@ package simulation
 
 import (
 	"math/rand"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -74,8 +75,8 @@ func TestSimulation_CreateWorld(t *testing.T) {
 		World            map[string][]*City
 		Aliens           []*Alien
 		Cities           []*City
-		AlienCityMapping map[string]string
-		CityAlienMapping map[string][]string
+		AlienCityMapping map[int]string
+		CityAlienMapping map[string][]int
 		RandSeed         *rand.Rand
 		logger           *zap.Logger
 	}
@@ -225,8 +226,8 @@ func TestSimulation_Start(t *testing.T) {
 		World            map[string][]*City
 		Aliens           []*Alien
 		Cities           []*City
-		AlienCityMapping map[string]string
-		CityAlienMapping map[string][]string
+		AlienCityMapping map[int]string
+		CityAlienMapping map[string][]int
 		RandSeed         *rand.Rand
 		logger           *zap.Logger
 	}
@@ -234,15 +235,15 @@ func TestSimulation_Start(t *testing.T) {
 		name                 string
 		fields               fields
 		wantErr              bool
-		wantAlienCityMapping map[string]string
-		wantCityAlienMapping map[string][]string
+		wantAlienCityMapping map[int]string
+		wantCityAlienMapping map[string][]int
 	}{
 		{
 			name:                 "Run Single Iteration",
-			wantAlienCityMapping: map[string]string{"Alien1": "Bar", "Alien3": "Lee"},
-			wantCityAlienMapping: map[string][]string{
-				"Bar": {"Alien1"},
-				"Lee": {"Alien3"},
+			wantAlienCityMapping: map[int]string{2: "Bar", 4: "Lee"},
+			wantCityAlienMapping: map[string][]int{
+				"Bar": {2},
+				"Lee": {4},
 				"Mee": {},
 			},
 			fields: fields{
@@ -265,23 +266,23 @@ func TestSimulation_Start(t *testing.T) {
 				Iterations:       1,
 				Cities:           []*City{NewCity("Foo"), NewCity("Bar"), NewCity("Lee"), NewCity("Mee")},
 				RandSeed:         rand.New(rand.NewSource(3)),
-				Aliens:           []*Alien{NewAlien("Alien0"), NewAlien("Alien1"), NewAlien("Alien2"), NewAlien("Alien3")},
-				CityAlienMapping: make(map[string][]string),
-				AlienCityMapping: make(map[string]string),
+				Aliens:           []*Alien{NewAlien(1, "Alien0"), NewAlien(2, "Alien1"), NewAlien(3, "Alien2"), NewAlien(4, "Alien3")},
+				CityAlienMapping: make(map[string][]int),
+				AlienCityMapping: make(map[int]string),
 			},
 			wantErr: false,
 		},
 		{
 			name: "Run two predictable Iterations",
-			wantAlienCityMapping: map[string]string{
-				"Alien4": "Berlin",
-				"Alien9": "Mee",
+			wantAlienCityMapping: map[int]string{
+				5:  "Berlin",
+				10: "Mee",
 			},
-			wantCityAlienMapping: map[string][]string{
+			wantCityAlienMapping: map[string][]int{
 				"Bar":       {},
 				"Lee":       {},
-				"Mee":       {"Alien9"},
-				"Berlin":    {"Alien4"},
+				"Mee":       {10},
+				"Berlin":    {5},
 				"Moscow":    {},
 				"Tokyo":     {},
 				"Bangalore": {},
@@ -340,34 +341,35 @@ func TestSimulation_Start(t *testing.T) {
 				},
 				RandSeed: rand.New(rand.NewSource(3)),
 				Aliens: []*Alien{
-					NewAlien("Alien0"),
-					NewAlien("Alien1"),
-					NewAlien("Alien2"),
-					NewAlien("Alien3"),
-					NewAlien("Alien4"),
-					NewAlien("Alien5"),
-					NewAlien("Alien6"),
-					NewAlien("Alien7"),
-					NewAlien("Alien8"),
-					NewAlien("Alien9"),
+					NewAlien(1, "Alien0"),
+					NewAlien(2, "Alien1"),
+					NewAlien(3, "Alien2"),
+					NewAlien(4, "Alien3"),
+					NewAlien(5, "Alien4"),
+					NewAlien(6, "Alien5"),
+					NewAlien(7, "Alien6"),
+					NewAlien(8, "Alien7"),
+					NewAlien(9, "Alien8"),
+					NewAlien(10, "Alien9"),
 				},
-				CityAlienMapping: make(map[string][]string),
-				AlienCityMapping: make(map[string]string),
+				CityAlienMapping: make(map[string][]int),
+				AlienCityMapping: make(map[int]string),
 			},
 			wantErr: false,
 		},
 		{
+			// Foo and Delhi are destroyed in the second round, the aliens left in Mee and Berlin can never meet
 			name: "Run two predictable Iterations",
-			wantAlienCityMapping: map[string]string{
-				"Alien4": "Moscow",
-				"Alien9": "Mee",
+			wantAlienCityMapping: map[int]string{
+				5:  "Berlin",
+				10: "Mee",
 			},
-			wantCityAlienMapping: map[string][]string{
+			wantCityAlienMapping: map[string][]int{
 				"Bar":       {},
 				"Lee":       {},
-				"Mee":       {"Alien9"},
-				"Berlin":    {},
-				"Moscow":    {"Alien4"},
+				"Mee":       {10},
+				"Berlin":    {5},
+				"Moscow":    {},
 				"Tokyo":     {},
 				"Bangalore": {},
 			},
@@ -425,19 +427,19 @@ func TestSimulation_Start(t *testing.T) {
 				},
 				RandSeed: rand.New(rand.NewSource(3)),
 				Aliens: []*Alien{
-					NewAlien("Alien0"),
-					NewAlien("Alien1"),
-					NewAlien("Alien2"),
-					NewAlien("Alien3"),
-					NewAlien("Alien4"),
-					NewAlien("Alien5"),
-					NewAlien("Alien6"),
-					NewAlien("Alien7"),
-					NewAlien("Alien8"),
-					NewAlien("Alien9"),
+					NewAlien(1, "Alien0"),
+					NewAlien(2, "Alien1"),
+					NewAlien(3, "Alien2"),
+					NewAlien(4, "Alien3"),
+					NewAlien(5, "Alien4"),
+					NewAlien(6, "Alien5"),
+					NewAlien(7, "Alien6"),
+					NewAlien(8, "Alien7"),
+					NewAlien(9, "Alien8"),
+					NewAlien(10, "Alien9"),
 				},
-				CityAlienMapping: make(map[string][]string),
-				AlienCityMapping: make(map[string]string),
+				CityAlienMapping: make(map[string][]int),
+				AlienCityMapping: make(map[int]string),
 			},
 			wantErr: false,
 		},
@@ -475,8 +477,8 @@ func TestSimulation_fight(t *testing.T) {
 		World            map[string][]*City
 		Aliens           []*Alien
 		Cities           []*City
-		AlienCityMapping map[string]string
-		CityAlienMapping map[string][]string
+		AlienCityMapping map[int]string
+		CityAlienMapping map[string][]int
 		RandSeed         *rand.Rand
 		logger           *zap.Logger
 	}
@@ -484,7 +486,7 @@ func TestSimulation_fight(t *testing.T) {
 		name            string
 		fields          fields
 		destroyedCity   []string
-		destroyedAliens []string
+		destroyedAliens []int
 	}{
 		{
 			name: "Test alien reach same city",
@@ -505,18 +507,18 @@ func TestSimulation_fight(t *testing.T) {
 						NewCityWithDirections("Foo", "east"),
 					},
 				},
-				Aliens: []*Alien{NewAlien("Alien1"), NewAlien("Alien2")},
-				CityAlienMapping: map[string][]string{
-					"Foo": {"Alien1", "Alien2"},
+				Aliens: []*Alien{NewAlien(1, "Alien1"), NewAlien(2, "Alien2")},
+				CityAlienMapping: map[string][]int{
+					"Foo": {1, 2},
 					"Lee": {},
 					"Bar": {},
 					"Mee": {},
 				},
-				AlienCityMapping: map[string]string{"Alien1": "Foo", "Alien2": "Foo"},
+				AlienCityMapping: map[int]string{1: "Foo", 2: "Foo"},
 				Cities:           []*City{NewCity("Foo"), NewCity("Lee"), NewCity("Bar"), NewCity("Mee")},
 			},
 			destroyedCity:   []string{"Foo"},
-			destroyedAliens: []string{"Alien1", "Alien2"},
+			destroyedAliens: []int{1, 2},
 		},
 		{
 			name: "Test alien reach different city",
@@ -537,18 +539,18 @@ func TestSimulation_fight(t *testing.T) {
 						NewCityWithDirections("Foo", "east"),
 					},
 				},
-				Aliens: []*Alien{NewAlien("Alien1"), NewAlien("Alien2")},
-				CityAlienMapping: map[string][]string{
-					"Foo": {"Alien1"},
-					"Lee": {"Alien2"},
+				Aliens: []*Alien{NewAlien(1, "Alien1"), NewAlien(2, "Alien2")},
+				CityAlienMapping: map[string][]int{
+					"Foo": {1},
+					"Lee": {2},
 					"Bar": {},
 					"Mee": {},
 				},
-				AlienCityMapping: map[string]string{"Alien1": "Foo", "Alien2": "Lee"},
+				AlienCityMapping: map[int]string{1: "Foo", 2: "Lee"},
 				Cities:           []*City{NewCity("Foo"), NewCity("Lee"), NewCity("Bar"), NewCity("Mee")},
 			},
 			destroyedCity:   []string{},
-			destroyedAliens: []string{},
+			destroyedAliens: []int{},
 		},
 	}
 	for _, tt := range tests {
@@ -595,13 +597,13 @@ func TestSimulation_burryDeadAliens(t *testing.T) {
 		World            map[string][]*City
 		Aliens           []*Alien
 		Cities           []*City
-		AlienCityMapping map[string]string
-		CityAlienMapping map[string][]string
+		AlienCityMapping map[int]string
+		CityAlienMapping map[string][]int
 		RandSeed         *rand.Rand
 		logger           *zap.Logger
 	}
 	type args struct {
-		deadAliens []string
+		deadAliens []int
 	}
 	tests := []struct {
 		name   string
@@ -611,25 +613,25 @@ func TestSimulation_burryDeadAliens(t *testing.T) {
 		{
 			name: "Test no Alien dead",
 			fields: fields{
-				Aliens:           []*Alien{NewAlien("Alien1"), NewAlien("Alien2")},
-				AlienCityMapping: map[string]string{"Alien1": "Foo", "Alien2": "Lee"},
+				Aliens:           []*Alien{NewAlien(1, "Alien1"), NewAlien(2, "Alien2")},
+				AlienCityMapping: map[int]string{1: "Foo", 2: "Lee"},
 			},
 		},
 		{
 			name: "Test alien 1 is dead",
 			fields: fields{
-				Aliens:           []*Alien{NewAlien("Alien1"), NewAlien("Alien2")},
-				AlienCityMapping: map[string]string{"Alien1": "Foo", "Alien2": "Lee"},
+				Aliens:           []*Alien{NewAlien(1, "Alien1"), NewAlien(2, "Alien2")},
+				AlienCityMapping: map[int]string{1: "Foo", 2: "Lee"},
 			},
-			args: args{deadAliens: []string{"Alien1"}},
+			args: args{deadAliens: []int{1}},
 		},
 		{
 			name: "Test alien 2 is dead",
 			fields: fields{
-				Aliens:           []*Alien{NewAlien("Alien1"), NewAlien("Alien2")},
-				AlienCityMapping: map[string]string{"Alien1": "Foo", "Alien2": "Lee"},
+				Aliens:           []*Alien{NewAlien(1, "Alien1"), NewAlien(2, "Alien2")},
+				AlienCityMapping: map[int]string{1: "Foo", 2: "Lee"},
 			},
-			args: args{deadAliens: []string{"Alien2"}},
+			args: args{deadAliens: []int{2}},
 		},
 	}
 	for _, tt := range tests {
@@ -668,8 +670,8 @@ func TestSimulation_removeDestroyedCities(t *testing.T) {
 		World            map[string][]*City
 		Aliens           []*Alien
 		Cities           []*City
-		AlienCityMapping map[string]string
-		CityAlienMapping map[string][]string
+		AlienCityMapping map[int]string
+		CityAlienMapping map[string][]int
 		RandSeed         *rand.Rand
 		logger           *zap.Logger
 	}
@@ -701,7 +703,7 @@ func TestSimulation_removeDestroyedCities(t *testing.T) {
 					},
 				},
 				Cities: []*City{NewCity("Foo"), NewCity("Lee"), NewCity("Bar"), NewCity("Mee")},
-				CityAlienMapping: map[string][]string{
+				CityAlienMapping: map[string][]int{
 					"Foo": {},
 					"Lee": {},
 					"Bar": {},
@@ -750,8 +752,8 @@ func TestSimulation_deleteCityFromWorldMap(t *testing.T) {
 		World            map[string][]*City
 		Aliens           []*Alien
 		Cities           []*City
-		AlienCityMapping map[string]string
-		CityAlienMapping map[string][]string
+		AlienCityMapping map[int]string
+		CityAlienMapping map[string][]int
 		RandSeed         *rand.Rand
 		logger           *zap.Logger
 	}
@@ -842,8 +844,8 @@ func TestSimulation_isNextIterationRequired(t *testing.T) {
 		World            map[string][]*City
 		Aliens           []*Alien
 		Cities           []*City
-		AlienCityMapping map[string]string
-		CityAlienMapping map[string][]string
+		AlienCityMapping map[int]string
+		CityAlienMapping map[string][]int
 		RandSeed         *rand.Rand
 		logger           *zap.Logger
 	}
@@ -904,8 +906,8 @@ func TestSimulation_prepareAttack(t *testing.T) {
 		World            map[string][]*City
 		Aliens           []*Alien
 		Cities           []*City
-		AlienCityMapping map[string]string
-		CityAlienMapping map[string][]string
+		AlienCityMapping map[int]string
+		CityAlienMapping map[string][]int
 		RandSeed         *rand.Rand
 		logger           *zap.Logger
 	}
@@ -919,9 +921,9 @@ func TestSimulation_prepareAttack(t *testing.T) {
 			fields: fields{
 				Cities:           []*City{NewCity("Foo"), NewCity("Bar"), NewCity("Lee"), NewCity("Mee")},
 				RandSeed:         rand.New(rand.NewSource(3)),
-				Aliens:           []*Alien{NewAlien("Alien0"), NewAlien("Alien1"), NewAlien("Alien2"), NewAlien("Alien3")},
-				CityAlienMapping: make(map[string][]string),
-				AlienCityMapping: make(map[string]string),
+				Aliens:           []*Alien{NewAlien(1, "Alien0"), NewAlien(2, "Alien1"), NewAlien(3, "Alien2"), NewAlien(4, "Alien3")},
+				CityAlienMapping: make(map[string][]int),
+				AlienCityMapping: make(map[int]string),
 			},
 			wantCity: []string{"Foo", "Bar", "Foo", "Lee"},
 		},
@@ -945,7 +947,7 @@ func TestSimulation_prepareAttack(t *testing.T) {
 			// validate if right city are choosen by the alien
 			// with random seed as 3, the order should be 0, 1, 0, 2
 			for idx := range sim.Aliens {
-				assert.Equal(t, tt.wantCity[idx], sim.AlienCityMapping[sim.Aliens[idx].Name])
+				assert.Equal(t, tt.wantCity[idx], sim.AlienCityMapping[sim.Aliens[idx].ID])
 			}
 		})
 	}
@@ -960,8 +962,8 @@ func TestSimulation_runNextRoundOfAttack(t *testing.T) {
 		World            map[string][]*City
 		Aliens           []*Alien
 		Cities           []*City
-		AlienCityMapping map[string]string
-		CityAlienMapping map[string][]string
+		AlienCityMapping map[int]string
+		CityAlienMapping map[string][]int
 		RandSeed         *rand.Rand
 		logger           *zap.Logger
 	}
@@ -974,7 +976,7 @@ func TestSimulation_runNextRoundOfAttack(t *testing.T) {
 		{
 			name: "Test alien moves",
 			fields: fields{
-				Aliens: []*Alien{NewAlien("Alien1"), NewAlien("Alien2"), NewAlien("Alien3")},
+				Aliens: []*Alien{NewAlien(1, "Alien1"), NewAlien(2, "Alien2"), NewAlien(3, "Alien3")},
 				World: map[string][]*City{
 					"Foo": {
 						NewCityWithDirections("Lee", "north"),
@@ -982,9 +984,9 @@ func TestSimulation_runNextRoundOfAttack(t *testing.T) {
 						NewCityWithDirections("Mee", "west"),
 					},
 				},
-				AlienCityMapping: map[string]string{"Alien1": "Foo", "Alien2": "Foo", "Alien3": "Foo"},
-				CityAlienMapping: map[string][]string{
-					"Foo": {"Alien1", "Alien2", "Alien3"},
+				AlienCityMapping: map[int]string{1: "Foo", 2: "Foo", 3: "Foo"},
+				CityAlienMapping: map[string][]int{
+					"Foo": {1, 2, 3},
 					"Lee": {},
 					"Bar": {},
 					"Mee": {},
@@ -998,7 +1000,7 @@ func TestSimulation_runNextRoundOfAttack(t *testing.T) {
 		{
 			name: "Test alien moves and some stays",
 			fields: fields{
-				Aliens: []*Alien{NewAlien("Alien1"), NewAlien("Alien2"), NewAlien("Alien3"), NewAlien("Alien4")},
+				Aliens: []*Alien{NewAlien(1, "Alien1"), NewAlien(2, "Alien2"), NewAlien(3, "Alien3"), NewAlien(4, "Alien4")},
 				World: map[string][]*City{
 					"Foo": {
 						NewCityWithDirections("Lee", "north"),
@@ -1006,9 +1008,9 @@ func TestSimulation_runNextRoundOfAttack(t *testing.T) {
 						NewCityWithDirections("Mee", "west"),
 					},
 				},
-				AlienCityMapping: map[string]string{"Alien1": "Foo", "Alien2": "Foo", "Alien3": "Foo", "Alien4": "Foo"},
-				CityAlienMapping: map[string][]string{
-					"Foo": {"Alien1", "Alien2", "Alien3", "Alien4"},
+				AlienCityMapping: map[int]string{1: "Foo", 2: "Foo", 3: "Foo", 4: "Foo"},
+				CityAlienMapping: map[string][]int{
+					"Foo": {1, 2, 3, 4},
 					"Lee": {},
 					"Bar": {},
 					"Mee": {},
@@ -1038,9 +1040,9 @@ func TestSimulation_runNextRoundOfAttack(t *testing.T) {
 			sim.runNextRoundOfAttack()
 			for idx, alien := range tt.fields.Aliens {
 				// Check if alien reached the next city as expected
-				assert.Contains(t, sim.CityAlienMapping[tt.alienNextCity[idx]], alien.Name)
+				assert.Contains(t, sim.CityAlienMapping[tt.alienNextCity[idx]], alien.ID)
 				if tt.alienNextCity[idx] != tt.alienCurrentCities[idx] {
-					assert.NotContains(t, sim.CityAlienMapping[tt.alienCurrentCities[idx]], alien.Name)
+					assert.NotContains(t, sim.CityAlienMapping[tt.alienCurrentCities[idx]], alien.ID)
 				}
 			}
 		})
@@ -1056,8 +1058,8 @@ func TestSimulation_EndAndConclude(t *testing.T) {
 		World            map[string][]*City
 		Aliens           []*Alien
 		Cities           []*City
-		AlienCityMapping map[string]string
-		CityAlienMapping map[string][]string
+		AlienCityMapping map[int]string
+		CityAlienMapping map[string][]int
 		RandSeed         *rand.Rand
 		logger           *zap.Logger
 	}
@@ -1102,3 +1104,120 @@ func TestSimulation_EndAndConclude(t *testing.T) {
 		})
 	}
 }
+
+func TestSimulation_alienIdentities(t *testing.T) {
+	tests := []struct {
+		name        string
+		names       string
+		wantAliens  []string
+		wantOutputs []string
+	}{
+		{
+			name:        "Duplicate names are different aliens",
+			names:       "Zork\nZork\nZork\n",
+			wantAliens:  []string{"Zork", "Zork"},
+			wantOutputs: []string{"The Foo was destroyed by Zork and Zork\n"},
+		},
+		{
+			name:        "Aliens without a name are displayed by their ID",
+			wantAliens:  []string{"alien 2", "alien 3"},
+			wantOutputs: []string{"The Foo was destroyed by alien 1 and alien 4\n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			sim, err := NewSimulation(10, 4, "", "", nil, zap.NewNop())
+			assert.NoError(t, err)
+			sim.Output = &out
+			assert.NoError(t, sim.LoadWorld(strings.NewReader("Foo north=Bar\nBar east=Lee")))
+			if tt.names != "" {
+				sim.NumberOfAliens = 3
+				assert.NoError(t, sim.LoadAliens(strings.NewReader(tt.names)))
+				sim.AddAlien("Zork")
+			} else {
+				assert.NoError(t, sim.CreateAliens())
+			}
+			assert.Len(t, sim.Aliens, 4)
+
+			// the first and the last aliens meet in Foo, the others stay apart
+			sim.Round = 1
+			for idx, city := range []string{"Foo", "Bar", "Lee", "Foo"} {
+				sim.CityAlienMapping[city] = append(sim.CityAlienMapping[city], sim.Aliens[idx].ID)
+				sim.AlienCityMapping[sim.Aliens[idx].ID] = city
+			}
+			sim.fight()
+
+			alive := make([]string, 0)
+			for _, alien := range sim.Aliens {
+				alive = append(alive, alien.String())
+				assert.Contains(t, sim.AlienCityMapping, alien.ID)
+			}
+			assert.Equal(t, tt.wantAliens, alive)
+			for _, output := range tt.wantOutputs {
+				assert.Contains(t, out.String(), output)
+			}
+		})
+	}
+}
+
+func TestSimulation_ViewAliens(t *testing.T) {
+	var out strings.Builder
+	sim, err := NewSimulation(10, 2, "", "", nil, zap.NewNop())
+	assert.NoError(t, err)
+	sim.Output = &out
+	sim.AddAlien("Zork")
+	sim.AddAlien("")
+	assert.NoError(t, sim.ViewAliens())
+	assert.Contains(t, out.String(), "The alien 1 has a name Zork\nThe alien 2 has no name\n")
+}
+
+func TestSimulation_LoadAliens(t *testing.T) {
+	roster := "Alpha\nBeta\nGamma\nDelta\n"
+	tests := []struct {
+		name        string
+		aliens      int
+		sampleNames bool
+		wantFirst   []string
+	}{
+		{name: "First names of the roster", aliens: 3, wantFirst: []string{"Alpha", "Beta", "Gamma"}},
+		{name: "Roster runs short", aliens: 6, wantFirst: []string{"Alpha", "Beta", "Gamma", "Delta"}},
+		{name: "Names sampled from the roster", aliens: 4, sampleNames: true},
+		{name: "Sampled roster runs short", aliens: 100, sampleNames: true},
+		{name: "More generated names than syllables combine to", aliens: 20000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			load := func() []string {
+				sim, err := NewSimulation(10, tt.aliens, "", "", nil, zap.NewNop())
+				assert.NoError(t, err)
+				sim.SetSeed(7)
+				sim.SampleNames = tt.sampleNames
+				assert.NoError(t, sim.LoadAliens(strings.NewReader(roster)))
+				names := make([]string, 0, len(sim.Aliens))
+				for _, alien := range sim.Aliens {
+					assert.NotEmpty(t, alien.Name)
+					names = append(names, alien.Name)
+				}
+				return names
+			}
+			names := load()
+			assert.Len(t, names, tt.aliens)
+			assert.Equal(t, names, load())
+			// the roster has no duplicate, the generated names do not repeat any name
+			unique := make(map[string]bool, len(names))
+			for _, name := range names {
+				unique[name] = true
+			}
+			assert.Len(t, unique, len(names))
+			if tt.wantFirst != nil {
+				assert.Equal(t, tt.wantFirst, names[:len(tt.wantFirst)])
+			}
+			if tt.sampleNames {
+				// every name of the roster is taken once before names are generated
+				taken := append([]string(nil), names[:4]...)
+				assert.ElementsMatch(t, []string{"Alpha", "Beta", "Gamma", "Delta"}, taken)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// syllables alien names are made of
+var syllables = []string{
+	"ka", "zor", "bel", "tri", "xo", "gla", "mun", "dra", "vex", "qui", "lo", "ra",
+	"thu", "nek", "pli", "os", "ar", "zu", "fen", "gor", "ix", "ul", "sha", "vo",
+}
+
+// GenerateName returns an alien name of two or three syllables drawn from the source provided
+func GenerateName(r *rand.Rand) string {
+	var name strings.Builder
+	for count := 2 + r.Intn(2); count > 0; count-- {
+		name.WriteString(syllables[r.Intn(len(syllables))])
+	}
+	return strings.ToUpper(name.String()[:1]) + name.String()[1:]
+}
+
+// UniqueName returns the name, followed by the first number making it unique if it is taken already, and takes it
+func UniqueName(name string, taken map[string]bool) string {
+	unique := name
+	for suffix := 2; taken[unique]; suffix++ {
+		unique = name + "-" + strconv.Itoa(suffix)
+	}
+	taken[unique] = true
+	return unique
+}
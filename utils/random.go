@@ -0,0 +1,56 @@
+package utils
+
+/*
+	RandSource is a math/rand source which counts the numbers drawn from it.
+	It is a splitmix64 generator, its whole state is one number which can be saved and restored in constant time.
+*/
+type RandSource struct {
+	seed      int64
+	draws     uint64
+	generator uint64
+}
+
+// NewRandSource returns a source seeded with the seed provided
+func NewRandSource(seed int64) *RandSource {
+	return &RandSource{seed: seed, generator: uint64(seed)}
+}
+
+/*
+	RestoreRandSource returns a source in the state returned by State and Generator, it draws the same numbers as the
+	source the state was taken from.
+*/
+func RestoreRandSource(seed int64, draws uint64, generator uint64) *RandSource {
+	return &RandSource{seed: seed, draws: draws, generator: generator}
+}
+
+// Seed reseeds the source, the draws are counted from the new seed
+func (source *RandSource) Seed(seed int64) {
+	source.seed = seed
+	source.draws = 0
+	source.generator = uint64(seed)
+}
+
+// Int63 returns a non-negative pseudo-random 63-bit integer
+func (source *RandSource) Int63() int64 {
+	return int64(source.Uint64() >> 1)
+}
+
+// Uint64 returns a pseudo-random 64-bit value
+func (source *RandSource) Uint64() uint64 {
+	source.draws++
+	source.generator += 0x9e3779b97f4a7c15
+	z := source.generator
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// State returns the seed and the number of draws since seeding
+func (source *RandSource) State() (int64, uint64) {
+	return source.seed, source.draws
+}
+
+// Generator returns the state of the generator, the next numbers drawn only depend on it
+func (source *RandSource) Generator() uint64 {
+	return source.generator
+}
@@ -0,0 +1,12 @@
+//go:build !windows
+// +build !windows
+
+package utils
+
+import (
+	"os"
+	"syscall"
+)
+
+// CheckpointSignals are the signals requesting a checkpoint of the running simulation
+var CheckpointSignals = []os.Signal{syscall.SIGUSR1}
@@ -0,0 +1,11 @@
+//go:build windows
+// +build windows
+
+package utils
+
+import (
+	"os"
+)
+
+// CheckpointSignals is empty, windows has no user signal, checkpoints can only be requested with -checkpoint-every
+var CheckpointSignals = []os.Signal{}
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"math/rand"
+	"strings"
 	"testing"
 )
 
@@ -69,3 +70,122 @@ func TestGetRandomNumber(t *testing.T) {
 		})
 	}
 }
+
+func TestRestoreRandSource(t *testing.T) {
+	tests := []struct {
+		name  string
+		seed  int64
+		draws int
+	}{
+		{name: "Restore without draws", seed: 3, draws: 0},
+		{name: "Restore after draws", seed: 3, draws: 17},
+		{name: "Restore after many draws", seed: 42, draws: 1000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := NewRandSource(tt.seed)
+			original := rand.New(source)
+			for draw := 0; draw < tt.draws; draw++ {
+				original.Intn(draw + 1)
+			}
+			seed, draws := source.State()
+			restoredSource := RestoreRandSource(seed, draws, source.Generator())
+			restored := rand.New(restoredSource)
+			for draw := 0; draw < 100; draw++ {
+				if got, want := restored.Intn(1000), original.Intn(1000); got != want {
+					t.Fatalf("RestoreRandSource() draw %d = %v, want %v", draw, got, want)
+				}
+			}
+			if gotSeed, gotDraws := restoredSource.State(); gotSeed != tt.seed || gotDraws != draws+100 {
+				t.Fatalf("RestoreRandSource() state = %v, %v, want %v, %v", gotSeed, gotDraws, tt.seed, draws+100)
+			}
+		})
+	}
+}
+
+func TestRandSource_Seed(t *testing.T) {
+	source := NewRandSource(7)
+	first := source.Uint64()
+	source.Uint64()
+	source.Seed(7)
+	if got := source.Uint64(); got != first {
+		t.Fatalf("Uint64() after Seed() = %v, want %v", got, first)
+	}
+	if _, draws := source.State(); draws != 1 {
+		t.Fatalf("draws = %v, want 1", draws)
+	}
+	if got := NewRandSource(8).Uint64(); got == first {
+		t.Fatalf("Uint64() with another seed = %v, want a different number", got)
+	}
+}
+
+func TestGenerateName(t *testing.T) {
+	tests := []struct {
+		name string
+		seed int64
+	}{
+		{name: "Seed 3", seed: 3},
+		{name: "Seed 42", seed: 42},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first, second := rand.New(rand.NewSource(tt.seed)), rand.New(rand.NewSource(tt.seed))
+			for draw := 0; draw < 100; draw++ {
+				got := GenerateName(first)
+				if want := GenerateName(second); got != want {
+					t.Fatalf("GenerateName() draw %d = %v, want %v", draw, got, want)
+				}
+				if got == "" || got[0] < 'A' || got[0] > 'Z' || strings.ContainsAny(got, " \n") {
+					t.Fatalf("GenerateName() = %q, want a capitalised name without spaces", got)
+				}
+			}
+		})
+	}
+}
+
+func TestUniqueName(t *testing.T) {
+	taken := map[string]bool{"Kazor": true, "Kazor-2": true}
+	tests := []struct {
+		name  string
+		taken string
+		want  string
+	}{
+		{name: "Free name", taken: "Bel", want: "Bel"},
+		{name: "Taken name", taken: "Kazor", want: "Kazor-3"},
+		{name: "Taken again", taken: "Kazor", want: "Kazor-4"},
+		{name: "Free name taken", taken: "Bel", want: "Bel-2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := UniqueName(tt.taken, taken); got != tt.want {
+				t.Errorf("UniqueName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateInput(t *testing.T) {
+	tests := []struct {
+		name       string
+		iterations int
+		aliens     int
+		names      string
+		world      string
+		wantErr    bool
+	}{
+		{name: "Valid input", iterations: 10, aliens: 2, names: "../data/alien_names.txt", world: "../data/world-example-1.txt"},
+		{name: "Without names", iterations: 10, aliens: 2, world: "../data/world-example-1.txt"},
+		{name: "Negative iterations", iterations: -1, aliens: 2, world: "../data/world-example-1.txt", wantErr: true},
+		{name: "Negative aliens", iterations: 10, aliens: -2, world: "../data/world-example-1.txt", wantErr: true},
+		{name: "Missing names", iterations: 10, aliens: 2, names: "../data/nope.txt", world: "../data/world-example-1.txt", wantErr: true},
+		{name: "Missing world", iterations: 10, aliens: 2, world: "../data/nope.txt", wantErr: true},
+		{name: "World directory", iterations: 10, aliens: 2, world: "../data", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateInput(tt.iterations, tt.aliens, tt.names, tt.world); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateInput() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}